@@ -0,0 +1,4 @@
+// Package ocrv1 is the generated grpc api of the ocr module, regenerate it after a change of ocr.proto
+package ocrv1
+
+//go:generate protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative api/ocr/v1/ocr.proto
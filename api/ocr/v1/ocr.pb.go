@@ -0,0 +1,1174 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: api/ocr/v1/ocr.proto
+
+package ocrv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RecognizeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileName    string `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Image       []byte `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	HocrEnabled bool   `protobuf:"varint,3,opt,name=hocr_enabled,json=hocrEnabled,proto3" json:"hocr_enabled,omitempty"`
+	// type is the document type profile, generic when it is empty
+	Type string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	// zones are read instead of the whole page, they replace the zones of the profile
+	Zones []*OcrZone `protobuf:"bytes,5,rep,name=zones,proto3" json:"zones,omitempty"`
+	// full_text reads the whole page besides the zones
+	FullText bool `protobuf:"varint,6,opt,name=full_text,json=fullText,proto3" json:"full_text,omitempty"`
+}
+
+func (x *RecognizeRequest) Reset() {
+	*x = RecognizeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RecognizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecognizeRequest) ProtoMessage() {}
+
+func (x *RecognizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecognizeRequest.ProtoReflect.Descriptor instead.
+func (*RecognizeRequest) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RecognizeRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *RecognizeRequest) GetImage() []byte {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *RecognizeRequest) GetHocrEnabled() bool {
+	if x != nil {
+		return x.HocrEnabled
+	}
+	return false
+}
+
+func (x *RecognizeRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RecognizeRequest) GetZones() []*OcrZone {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+func (x *RecognizeRequest) GetFullText() bool {
+	if x != nil {
+		return x.FullText
+	}
+	return false
+}
+
+// RecognizeChunk is a part of the image, the file name, the hocr flag and the type are read from the first chunk
+type RecognizeChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileName    string     `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	HocrEnabled bool       `protobuf:"varint,2,opt,name=hocr_enabled,json=hocrEnabled,proto3" json:"hocr_enabled,omitempty"`
+	Data        []byte     `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Type        string     `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Zones       []*OcrZone `protobuf:"bytes,5,rep,name=zones,proto3" json:"zones,omitempty"`
+	FullText    bool       `protobuf:"varint,6,opt,name=full_text,json=fullText,proto3" json:"full_text,omitempty"`
+}
+
+func (x *RecognizeChunk) Reset() {
+	*x = RecognizeChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RecognizeChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecognizeChunk) ProtoMessage() {}
+
+func (x *RecognizeChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecognizeChunk.ProtoReflect.Descriptor instead.
+func (*RecognizeChunk) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RecognizeChunk) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *RecognizeChunk) GetHocrEnabled() bool {
+	if x != nil {
+		return x.HocrEnabled
+	}
+	return false
+}
+
+func (x *RecognizeChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *RecognizeChunk) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RecognizeChunk) GetZones() []*OcrZone {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+func (x *RecognizeChunk) GetFullText() bool {
+	if x != nil {
+		return x.FullText
+	}
+	return false
+}
+
+type GetResultRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetResultRequest) Reset() {
+	*x = GetResultRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResultRequest) ProtoMessage() {}
+
+func (x *GetResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResultRequest.ProtoReflect.Descriptor instead.
+func (*GetResultRequest) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetResultRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListResultsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Page         int32  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize     int32  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Text         string `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	Status       string `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	SortBy       string `protobuf:"bytes,5,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	SortOrder    string `protobuf:"bytes,6,opt,name=sort_order,json=sortOrder,proto3" json:"sort_order,omitempty"`
+	DocumentType string `protobuf:"bytes,7,opt,name=document_type,json=documentType,proto3" json:"document_type,omitempty"`
+}
+
+func (x *ListResultsRequest) Reset() {
+	*x = ListResultsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResultsRequest) ProtoMessage() {}
+
+func (x *ListResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResultsRequest.ProtoReflect.Descriptor instead.
+func (*ListResultsRequest) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListResultsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListResultsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListResultsRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ListResultsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListResultsRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListResultsRequest) GetSortOrder() string {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ""
+}
+
+func (x *ListResultsRequest) GetDocumentType() string {
+	if x != nil {
+		return x.DocumentType
+	}
+	return ""
+}
+
+type ListResultsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*OcrResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Total   int64        `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+}
+
+func (x *ListResultsResponse) Reset() {
+	*x = ListResultsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListResultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResultsResponse) ProtoMessage() {}
+
+func (x *ListResultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResultsResponse.ProtoReflect.Descriptor instead.
+func (*ListResultsResponse) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListResultsResponse) GetResults() []*OcrResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *ListResultsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type OcrResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id           int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	TenantId     string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	ImageUrl     string                 `protobuf:"bytes,3,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	Text         string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	Status       string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedBy    string                 `protobuf:"bytes,6,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	DocumentType string                 `protobuf:"bytes,9,opt,name=document_type,json=documentType,proto3" json:"document_type,omitempty"`
+	Fields       []*OcrField            `protobuf:"bytes,10,rep,name=fields,proto3" json:"fields,omitempty"`
+	// zones are the results of the zones keyed by their name
+	Zones map[string]*OcrZoneResult `protobuf:"bytes,11,rep,name=zones,proto3" json:"zones,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *OcrResult) Reset() {
+	*x = OcrResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OcrResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OcrResult) ProtoMessage() {}
+
+func (x *OcrResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OcrResult.ProtoReflect.Descriptor instead.
+func (*OcrResult) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OcrResult) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *OcrResult) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *OcrResult) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+func (x *OcrResult) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *OcrResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *OcrResult) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *OcrResult) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *OcrResult) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *OcrResult) GetDocumentType() string {
+	if x != nil {
+		return x.DocumentType
+	}
+	return ""
+}
+
+func (x *OcrResult) GetFields() []*OcrField {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *OcrResult) GetZones() map[string]*OcrZoneResult {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+// OcrField is a value extracted by the profile of the document type or by its templates
+type OcrField struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// confidence is the mean of the words of the value, 0 when they are unknown
+	Confidence float64 `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	// bbox is the box of the words of the value, unset when they are unknown
+	Bbox *OcrBox `protobuf:"bytes,4,opt,name=bbox,proto3" json:"bbox,omitempty"`
+}
+
+func (x *OcrField) Reset() {
+	*x = OcrField{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OcrField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OcrField) ProtoMessage() {}
+
+func (x *OcrField) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OcrField.ProtoReflect.Descriptor instead.
+func (*OcrField) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *OcrField) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OcrField) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *OcrField) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *OcrField) GetBbox() *OcrBox {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+// OcrBox is a rectangle in pixels from the top left of the image
+type OcrBox struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X      int32 `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y      int32 `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width  int32 `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height int32 `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (x *OcrBox) Reset() {
+	*x = OcrBox{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OcrBox) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OcrBox) ProtoMessage() {}
+
+func (x *OcrBox) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OcrBox.ProtoReflect.Descriptor instead.
+func (*OcrBox) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *OcrBox) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *OcrBox) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *OcrBox) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *OcrBox) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// OcrZone is a named rectangle read on its own, in fractions of the page from the top left unless the unit is pixel
+type OcrZone struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name   string  `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	X      float64 `protobuf:"fixed64,2,opt,name=x,proto3" json:"x,omitempty"`
+	Y      float64 `protobuf:"fixed64,3,opt,name=y,proto3" json:"y,omitempty"`
+	Width  float64 `protobuf:"fixed64,4,opt,name=width,proto3" json:"width,omitempty"`
+	Height float64 `protobuf:"fixed64,5,opt,name=height,proto3" json:"height,omitempty"`
+	Unit   string  `protobuf:"bytes,6,opt,name=unit,proto3" json:"unit,omitempty"`
+	// psm is the page segmentation mode of the zone, 0 keeps the one of the recognition
+	Psm       int32  `protobuf:"varint,7,opt,name=psm,proto3" json:"psm,omitempty"`
+	Whitelist string `protobuf:"bytes,8,opt,name=whitelist,proto3" json:"whitelist,omitempty"`
+}
+
+func (x *OcrZone) Reset() {
+	*x = OcrZone{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OcrZone) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OcrZone) ProtoMessage() {}
+
+func (x *OcrZone) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OcrZone.ProtoReflect.Descriptor instead.
+func (*OcrZone) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *OcrZone) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OcrZone) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *OcrZone) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *OcrZone) GetWidth() float64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *OcrZone) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *OcrZone) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *OcrZone) GetPsm() int32 {
+	if x != nil {
+		return x.Psm
+	}
+	return 0
+}
+
+func (x *OcrZone) GetWhitelist() string {
+	if x != nil {
+		return x.Whitelist
+	}
+	return ""
+}
+
+// OcrZoneResult is the text read in a zone, the box is the zone in pixels clipped to the image
+type OcrZoneResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Text       string  `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Confidence float64 `protobuf:"fixed64,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Bbox       *OcrBox `protobuf:"bytes,3,opt,name=bbox,proto3" json:"bbox,omitempty"`
+}
+
+func (x *OcrZoneResult) Reset() {
+	*x = OcrZoneResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_ocr_v1_ocr_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OcrZoneResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OcrZoneResult) ProtoMessage() {}
+
+func (x *OcrZoneResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ocr_v1_ocr_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OcrZoneResult.ProtoReflect.Descriptor instead.
+func (*OcrZoneResult) Descriptor() ([]byte, []int) {
+	return file_api_ocr_v1_ocr_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *OcrZoneResult) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *OcrZoneResult) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *OcrZoneResult) GetBbox() *OcrBox {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+var File_api_ocr_v1_ocr_proto protoreflect.FileDescriptor
+
+var file_api_ocr_v1_ocr_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x61, 0x70, 0x69, 0x2f, 0x6f, 0x63, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x6f, 0x63, 0x72,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1f,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xc0, 0x01, 0x0a, 0x10, 0x52, 0x65, 0x63, 0x6f, 0x67, 0x6e, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x68, 0x6f, 0x63, 0x72, 0x5f,
+	0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x68,
+	0x6f, 0x63, 0x72, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x25,
+	0x0a, 0x05, 0x7a, 0x6f, 0x6e, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
+	0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x63, 0x72, 0x5a, 0x6f, 0x6e, 0x65, 0x52, 0x05,
+	0x7a, 0x6f, 0x6e, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x75, 0x6c, 0x6c, 0x5f, 0x74, 0x65,
+	0x78, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x66, 0x75, 0x6c, 0x6c, 0x54, 0x65,
+	0x78, 0x74, 0x22, 0xbc, 0x01, 0x0a, 0x0e, 0x52, 0x65, 0x63, 0x6f, 0x67, 0x6e, 0x69, 0x7a, 0x65,
+	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x68, 0x6f, 0x63, 0x72, 0x5f, 0x65, 0x6e, 0x61, 0x62, 0x6c,
+	0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x68, 0x6f, 0x63, 0x72, 0x45, 0x6e,
+	0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x25, 0x0a,
+	0x05, 0x7a, 0x6f, 0x6e, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6f,
+	0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x63, 0x72, 0x5a, 0x6f, 0x6e, 0x65, 0x52, 0x05, 0x7a,
+	0x6f, 0x6e, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x75, 0x6c, 0x6c, 0x5f, 0x74, 0x65, 0x78,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x66, 0x75, 0x6c, 0x6c, 0x54, 0x65, 0x78,
+	0x74, 0x22, 0x22, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x02, 0x69, 0x64, 0x22, 0xce, 0x01, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x70, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70, 0x61, 0x67, 0x65,
+	0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x6f, 0x72,
+	0x74, 0x5f, 0x62, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x72, 0x74,
+	0x42, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x6f, 0x72, 0x74, 0x5f, 0x6f, 0x72, 0x64, 0x65, 0x72,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x6f, 0x72, 0x74, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65,
+	0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x22, 0x58, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a,
+	0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x63, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x22, 0xea, 0x03, 0x0a, 0x09, 0x4f, 0x63, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1b,
+	0x0a, 0x09, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x69,
+	0x6d, 0x61, 0x67, 0x65, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x69, 0x6d, 0x61, 0x67, 0x65, 0x55, 0x72, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f,
+	0x62, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65,
+	0x64, 0x42, 0x79, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61,
+	0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x39,
+	0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09,
+	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x6f, 0x63,
+	0x75, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0c, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x28,
+	0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x63, 0x72, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x52, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x32, 0x0a, 0x05, 0x7a, 0x6f, 0x6e, 0x65,
+	0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x4f, 0x63, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x2e, 0x5a, 0x6f, 0x6e, 0x65, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x7a, 0x6f, 0x6e, 0x65, 0x73, 0x1a, 0x4f, 0x0a, 0x0a,
+	0x5a, 0x6f, 0x6e, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2b, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6f, 0x63,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x63, 0x72, 0x5a, 0x6f, 0x6e, 0x65, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x78, 0x0a,
+	0x08, 0x4f, 0x63, 0x72, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65,
+	0x6e, 0x63, 0x65, 0x12, 0x22, 0x0a, 0x04, 0x62, 0x62, 0x6f, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0e, 0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x63, 0x72, 0x42, 0x6f,
+	0x78, 0x52, 0x04, 0x62, 0x62, 0x6f, 0x78, 0x22, 0x52, 0x0a, 0x06, 0x4f, 0x63, 0x72, 0x42, 0x6f,
+	0x78, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x78, 0x12,
+	0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x69,
+	0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0xab, 0x01, 0x0a, 0x07,
+	0x4f, 0x63, 0x72, 0x5a, 0x6f, 0x6e, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x0c, 0x0a, 0x01, 0x78,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a,
+	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x68,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x73, 0x6d,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x70, 0x73, 0x6d, 0x12, 0x1c, 0x0a, 0x09, 0x77,
+	0x68, 0x69, 0x74, 0x65, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x77, 0x68, 0x69, 0x74, 0x65, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x67, 0x0a, 0x0d, 0x4f, 0x63, 0x72,
+	0x5a, 0x6f, 0x6e, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65,
+	0x78, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x1e,
+	0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x22,
+	0x0a, 0x04, 0x62, 0x62, 0x6f, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6f,
+	0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x63, 0x72, 0x42, 0x6f, 0x78, 0x52, 0x04, 0x62, 0x62,
+	0x6f, 0x78, 0x32, 0x88, 0x02, 0x0a, 0x0a, 0x4f, 0x63, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x38, 0x0a, 0x09, 0x52, 0x65, 0x63, 0x6f, 0x67, 0x6e, 0x69, 0x7a, 0x65, 0x12, 0x18,
+	0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x67, 0x6e, 0x69, 0x7a,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x4f, 0x63, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x3e, 0x0a, 0x0f, 0x52,
+	0x65, 0x63, 0x6f, 0x67, 0x6e, 0x69, 0x7a, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x16,
+	0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x67, 0x6e, 0x69, 0x7a,
+	0x65, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x11, 0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x4f, 0x63, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x28, 0x01, 0x12, 0x38, 0x0a, 0x09, 0x47,
+	0x65, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x18, 0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x11, 0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x63, 0x72, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x46, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x73, 0x12, 0x1a, 0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1b, 0x2e, 0x6f, 0x63, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x19, 0x5a,
+	0x17, 0x67, 0x6f, 0x2d, 0x6f, 0x63, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x6f, 0x63, 0x72, 0x2f,
+	0x76, 0x31, 0x3b, 0x6f, 0x63, 0x72, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_api_ocr_v1_ocr_proto_rawDescOnce sync.Once
+	file_api_ocr_v1_ocr_proto_rawDescData = file_api_ocr_v1_ocr_proto_rawDesc
+)
+
+func file_api_ocr_v1_ocr_proto_rawDescGZIP() []byte {
+	file_api_ocr_v1_ocr_proto_rawDescOnce.Do(func() {
+		file_api_ocr_v1_ocr_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_ocr_v1_ocr_proto_rawDescData)
+	})
+	return file_api_ocr_v1_ocr_proto_rawDescData
+}
+
+var file_api_ocr_v1_ocr_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_api_ocr_v1_ocr_proto_goTypes = []any{
+	(*RecognizeRequest)(nil),      // 0: ocr.v1.RecognizeRequest
+	(*RecognizeChunk)(nil),        // 1: ocr.v1.RecognizeChunk
+	(*GetResultRequest)(nil),      // 2: ocr.v1.GetResultRequest
+	(*ListResultsRequest)(nil),    // 3: ocr.v1.ListResultsRequest
+	(*ListResultsResponse)(nil),   // 4: ocr.v1.ListResultsResponse
+	(*OcrResult)(nil),             // 5: ocr.v1.OcrResult
+	(*OcrField)(nil),              // 6: ocr.v1.OcrField
+	(*OcrBox)(nil),                // 7: ocr.v1.OcrBox
+	(*OcrZone)(nil),               // 8: ocr.v1.OcrZone
+	(*OcrZoneResult)(nil),         // 9: ocr.v1.OcrZoneResult
+	nil,                           // 10: ocr.v1.OcrResult.ZonesEntry
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_api_ocr_v1_ocr_proto_depIdxs = []int32{
+	8,  // 0: ocr.v1.RecognizeRequest.zones:type_name -> ocr.v1.OcrZone
+	8,  // 1: ocr.v1.RecognizeChunk.zones:type_name -> ocr.v1.OcrZone
+	5,  // 2: ocr.v1.ListResultsResponse.results:type_name -> ocr.v1.OcrResult
+	11, // 3: ocr.v1.OcrResult.created_at:type_name -> google.protobuf.Timestamp
+	11, // 4: ocr.v1.OcrResult.updated_at:type_name -> google.protobuf.Timestamp
+	6,  // 5: ocr.v1.OcrResult.fields:type_name -> ocr.v1.OcrField
+	10, // 6: ocr.v1.OcrResult.zones:type_name -> ocr.v1.OcrResult.ZonesEntry
+	7,  // 7: ocr.v1.OcrField.bbox:type_name -> ocr.v1.OcrBox
+	7,  // 8: ocr.v1.OcrZoneResult.bbox:type_name -> ocr.v1.OcrBox
+	9,  // 9: ocr.v1.OcrResult.ZonesEntry.value:type_name -> ocr.v1.OcrZoneResult
+	0,  // 10: ocr.v1.OcrService.Recognize:input_type -> ocr.v1.RecognizeRequest
+	1,  // 11: ocr.v1.OcrService.RecognizeStream:input_type -> ocr.v1.RecognizeChunk
+	2,  // 12: ocr.v1.OcrService.GetResult:input_type -> ocr.v1.GetResultRequest
+	3,  // 13: ocr.v1.OcrService.ListResults:input_type -> ocr.v1.ListResultsRequest
+	5,  // 14: ocr.v1.OcrService.Recognize:output_type -> ocr.v1.OcrResult
+	5,  // 15: ocr.v1.OcrService.RecognizeStream:output_type -> ocr.v1.OcrResult
+	5,  // 16: ocr.v1.OcrService.GetResult:output_type -> ocr.v1.OcrResult
+	4,  // 17: ocr.v1.OcrService.ListResults:output_type -> ocr.v1.ListResultsResponse
+	14, // [14:18] is the sub-list for method output_type
+	10, // [10:14] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_api_ocr_v1_ocr_proto_init() }
+func file_api_ocr_v1_ocr_proto_init() {
+	if File_api_ocr_v1_ocr_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_api_ocr_v1_ocr_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*RecognizeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*RecognizeChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*GetResultRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*ListResultsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*ListResultsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*OcrResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*OcrField); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*OcrBox); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*OcrZone); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_ocr_v1_ocr_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*OcrZoneResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_api_ocr_v1_ocr_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_ocr_v1_ocr_proto_goTypes,
+		DependencyIndexes: file_api_ocr_v1_ocr_proto_depIdxs,
+		MessageInfos:      file_api_ocr_v1_ocr_proto_msgTypes,
+	}.Build()
+	File_api_ocr_v1_ocr_proto = out.File
+	file_api_ocr_v1_ocr_proto_rawDesc = nil
+	file_api_ocr_v1_ocr_proto_goTypes = nil
+	file_api_ocr_v1_ocr_proto_depIdxs = nil
+}
@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/ocr/v1/ocr.proto
+
+package ocrv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OcrService_Recognize_FullMethodName       = "/ocr.v1.OcrService/Recognize"
+	OcrService_RecognizeStream_FullMethodName = "/ocr.v1.OcrService/RecognizeStream"
+	OcrService_GetResult_FullMethodName       = "/ocr.v1.OcrService/GetResult"
+	OcrService_ListResults_FullMethodName     = "/ocr.v1.OcrService/ListResults"
+)
+
+// OcrServiceClient is the client API for OcrService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// OcrService is the grpc api of the ocr module, it shares the service of the rest api
+type OcrServiceClient interface {
+	// Recognize runs the ocr on an image sent in one message
+	Recognize(ctx context.Context, in *RecognizeRequest, opts ...grpc.CallOption) (*OcrResult, error)
+	// RecognizeStream runs the ocr on an image sent in chunks, for the large files
+	RecognizeStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RecognizeChunk, OcrResult], error)
+	// GetResult returns a stored result by id
+	GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*OcrResult, error)
+	// ListResults returns a page of the stored results
+	ListResults(ctx context.Context, in *ListResultsRequest, opts ...grpc.CallOption) (*ListResultsResponse, error)
+}
+
+type ocrServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOcrServiceClient(cc grpc.ClientConnInterface) OcrServiceClient {
+	return &ocrServiceClient{cc}
+}
+
+func (c *ocrServiceClient) Recognize(ctx context.Context, in *RecognizeRequest, opts ...grpc.CallOption) (*OcrResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OcrResult)
+	err := c.cc.Invoke(ctx, OcrService_Recognize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ocrServiceClient) RecognizeStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RecognizeChunk, OcrResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OcrService_ServiceDesc.Streams[0], OcrService_RecognizeStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RecognizeChunk, OcrResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OcrService_RecognizeStreamClient = grpc.ClientStreamingClient[RecognizeChunk, OcrResult]
+
+func (c *ocrServiceClient) GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*OcrResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OcrResult)
+	err := c.cc.Invoke(ctx, OcrService_GetResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ocrServiceClient) ListResults(ctx context.Context, in *ListResultsRequest, opts ...grpc.CallOption) (*ListResultsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListResultsResponse)
+	err := c.cc.Invoke(ctx, OcrService_ListResults_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OcrServiceServer is the server API for OcrService service.
+// All implementations must embed UnimplementedOcrServiceServer
+// for forward compatibility.
+//
+// OcrService is the grpc api of the ocr module, it shares the service of the rest api
+type OcrServiceServer interface {
+	// Recognize runs the ocr on an image sent in one message
+	Recognize(context.Context, *RecognizeRequest) (*OcrResult, error)
+	// RecognizeStream runs the ocr on an image sent in chunks, for the large files
+	RecognizeStream(grpc.ClientStreamingServer[RecognizeChunk, OcrResult]) error
+	// GetResult returns a stored result by id
+	GetResult(context.Context, *GetResultRequest) (*OcrResult, error)
+	// ListResults returns a page of the stored results
+	ListResults(context.Context, *ListResultsRequest) (*ListResultsResponse, error)
+	mustEmbedUnimplementedOcrServiceServer()
+}
+
+// UnimplementedOcrServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOcrServiceServer struct{}
+
+func (UnimplementedOcrServiceServer) Recognize(context.Context, *RecognizeRequest) (*OcrResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Recognize not implemented")
+}
+func (UnimplementedOcrServiceServer) RecognizeStream(grpc.ClientStreamingServer[RecognizeChunk, OcrResult]) error {
+	return status.Errorf(codes.Unimplemented, "method RecognizeStream not implemented")
+}
+func (UnimplementedOcrServiceServer) GetResult(context.Context, *GetResultRequest) (*OcrResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResult not implemented")
+}
+func (UnimplementedOcrServiceServer) ListResults(context.Context, *ListResultsRequest) (*ListResultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListResults not implemented")
+}
+func (UnimplementedOcrServiceServer) mustEmbedUnimplementedOcrServiceServer() {}
+func (UnimplementedOcrServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeOcrServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OcrServiceServer will
+// result in compilation errors.
+type UnsafeOcrServiceServer interface {
+	mustEmbedUnimplementedOcrServiceServer()
+}
+
+func RegisterOcrServiceServer(s grpc.ServiceRegistrar, srv OcrServiceServer) {
+	// If the following call pancis, it indicates UnimplementedOcrServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OcrService_ServiceDesc, srv)
+}
+
+func _OcrService_Recognize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecognizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OcrServiceServer).Recognize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OcrService_Recognize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OcrServiceServer).Recognize(ctx, req.(*RecognizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OcrService_RecognizeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(OcrServiceServer).RecognizeStream(&grpc.GenericServerStream[RecognizeChunk, OcrResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OcrService_RecognizeStreamServer = grpc.ClientStreamingServer[RecognizeChunk, OcrResult]
+
+func _OcrService_GetResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OcrServiceServer).GetResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OcrService_GetResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OcrServiceServer).GetResult(ctx, req.(*GetResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OcrService_ListResults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListResultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OcrServiceServer).ListResults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OcrService_ListResults_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OcrServiceServer).ListResults(ctx, req.(*ListResultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OcrService_ServiceDesc is the grpc.ServiceDesc for OcrService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OcrService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ocr.v1.OcrService",
+	HandlerType: (*OcrServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Recognize",
+			Handler:    _OcrService_Recognize_Handler,
+		},
+		{
+			MethodName: "GetResult",
+			Handler:    _OcrService_GetResult_Handler,
+		},
+		{
+			MethodName: "ListResults",
+			Handler:    _OcrService_ListResults_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RecognizeStream",
+			Handler:       _OcrService_RecognizeStream_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "api/ocr/v1/ocr.proto",
+}
@@ -0,0 +1,21 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	swaggerFiles "github.com/swaggo/files"
+)
+
+// Spec is the OpenAPI 3 document of the http routes, keep it in sync with the router
+//
+//go:embed openapi.json
+var Spec []byte
+
+// SwaggerUI is the page rendering the Spec, its assets are served from SwaggerAssets
+//
+//go:embed swagger.html
+var SwaggerUI []byte
+
+// SwaggerAssets are the embedded swagger ui files
+var SwaggerAssets http.FileSystem = swaggerFiles.HTTP
@@ -1,26 +1,46 @@
 package boot
 
 import (
+	"context"
 	"os"
+	"time"
 
+	"go-ocr/infrastructure/auth"
 	"go-ocr/infrastructure/config"
 	"go-ocr/infrastructure/database"
+	"go-ocr/infrastructure/events"
+	"go-ocr/infrastructure/idempotency"
 	"go-ocr/infrastructure/limiter"
 	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/migration"
 	"go-ocr/infrastructure/redis"
+	"go-ocr/infrastructure/tenant"
 	tesseractsClient "go-ocr/infrastructure/tesseracts-client"
+	"go-ocr/infrastructure/tracing"
+	"go-ocr/modules/apikey"
 	"go-ocr/modules/health"
 	"go-ocr/modules/ocr"
+	"go-ocr/modules/template"
 	"go-ocr/utils"
 
 	redisThirdPartyLib "github.com/go-redis/redis"
+	"github.com/gookit/event"
 	log "github.com/sirupsen/logrus"
 )
 
 type HandlerSetup struct {
-	Limiter    *limiter.RateLimiter
-	HealthHttp health.InterfaceHttp
-	OcrHttp    ocr.InterfaceHttp
+	Limiter          limiter.LimiterInterface
+	IpLimiter        limiter.LimiterInterface
+	TenantLimiters   map[string]limiter.LimiterInterface
+	IdempotencyStore idempotency.Store
+	Authenticator    auth.Authenticator
+	JwtVerifier      *auth.JwtVerifier
+	HealthHttp       health.InterfaceHttp
+	OcrService       ocr.ServiceInterface
+	OcrHttp          ocr.InterfaceHttp
+	OcrGrpc          ocr.InterfaceGrpc
+	ApiKeyHttp       apikey.InterfaceHttp
+	TemplateHttp     template.InterfaceHttp
 }
 
 func MakeHandler() HandlerSetup {
@@ -30,8 +50,24 @@ func MakeHandler() HandlerSetup {
 	//initiate logger
 	logger.Init(config.Conf.LogFormat, config.Conf.LogLevel)
 
+	//stop on an invalid config instead of falling back to the defaults
+	if err := config.Validate(config.Conf, tesseractsClient.AvailableLanguages()); err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+
 	var err error
 
+	//initiate tracing, flush the remaining spans on shutdown
+	shutdownTracing, err := tracing.Init(&config.Conf)
+	if err != nil {
+		log.Fatalf("failed initiate tracing: %v", err)
+		os.Exit(1)
+	}
+	event.On(utils.ShutDownEvent, event.ListenerFunc(func(e event.Event) error {
+		return shutdownTracing(context.Background())
+	}))
+
 	//initiate a redis client
 	var redisClient *redisThirdPartyLib.Client
 	var redisLibInterface redis.LibInterface
@@ -57,11 +93,116 @@ func MakeHandler() HandlerSetup {
 			log.Fatalf("failed initiate database postgres: %v", err)
 			os.Exit(1)
 		}
+		//apply the pending migrations before serving
+		if config.Conf.Postgres.AutoMigrate {
+			if err = migrate(context.Background(), db, migration.DirectionUp, 0); err != nil {
+				log.Fatalf("failed migrate database postgres: %v", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	//setup infrastructure sqlite, the embedded database when postgres is not enabled
+	if useSqlite() {
+		db, err = database.NewSqliteClient(&config.Conf)
+		if err != nil {
+			log.Fatalf("failed initiate database sqlite: %v", err)
+			os.Exit(1)
+		}
+		//apply the pending migrations before serving
+		if config.Conf.Sqlite.AutoMigrate {
+			if err = migrate(context.Background(), db, migration.DirectionUp, 0); err != nil {
+				log.Fatalf("failed migrate database sqlite: %v", err)
+				os.Exit(1)
+			}
+		}
 	}
 
-	//add limiter
+	//add limiter, use redis to share the limit across instances when configured
+	if config.Conf.RateLimiter == limiter.BackendRedis && !config.Conf.Redis.EnableRedis {
+		log.Warn("rate limiter redis is configured but redis is not enabled, fallback to in process limiter")
+	}
+	newLimiter := func(rate int, interval time.Duration, burst int) limiter.LimiterInterface {
+		if config.Conf.RateLimiter == limiter.BackendRedis && config.Conf.Redis.EnableRedis {
+			return limiter.NewRedisRateLimiter(redisClient, rate, interval, burst)
+		}
+		return limiter.NewRateLimiter(rate, interval, burst)
+	}
 	interval := utils.StringUnitToDuration(config.Conf.Interval)
-	middlewareWithLimiter := limiter.NewRateLimiter(int(config.Conf.Rate), interval)
+	middlewareWithLimiter := newLimiter(int(config.Conf.Rate), interval, int(config.Conf.Burst))
+
+	//the limit by ip runs before the auth, it has its own buckets so a caller isn't charged twice
+	ipRate, ipBurst := config.Conf.IpRateLimit()
+	ipLimiter := newLimiter(ipRate, interval, ipBurst)
+
+	//apply the reloaded config live, the other fields are logged as restart required
+	config.OnReload(func(previous, current config.Config) {
+		if previous.LogFormat != current.LogFormat || previous.LogLevel != current.LogLevel {
+			logger.Init(current.LogFormat, current.LogLevel)
+			log.Infof("log level %s and format %s applied", current.LogLevel, current.LogFormat)
+		}
+		if previous.Rate != current.Rate || previous.Interval != current.Interval || previous.Burst != current.Burst {
+			middlewareWithLimiter.Update(int(current.Rate), utils.StringUnitToDuration(current.Interval), int(current.Burst))
+			log.Infof("rate limit %d per %s with burst %d applied", current.Rate, current.Interval, current.Burst)
+		}
+		previousIpRate, previousIpBurst := previous.IpRateLimit()
+		currentIpRate, currentIpBurst := current.IpRateLimit()
+		if previousIpRate != currentIpRate || previous.Interval != current.Interval || previousIpBurst != currentIpBurst {
+			ipLimiter.Update(currentIpRate, utils.StringUnitToDuration(current.Interval), currentIpBurst)
+			log.Infof("rate limit by ip %d per %s with burst %d applied", currentIpRate, current.Interval, currentIpBurst)
+		}
+	})
+	config.Watch(tesseractsClient.AvailableLanguages)
+
+	//add limiter for the tenants that override the rate
+	tenantLimiters := make(map[string]limiter.LimiterInterface)
+	for _, tenantConfig := range config.Conf.Tenants {
+		settings := tenant.SettingsFor(tenantConfig.ID)
+		if settings.Rate <= 0 {
+			continue
+		}
+		tenantInterval := interval
+		if settings.Interval != "" {
+			tenantInterval = utils.StringUnitToDuration(settings.Interval)
+		}
+		tenantLimiters[tenantConfig.ID] = newLimiter(settings.Rate, tenantInterval, settings.Burst)
+	}
+
+	//stop the eviction of the idle buckets on shutdown
+	event.On(utils.ShutDownEvent, event.ListenerFunc(func(e event.Event) error {
+		middlewareWithLimiter.Close()
+		ipLimiter.Close()
+		for _, tenantLimiter := range tenantLimiters {
+			tenantLimiter.Close()
+		}
+		return nil
+	}))
+
+	//add idempotency store, fallback to in memory when redis is disabled
+	var idempotencyStore idempotency.Store
+	if config.Conf.Redis.EnableRedis {
+		idempotencyStore = idempotency.NewRedisStore(redisLibInterface)
+	} else {
+		idempotencyStore = idempotency.NewInMemoryStore()
+	}
+	event.On(utils.ShutDownEvent, event.ListenerFunc(func(e event.Event) error {
+		idempotencyStore.Close()
+		return nil
+	}))
+
+	//add events broker of the ocr progress, in process until it is backed by redis
+	eventsBroker := events.NewInMemoryBroker(0, 0)
+
+	//add jwt verifier
+	var jwtVerifier *auth.JwtVerifier
+	if config.Conf.Auth.EnableJwt {
+		jwtVerifier, err = auth.NewJwtVerifier(config.Conf.SignString, config.Conf.Auth.JwksFile,
+			config.Conf.Auth.JwtIssuer, config.Conf.Auth.JwtAudience, config.Conf.Auth.RolesClaim, config.Conf.Auth.TenantClaim)
+		if err != nil {
+			log.Fatalf("failed initiate jwt verifier: %v", err)
+			os.Exit(1)
+		}
+	}
 
 	//add tesseracts client library using gosseract
 	tesseractsClientLib := tesseractsClient.NewClient()
@@ -69,23 +210,62 @@ func MakeHandler() HandlerSetup {
 	//health module
 	var healthRepository health.RepositoryInterface
 	var ocrRepository ocr.RepositoryInterface
+	var apiKeyRepository apikey.RepositoryInterface
+	var templateRepository template.RepositoryInterface
 	if config.Conf.Postgres.EnablePostgres {
 		healthRepository = health.NewRepository(db.DbConn)
 		ocrRepository = ocr.NewRepository(db.DbConn)
+		apiKeyRepository = apikey.NewRepository(db.DbConn)
+		templateRepository = template.NewRepository(db.DbConn)
+	} else if config.Conf.Sqlite.EnableSqlite {
+		healthRepository = health.NewSqliteRepository(db.DbConn)
+		ocrRepository = ocr.NewSqliteRepository(db.DbConn)
+		apiKeyRepository = apikey.NewRepository(db.DbConn)
+		templateRepository = template.NewRepository(db.DbConn)
+	} else if config.Conf.Persistence.EnablePersistence {
+		//keep the in memory data on disk, it is loaded by the listener on start up
+		ocrRepository, err = ocr.NewPersistentInMemoryRepository(config.Conf.Persistence.DataDir,
+			utils.StringUnitToDuration(config.Conf.Persistence.FsyncInterval))
+		if err != nil {
+			log.Fatalf("failed initiate persistent in memory repository: %v", err)
+			os.Exit(1)
+		}
+		apiKeyRepository = apikey.NewInMemoryRepository()
+		templateRepository = template.NewInMemoryRepository()
 	} else {
 		ocrRepository = ocr.NewInMemoryRepositoryRepositoryAdapter()
+		apiKeyRepository = apikey.NewInMemoryRepository()
+		templateRepository = template.NewInMemoryRepository()
 	}
 
 	healthService := health.NewService(healthRepository, redisClient)
 	healthModule := health.NewHttp(healthService)
 
 	//ocr module
-	ocrService := ocr.NewService(ocrRepository, redisLibInterface, tesseractsClientLib)
+	ocrService := ocr.NewService(ocrRepository, redisLibInterface, eventsBroker, templateRepository, tesseractsClientLib)
 	ocrModule := ocr.NewHttp(ocrService)
+	ocrGrpcModule := ocr.NewGrpc(ocrService)
+
+	//template module, the templates run on the records of the ocr module
+	templateService := template.NewService(templateRepository, ocrService)
+	templateModule := template.NewHttp(templateService)
+
+	//api key module
+	apiKeyService := apikey.NewService(apiKeyRepository)
+	apiKeyModule := apikey.NewHttp(apiKeyService)
 
 	return HandlerSetup{
-		Limiter:    middlewareWithLimiter,
-		HealthHttp: healthModule,
-		OcrHttp:    ocrModule,
+		Limiter:          middlewareWithLimiter,
+		IpLimiter:        ipLimiter,
+		TenantLimiters:   tenantLimiters,
+		IdempotencyStore: idempotencyStore,
+		Authenticator:    apiKeyService,
+		JwtVerifier:      jwtVerifier,
+		HealthHttp:       healthModule,
+		OcrService:       ocrService,
+		OcrHttp:          ocrModule,
+		OcrGrpc:          ocrGrpcModule,
+		ApiKeyHttp:       apiKeyModule,
+		TemplateHttp:     templateModule,
 	}
 }
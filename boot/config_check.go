@@ -0,0 +1,29 @@
+package boot
+
+import (
+	"fmt"
+	"os"
+
+	"go-ocr/infrastructure/config"
+	tesseractsClient "go-ocr/infrastructure/tesseracts-client"
+)
+
+// RunConfigCheck is the entry point of the config check subcommand, it prints the
+// effective config with the secrets redacted and exits non zero when it is invalid
+func RunConfigCheck() {
+	//initiate config
+	config.Initialize()
+
+	effective, err := config.Redacted(config.Conf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed print config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(effective))
+
+	if err = config.Validate(config.Conf, tesseractsClient.AvailableLanguages()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr, "config is valid")
+}
@@ -0,0 +1,127 @@
+package boot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/database"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/migration"
+	"go-ocr/migrations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up | down [steps] | status")
+
+// RunMigrate is the entry point of the migrate subcommand, the args are the ones after "migrate"
+func RunMigrate(args []string) {
+	//initiate config
+	config.Initialize()
+
+	//initiate logger
+	logger.Init(config.Conf.LogFormat, config.Conf.LogLevel)
+
+	if len(args) == 0 {
+		log.Fatal(errMigrateUsage)
+		os.Exit(1)
+	}
+
+	steps := 1
+	if args[0] == migration.DirectionDown && len(args) > 1 {
+		var errSteps error
+		if steps, errSteps = strconv.Atoi(args[1]); errSteps != nil || steps < 1 {
+			log.Fatal(errMigrateUsage)
+			os.Exit(1)
+		}
+	}
+
+	var db database.HandlerDatabase
+	var err error
+	if useSqlite() {
+		db, err = database.NewSqliteClient(&config.Conf)
+	} else {
+		db, err = database.NewDatabaseClient(&config.Conf)
+	}
+	if err != nil {
+		log.Fatalf("failed initiate database: %v", err)
+		os.Exit(1)
+	}
+
+	if args[0] == "status" {
+		if err = printMigrationStatus(context.Background(), db); err != nil {
+			log.Fatalf("failed read migration status: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err = migrate(context.Background(), db, args[0], steps); err != nil {
+		log.Fatalf("failed migrate database: %v", err)
+		os.Exit(1)
+	}
+}
+
+func migrate(ctx context.Context, db database.HandlerDatabase, direction string, steps int) error {
+	runner, err := newMigrationRunner(db)
+	if err != nil {
+		return err
+	}
+
+	migrated, err := runner.Run(ctx, direction, steps)
+	for _, m := range migrated {
+		log.Infof("migrated %s %d_%s", direction, m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(migrated) == 0 {
+		log.Infof("no migration to run %s", direction)
+	}
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, db database.HandlerDatabase) error {
+	runner, err := newMigrationRunner(db)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%06d  %-30s  %s\n", status.Version, status.Name, appliedAt)
+	}
+	return nil
+}
+
+func newMigrationRunner(db database.HandlerDatabase) (*migration.Runner, error) {
+	sqlDB, err := db.DbConn.DB()
+	if err != nil {
+		return nil, err
+	}
+	if useSqlite() {
+		files, err := fs.Sub(migrations.Sqlite, "sqlite")
+		if err != nil {
+			return nil, err
+		}
+		return migration.NewRunner(sqlDB, files, migration.DialectSqlite)
+	}
+	return migration.NewRunner(sqlDB, migrations.FS, migration.DialectPostgres)
+}
+
+// useSqlite tells whether sqlite is the database, postgres wins when both are enabled
+func useSqlite() bool {
+	return !config.Conf.Postgres.EnablePostgres && config.Conf.Sqlite.EnableSqlite
+}
@@ -0,0 +1,229 @@
+package boot
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-ocr/infrastructure/listener"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/ocr"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+
+	"github.com/gookit/event"
+	log "github.com/sirupsen/logrus"
+)
+
+// imageExtensions are the files picked up when a directory is given
+var imageExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".tif":  true,
+	".tiff": true,
+	".bmp":  true,
+	".gif":  true,
+	".webp": true,
+	".pnm":  true,
+}
+
+// outputExtensions are the extensions of the files written in the output dir
+var outputExtensions = map[string]string{
+	primitive.FormatText: ".txt",
+	primitive.FormatHocr: ".hocr",
+	primitive.FormatTsv:  ".tsv",
+	primitive.FormatJson: ".json",
+}
+
+// RunOcr is the entry point of the ocr subcommand, the args are the ones after "ocr",
+// the files go through the same service as the uploads
+func RunOcr(args []string) {
+	flags := flag.NewFlagSet("ocr", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ocr [flags] <files or dirs...>")
+		flags.PrintDefaults()
+	}
+	documentType := flags.String("type", "", "document type profile, its languages, psm and steps are used unless -lang or -psm are given")
+	languages := flags.String("lang", "", "languages joined by +, like eng+deu, default the languages of the tenant")
+	psm := flags.Int("psm", primitive.PsmDefault, "tesseract page segmentation mode 0-13, default the engine mode")
+	format := flags.String("format", primitive.FormatText, "output format text, json, hocr or tsv")
+	outDir := flags.String("out", "", "write one file per image in this dir instead of stdout")
+	recursive := flags.Bool("r", false, "walk the sub directories of the given dirs")
+	store := flags.Bool("store", false, "store the results as records like the uploaded files")
+	tenantID := flags.String("tenant", tenant.DefaultTenantID, "tenant of the stored records and of the default languages")
+	zones := flags.String("zones", "", `json array of the zones read instead of the page, like [{"name":"total","x":0.5,"y":0.8,"width":0.5,"height":0.2}]`)
+	fullText := flags.Bool("full-text", false, "read the whole page besides the zones")
+	_ = flags.Parse(args)
+
+	if _, ok := outputExtensions[*format]; !ok || flags.NArg() == 0 ||
+		*psm < primitive.PsmDefault || *psm > 13 || !tenant.IsValidID(*tenantID) {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	//keep stdout for the results
+	logger.SetOutput(os.Stderr)
+
+	setup := MakeHandler()
+
+	//the stored records of the in memory repository are kept on disk by the listener
+	if *store {
+		listen := listener.NewListener(setup.OcrHttp)
+		listen.TriggerStartUp()
+		listen.ListenForShutdownEvent()
+	}
+
+	options := primitive.RecognizeOptions{
+		Languages: tenant.Languages(*tenantID),
+		Psm:       primitive.PsmDefault,
+	}
+	if *documentType != "" {
+		profileOptions, err := ocr.ProfileOptions(*tenantID, *documentType)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		options = profileOptions
+	}
+	options.Format = *format
+	if *psm != primitive.PsmDefault {
+		options.Psm = *psm
+	}
+	if *languages != "" {
+		options.Languages = strings.Split(*languages, "+")
+	}
+	if *zones != "" {
+		parsedZones, err := ocr.ParseZones(*zones)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		options.Zones = parsedZones
+	}
+	if *fullText {
+		options.FullText = true
+	}
+	//json wraps the plain text
+	if options.Format == primitive.FormatJson {
+		options.Format = primitive.FormatText
+	}
+
+	failed := 0
+	var images []ocrImage
+	for _, root := range flags.Args() {
+		files, err := collectImages(root, *recursive)
+		if err != nil {
+			log.Errorf("failed read %s: %v", root, err)
+			failed++
+			continue
+		}
+		for _, file := range files {
+			images = append(images, ocrImage{root: root, path: file})
+		}
+	}
+
+	ctx := tenant.WithTenant(context.Background(), *tenantID)
+	for _, image := range images {
+		result, err := setup.OcrService.RecognizeFile(ctx, image.path, options, *store)
+		if err == nil {
+			err = writeOcrResult(image, *outDir, *format, len(images) > 1, result)
+		}
+		if err != nil {
+			log.Errorf("failed ocr %s: %v", image.path, err)
+			failed++
+		}
+	}
+
+	if *store {
+		event.MustFire(utils.ShutDownEvent, nil)
+	}
+	if failed > 0 {
+		log.Errorf("%d file(s) failed", failed)
+		os.Exit(1)
+	}
+}
+
+// ocrImage is an image to recognize with the file or dir it was found from
+type ocrImage struct {
+	root string
+	path string
+}
+
+// collectImages returns the file itself, or the images of the dir
+func collectImages(root string, recursive bool) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if imageExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// writeOcrResult prints the result on stdout, or writes it in the output dir keeping
+// the path of the image relative to the given dir
+func writeOcrResult(image ocrImage, outDir, format string, withHeader bool, result primitive.RecognizeResponse) error {
+	var content []byte
+	if format == primitive.FormatJson {
+		var err error
+		if content, err = json.Marshal(result); err != nil {
+			return err
+		}
+	} else {
+		content = []byte(result.Text)
+	}
+	content = append(content, '\n')
+
+	if outDir == "" {
+		//separate the results of the files, json is already one line per file
+		if withHeader && format != primitive.FormatJson {
+			if _, err := fmt.Fprintf(os.Stdout, "==> %s <==\n", image.path); err != nil {
+				return err
+			}
+		}
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+
+	name := filepath.Base(image.path)
+	if image.root != image.path {
+		relative, err := filepath.Rel(image.root, image.path)
+		if err != nil {
+			return err
+		}
+		name = relative
+	}
+	target := filepath.Join(outDir, strings.TrimSuffix(name, filepath.Ext(name))+outputExtensions[format])
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return fmt.Errorf("failed write %s: %w", target, err)
+	}
+	return nil
+}
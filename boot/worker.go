@@ -0,0 +1,35 @@
+package boot
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const retentionPurgeInterval = time.Hour
+
+// RunBackgroundJobs runs the jobs that don't belong to a request until the context is done,
+// both the server and the worker subcommand run them
+func RunBackgroundJobs(ctx context.Context, setup HandlerSetup) {
+	for {
+		//purge the records past their tenant retention
+		if _, errPurge := setup.OcrService.PurgeExpiredOcr(ctx); errPurge != nil {
+			log.Errorf("failed purge expired ocr: %v", errPurge)
+		}
+		//remove the finished exports past their retention
+		if _, errPurge := setup.OcrService.PurgeExpiredExports(ctx); errPurge != nil {
+			log.Errorf("failed purge expired exports: %v", errPurge)
+		}
+		//forget the finished batches past their retention
+		if _, errPurge := setup.OcrService.PurgeExpiredBatches(ctx); errPurge != nil {
+			log.Errorf("failed purge expired batches: %v", errPurge)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retentionPurgeInterval):
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	logger "go-ocr/infrastructure/log"
+)
+
+// IdentityKey is the key of the caller identity on both the gin and the request context
+const IdentityKey string = "X-Caller-Identity"
+
+const (
+	TypeApiKey = "api_key"
+	TypeJwt    = "jwt"
+
+	RoleAdmin = "admin"
+)
+
+var (
+	ErrMissingCredential = errors.New("missing credential")
+	ErrInvalidCredential = errors.New("invalid credential")
+)
+
+func init() {
+	// the caller is logged and kept by the background jobs of the request
+	logger.RegisterField("caller", func(ctx context.Context) (interface{}, bool) {
+		identity, ok := FromContext(ctx)
+		if !ok {
+			return nil, false
+		}
+		return identity.String(), true
+	})
+	logger.RegisterDetach(func(from, to context.Context) context.Context {
+		if identity, ok := FromContext(from); ok {
+			return WithIdentity(to, identity)
+		}
+		return to
+	})
+}
+
+// Identity is the authenticated caller of the request
+type Identity struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	TenantID    string   `json:"tenantId"`
+}
+
+// Authenticator resolve the identity of a raw api key
+type Authenticator interface {
+	AuthenticateApiKey(ctx context.Context, rawKey string) (Identity, error)
+}
+
+// HasRole check if the identity has the given role
+func (i Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission check if the identity is granted the given permission
+func (i Identity) HasPermission(permission string) bool {
+	for _, p := range i.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// String is the value used on the logs
+func (i Identity) String() string {
+	return i.Type + ":" + i.ID
+}
+
+// WithIdentity return a copy of the context carrying the identity
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, IdentityKey, identity)
+}
+
+// FromContext get the identity of the caller, false when the request is anonymous
+func FromContext(ctx context.Context) (Identity, bool) {
+	if ctx == nil {
+		return Identity{}, false
+	}
+	identity, ok := ctx.Value(IdentityKey).(Identity)
+	return identity, ok
+}
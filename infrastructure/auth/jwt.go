@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"go-ocr/infrastructure/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultRolesClaim  = "roles"
+	defaultTenantClaim = "tenant_id"
+)
+
+// ErrNoJwtKey is returned when neither a sign string nor a jwks file can verify the tokens
+var ErrNoJwtKey = errors.New("jwt needs a signString other than the default or a jwksFile")
+
+// JwtVerifier verify the bearer tokens issued by the gateway, HS256 use the
+// configured sign string and RS256/ES256 use the keys of a local JWKS file.
+// HS256 is only accepted with a sign string other than the default one.
+type JwtVerifier struct {
+	secret      []byte
+	publicKeys  map[string]interface{}
+	methods     []string
+	issuer      string
+	audience    string
+	rolesClaim  string
+	tenantClaim string
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func NewJwtVerifier(secret, jwksFile, issuer, audience, rolesClaim, tenantClaim string) (*JwtVerifier, error) {
+	if rolesClaim == "" {
+		rolesClaim = defaultRolesClaim
+	}
+	if tenantClaim == "" {
+		tenantClaim = defaultTenantClaim
+	}
+
+	verifier := &JwtVerifier{
+		publicKeys:  make(map[string]interface{}),
+		issuer:      issuer,
+		audience:    audience,
+		rolesClaim:  rolesClaim,
+		tenantClaim: tenantClaim,
+	}
+
+	if jwksFile != "" {
+		publicKeys, err := loadJwks(jwksFile)
+		if err != nil {
+			return nil, err
+		}
+		verifier.publicKeys = publicKeys
+	}
+
+	// the default sign string is public, a token signed with it proves nothing
+	if IsHmacSecret(secret) {
+		verifier.secret = []byte(secret)
+		verifier.methods = append(verifier.methods, "HS256")
+	}
+	if len(verifier.publicKeys) > 0 {
+		verifier.methods = append(verifier.methods, "RS256", "ES256")
+	}
+	if len(verifier.methods) == 0 {
+		return nil, ErrNoJwtKey
+	}
+
+	return verifier, nil
+}
+
+// IsHmacSecret check if the sign string can verify HS256 tokens, it must be set and not the default
+func IsHmacSecret(secret string) bool {
+	return secret != "" && secret != config.DefaultSignString
+}
+
+func loadJwks(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.Unmarshal(content, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks file: %w", err)
+	}
+
+	publicKeys := make(map[string]interface{})
+	for _, key := range jwks.Keys {
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse jwks key %q: %w", key.Kid, err)
+		}
+		publicKeys[key.Kid] = publicKey
+	}
+	return publicKeys, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func (v *JwtVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(v.secret) == 0 {
+			return nil, errors.New("hs256 is not enabled")
+		}
+		return v.secret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		kid, _ := token.Header["kid"].(string)
+		if publicKey, ok := v.publicKeys[kid]; ok {
+			return publicKey, nil
+		}
+		// a jwks with a single key doesn't need the kid
+		if kid == "" && len(v.publicKeys) == 1 {
+			for _, publicKey := range v.publicKeys {
+				return publicKey, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+}
+
+// Verify validate the token and returns the identity of its subject
+func (v *JwtVerifier) Verify(rawToken string) (Identity, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(v.methods),
+		jwt.WithExpirationRequired(),
+	}
+	if v.issuer != "" {
+		options = append(options, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		options = append(options, jwt.WithAudience(v.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, v.keyFunc, options...)
+	if err != nil {
+		return Identity{}, errors.Join(ErrInvalidCredential, err)
+	}
+
+	subject, _ := claims.GetSubject()
+	roles := claimToStrings(claims[v.rolesClaim])
+	name, _ := claims["name"].(string)
+	tenantID, _ := claims[v.tenantClaim].(string)
+
+	return Identity{
+		ID:          subject,
+		Name:        name,
+		Type:        TypeJwt,
+		Roles:       roles,
+		Permissions: PermissionsForRoles(roles),
+		TenantID:    tenantID,
+	}, nil
+}
+
+// claimToStrings accept the roles as a json array or a space/comma separated string
+func claimToStrings(value interface{}) []string {
+	result := make([]string, 0)
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+	case string:
+		for _, s := range strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' }) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// LooksLikeJwt check if the bearer value has the three parts of a jws
+func LooksLikeJwt(value string) bool {
+	return strings.Count(value, ".") == 2
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-ocr/infrastructure/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "a-secret-that-is-not-the-default"
+
+// writeJwks writes the public key as a jwks file with the given key id
+func writeJwks(t *testing.T, publicKey *rsa.PublicKey, kid string) string {
+	t.Helper()
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	}
+	content, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":       "user-1",
+		"roles":     []string{"admin"},
+		"tenant_id": "acme",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims, key interface{}, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestNewJwtVerifierNeedsAKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksFile := writeJwks(t, &privateKey.PublicKey, "k1")
+
+	tests := []struct {
+		name     string
+		secret   string
+		jwksFile string
+		methods  []string
+		wantErr  error
+	}{
+		{name: "empty secret without jwks", secret: "", wantErr: ErrNoJwtKey},
+		{name: "default secret without jwks", secret: config.DefaultSignString, wantErr: ErrNoJwtKey},
+		{name: "explicit secret", secret: testSecret, methods: []string{"HS256"}},
+		{name: "default secret with jwks", secret: config.DefaultSignString, jwksFile: jwksFile, methods: []string{"RS256", "ES256"}},
+		{name: "explicit secret with jwks", secret: testSecret, jwksFile: jwksFile, methods: []string{"HS256", "RS256", "ES256"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewJwtVerifier(tt.secret, tt.jwksFile, "", "", "", "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got err %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if strings.Join(verifier.methods, ",") != strings.Join(tt.methods, ",") {
+				t.Fatalf("got methods %v, want %v", verifier.methods, tt.methods)
+			}
+		})
+	}
+}
+
+func TestJwtVerifierVerify(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksFile := writeJwks(t, &privateKey.PublicKey, "k1")
+	publicKeyDer, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hmacOnly, err := NewJwtVerifier(testSecret, "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksOnly, err := NewJwtVerifier(config.DefaultSignString, jwksFile, "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withIssuer, err := NewJwtVerifier(testSecret, jwksFile, "https://issuer", "go-ocr", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := func(token string) string {
+		parts := strings.Split(token, ".")
+		claims := validClaims()
+		claims["tenant_id"] = "other"
+		payload, _ := json.Marshal(claims)
+		parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+		return strings.Join(parts, ".")
+	}
+	noneToken := func() string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	withoutExpiry := validClaims()
+	delete(withoutExpiry, "exp")
+	issued := validClaims()
+	issued["iss"] = "https://issuer"
+	issued["aud"] = "go-ocr"
+
+	tests := []struct {
+		name     string
+		verifier *JwtVerifier
+		token    string
+		valid    bool
+	}{
+		{name: "hs256 with the secret", verifier: hmacOnly,
+			token: sign(t, jwt.SigningMethodHS256, validClaims(), []byte(testSecret), ""), valid: true},
+		{name: "hs256 with another secret", verifier: hmacOnly,
+			token: sign(t, jwt.SigningMethodHS256, validClaims(), []byte("guessed"), "")},
+		{name: "hs256 with the default secret on a jwks verifier", verifier: jwksOnly,
+			token: sign(t, jwt.SigningMethodHS256, validClaims(), []byte(config.DefaultSignString), "")},
+		{name: "hs256 signed with the public key on a jwks verifier", verifier: jwksOnly,
+			token: sign(t, jwt.SigningMethodHS256, validClaims(), publicKeyDer, "k1")},
+		{name: "hs512 with the secret", verifier: hmacOnly,
+			token: sign(t, jwt.SigningMethodHS512, validClaims(), []byte(testSecret), "")},
+		{name: "rs256 with the jwks key", verifier: jwksOnly,
+			token: sign(t, jwt.SigningMethodRS256, validClaims(), privateKey, "k1"), valid: true},
+		{name: "rs256 without kid on a single key jwks", verifier: jwksOnly,
+			token: sign(t, jwt.SigningMethodRS256, validClaims(), privateKey, ""), valid: true},
+		{name: "rs256 with another key", verifier: jwksOnly,
+			token: sign(t, jwt.SigningMethodRS256, validClaims(), otherKey, "k1")},
+		{name: "rs256 with an unknown kid", verifier: jwksOnly,
+			token: sign(t, jwt.SigningMethodRS256, validClaims(), privateKey, "k2")},
+		{name: "rs256 on a hmac verifier", verifier: hmacOnly,
+			token: sign(t, jwt.SigningMethodRS256, validClaims(), privateKey, "k1")},
+		{name: "tampered payload", verifier: jwksOnly,
+			token: tampered(sign(t, jwt.SigningMethodRS256, validClaims(), privateKey, "k1"))},
+		{name: "alg none", verifier: hmacOnly, token: noneToken()},
+		{name: "expired", verifier: hmacOnly,
+			token: sign(t, jwt.SigningMethodHS256, expired, []byte(testSecret), "")},
+		{name: "without expiry", verifier: hmacOnly,
+			token: sign(t, jwt.SigningMethodHS256, withoutExpiry, []byte(testSecret), "")},
+		{name: "wrong issuer and audience", verifier: withIssuer,
+			token: sign(t, jwt.SigningMethodHS256, validClaims(), []byte(testSecret), "")},
+		{name: "issuer and audience", verifier: withIssuer,
+			token: sign(t, jwt.SigningMethodRS256, issued, privateKey, "k1"), valid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := tt.verifier.Verify(tt.token)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidCredential) {
+					t.Fatalf("got err %v, want %v", err, ErrInvalidCredential)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got err %v", err)
+			}
+			if identity.ID != "user-1" || identity.TenantID != "acme" || !identity.HasPermission(PermissionOcrAdmin) {
+				t.Fatalf("got identity %+v", identity)
+			}
+		})
+	}
+}
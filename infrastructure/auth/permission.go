@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"strings"
+
+	"go-ocr/infrastructure/config"
+)
+
+const (
+	PermissionOcrRead  = "ocr:read"
+	PermissionOcrWrite = "ocr:write"
+	PermissionOcrAdmin = "ocr:admin"
+)
+
+// DefaultRolePermissions is used when auth.rolePermissions is not configured
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin: {PermissionOcrRead, PermissionOcrWrite, PermissionOcrAdmin},
+	"writer":  {PermissionOcrRead, PermissionOcrWrite},
+	"reader":  {PermissionOcrRead},
+}
+
+// PermissionsForRoles map the roles to their permissions, a role that is
+// already a permission like "ocr:read" is granted as it is.
+func PermissionsForRoles(roles []string) []string {
+	rolePermissions := config.Conf.Auth.RolePermissions
+	if len(rolePermissions) == 0 {
+		rolePermissions = DefaultRolePermissions
+	}
+
+	seen := make(map[string]bool)
+	permissions := make([]string, 0)
+	grant := func(permission string) {
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	for _, role := range roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if isPermission(role) {
+			grant(role)
+		}
+		for _, permission := range rolePermissions[role] {
+			grant(permission)
+		}
+	}
+	return permissions
+}
+
+func isPermission(value string) bool {
+	switch value {
+	case PermissionOcrRead, PermissionOcrWrite, PermissionOcrAdmin:
+		return true
+	}
+	return false
+}
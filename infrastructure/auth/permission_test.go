@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+
+	"go-ocr/infrastructure/config"
+)
+
+func TestPermissionsForRoles(t *testing.T) {
+	previous := config.Conf.Auth.RolePermissions
+	t.Cleanup(func() { config.Conf.Auth.RolePermissions = previous })
+
+	configured := map[string][]string{"auditor": {PermissionOcrRead}}
+	tests := []struct {
+		name            string
+		rolePermissions map[string][]string
+		roles           []string
+		want            []string
+	}{
+		{name: "no role", want: []string{}},
+		{name: "default admin", roles: []string{RoleAdmin}, want: []string{PermissionOcrRead, PermissionOcrWrite, PermissionOcrAdmin}},
+		{name: "default reader", roles: []string{"reader"}, want: []string{PermissionOcrRead}},
+		{name: "roles are trimmed and lower cased", roles: []string{" Writer "}, want: []string{PermissionOcrRead, PermissionOcrWrite}},
+		{name: "permissions are granted once", roles: []string{"reader", "writer"}, want: []string{PermissionOcrRead, PermissionOcrWrite}},
+		{name: "a permission is granted as it is", roles: []string{PermissionOcrAdmin}, want: []string{PermissionOcrAdmin}},
+		{name: "an unknown role grants nothing", roles: []string{"root", "ocr:delete"}, want: []string{}},
+		{name: "configured roles replace the default", rolePermissions: configured, roles: []string{"auditor", RoleAdmin}, want: []string{PermissionOcrRead}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Conf.Auth.RolePermissions = tt.rolePermissions
+			if got := PermissionsForRoles(tt.roles); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("PermissionsForRoles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -39,7 +39,9 @@ func initialiseDefaults(v *viper.Viper) {
 func Initialize() {
 	v := viper.New()
 	initialiseDefaults(v)
+	isRemote = true
 	if err := initialiseRemote(v); err != nil {
+		isRemote = false
 		log.Warningf("No remote server configured will load configuration from file and environment variables: %+v", err)
 		if err := initialiseFileAndEnv(v, Env); err != nil {
 			var configFileNotFoundError viper.ConfigFileNotFoundError
@@ -49,12 +51,21 @@ func Initialize() {
 		}
 	}
 
+	mu.Lock()
+	defer mu.Unlock()
+	configViper = v
 	err := v.Unmarshal(&Conf)
 	if err != nil {
 		log.Printf("Error un-marshalling configuration: %s", err.Error())
 	}
 }
 
+// DefaultSignString is the placeholder sign string, it is public so it never signs anything
+const DefaultSignString = "supersecret"
+
+// ipRateFactor is how many times the limit of an identity the limit by ip allows by default
+const ipRateFactor = 4
+
 var (
 	Conf        Config
 	Env         string
@@ -72,10 +83,44 @@ var (
 		".",
 	}
 	configDefaults = map[string]interface{}{
-		"port":       1234,
-		"logLevel":   "DEBUG",
-		"logFormat":  "text",
-		"signString": "supersecret",
+		"port":        1234,
+		"logLevel":    "DEBUG",
+		"logFormat":   "text",
+		"signString":  DefaultSignString,
+		"rateLimiter": "memory",
+		"uploadDir":   "./uploads",
+		"exportDir":   "./exports",
+
+		"grpc.port":                 50051,
+		"redis.cacheTTL":            "1m",
+		"sqlite.path":               "./data/go-ocr.db",
+		"persistence.dataDir":       "./data",
+		"persistence.fsyncInterval": "second",
+
+		"profiles": []map[string]interface{}{
+			{"name": "generic"},
+			{
+				"name":        "receipt",
+				"psm":         4,
+				"preprocess":  []string{"grayscale", "threshold"},
+				"postprocess": []string{"trim_lines", "collapse_spaces"},
+				"extractors":  []string{"date", "total"},
+			},
+			{
+				"name":        "invoice",
+				"psm":         3,
+				"preprocess":  []string{"grayscale"},
+				"postprocess": []string{"trim_lines", "collapse_spaces"},
+				"extractors":  []string{"invoice_number", "date", "total", "email"},
+			},
+			{
+				"name":        "id_card",
+				"psm":         11,
+				"preprocess":  []string{"grayscale", "upscale", "threshold"},
+				"postprocess": []string{"trim_lines", "drop_empty_lines", "uppercase"},
+				"extractors":  []string{"id_number", "date"},
+			},
+		},
 	}
 	configName = map[string]string{
 		"local": "config.local",
@@ -87,40 +132,154 @@ var (
 )
 
 type Config struct {
-	Env              string           `mapstructure:"env"`
-	Port             int              `mapstructure:"port"`
-	LogLevel         string           `mapstructure:"logLevel"`
-	LogMode          bool             `mapstructure:"logMode"`
-	LogFormat        string           `mapstructure:"logFormat"`
-	Postgres         PostgresConfig   `mapstructure:"postgres"`
-	Redis            RedisConfig      `mapstructure:"redis"`
-	Rate             int64            `mapstructure:"rate"`
-	Interval         string           `mapstructure:"interval"`
-	TesseractsConfig TesseractsConfig `mapstructure:"tesseracts"`
+	Env              string            `mapstructure:"env"`
+	Port             int               `mapstructure:"port" validate:"min=1,max=65535"`
+	LogLevel         string            `mapstructure:"logLevel" validate:"omitempty,oneof=debug info warn error DEBUG INFO WARN ERROR"`
+	LogMode          bool              `mapstructure:"logMode"`
+	LogFormat        string            `mapstructure:"logFormat" validate:"omitempty,oneof=text json"`
+	SignString       string            `mapstructure:"signString" redact:"true"`
+	Postgres         PostgresConfig    `mapstructure:"postgres"`
+	Sqlite           SqliteConfig      `mapstructure:"sqlite"`
+	Redis            RedisConfig       `mapstructure:"redis"`
+	Rate             int64             `mapstructure:"rate" validate:"min=0"`
+	Burst            int64             `mapstructure:"burst" validate:"min=0"`
+	IpRate           int64             `mapstructure:"ipRate" validate:"min=0"`
+	IpBurst          int64             `mapstructure:"ipBurst" validate:"min=0"`
+	RateLimiter      string            `mapstructure:"rateLimiter" validate:"oneof=memory redis"`
+	Interval         string            `mapstructure:"interval" validate:"omitempty,unit_duration"`
+	UploadDir        string            `mapstructure:"uploadDir" validate:"required"`
+	ExportDir        string            `mapstructure:"exportDir" validate:"required"`
+	TrustedProxies   []string          `mapstructure:"trustedProxies" validate:"dive,ip|cidr"`
+	TesseractsConfig TesseractsConfig  `mapstructure:"tesseracts"`
+	Idempotency      IdempotencyConfig `mapstructure:"idempotency"`
+	Auth             AuthConfig        `mapstructure:"auth"`
+	Tenants          []TenantConfig    `mapstructure:"tenants" validate:"dive"`
+	Tracing          TracingConfig     `mapstructure:"tracing"`
+	Persistence      PersistenceConfig `mapstructure:"persistence"`
+	Grpc             GrpcConfig        `mapstructure:"grpc"`
+	Profiles         []ProfileConfig   `mapstructure:"profiles" validate:"dive"`
+}
+
+// IpRateLimit returns the limit by client ip applied before the auth, it defaults to
+// ipRateFactor times the limit of an identity so the callers behind a shared ip get through
+func (c Config) IpRateLimit() (rate, burst int) {
+	rate, burst = int(c.IpRate), int(c.IpBurst)
+	if rate <= 0 {
+		// a rate of zero is one action per interval for the limiter
+		rate = ipRateFactor * int(max(c.Rate, 1))
+	}
+	if burst <= 0 {
+		burst = ipRateFactor * int(c.Burst)
+	}
+	return rate, burst
 }
 
 // PostgresConfig ...
 type PostgresConfig struct {
-	ConnMaxLifetime    int    `mapstructure:"connectTimeout"`
-	MaxOpenConnections int    `mapstructure:"maxOpenConnections"`
-	MaxIdleConnections int    `mapstructure:"maxIdleConnections"`
-	Host               string `mapstructure:"host"`
-	Port               string `mapstructure:"port"`
+	ConnMaxLifetime    int    `mapstructure:"connectTimeout" validate:"min=0"`
+	MaxOpenConnections int    `mapstructure:"maxOpenConnections" validate:"min=0"`
+	MaxIdleConnections int    `mapstructure:"maxIdleConnections" validate:"min=0"`
+	Host               string `mapstructure:"host" validate:"required_if=EnablePostgres true"`
+	Port               string `mapstructure:"port" validate:"required_if=EnablePostgres true,omitempty,numeric"`
 	Schema             string `mapstructure:"schema"`
-	DBName             string `mapstructure:"dbName"`
-	User               string `mapstructure:"user"`
-	Password           string `mapstructure:"password"`
+	DBName             string `mapstructure:"dbName" validate:"required_if=EnablePostgres true"`
+	User               string `mapstructure:"user" validate:"required_if=EnablePostgres true"`
+	Password           string `mapstructure:"password" redact:"true"`
 	EnablePostgres     bool   `mapstructure:"enablePostgres"`
+	AutoMigrate        bool   `mapstructure:"autoMigrate"`
+}
+
+// SqliteConfig is the embedded database for the deployments without postgres
+type SqliteConfig struct {
+	Path         string `mapstructure:"path" validate:"required_if=EnableSqlite true"`
+	EnableSqlite bool   `mapstructure:"enableSqlite"`
+	AutoMigrate  bool   `mapstructure:"autoMigrate"`
 }
 
 type RedisConfig struct {
-	Host        string `mapstructure:"host"`
-	Password    string `mapstructure:"password"`
-	DB          int    `mapstructure:"db"`
-	Port        int    `mapstructure:"port"`
+	Host        string `mapstructure:"host" validate:"required_if=EnableRedis true"`
+	Password    string `mapstructure:"password" redact:"true"`
+	DB          int    `mapstructure:"db" validate:"min=0"`
+	Port        int    `mapstructure:"port" validate:"required_if=EnableRedis true,omitempty,min=1,max=65535"`
 	EnableRedis bool   `mapstructure:"enableRedis"`
+	CacheTTL    string `mapstructure:"cacheTTL" validate:"omitempty,duration"`
 }
 
 type TesseractsConfig struct {
-	Languages []string `mapstructure:"languages"`
+	Languages []string `mapstructure:"languages" validate:"dive,tesseract_language"`
+}
+
+type IdempotencyConfig struct {
+	TTL string `mapstructure:"ttl" validate:"omitempty,duration"`
+}
+
+type AuthConfig struct {
+	EnableApiKey    bool                `mapstructure:"enableApiKey"`
+	AdminKey        string              `mapstructure:"adminKey" redact:"true"`
+	EnableJwt       bool                `mapstructure:"enableJwt"`
+	JwksFile        string              `mapstructure:"jwksFile" validate:"omitempty,file"`
+	JwtIssuer       string              `mapstructure:"jwtIssuer"`
+	JwtAudience     string              `mapstructure:"jwtAudience"`
+	RolesClaim      string              `mapstructure:"rolesClaim"`
+	TenantClaim     string              `mapstructure:"tenantClaim"`
+	RolePermissions map[string][]string `mapstructure:"rolePermissions"`
+}
+
+// TenantConfig is the per tenant override, an empty field use the global value
+type TenantConfig struct {
+	ID            string   `mapstructure:"id" validate:"required"`
+	Languages     []string `mapstructure:"languages" validate:"dive,tesseract_language"`
+	Rate          int64    `mapstructure:"rate" validate:"min=0"`
+	Interval      string   `mapstructure:"interval" validate:"omitempty,unit_duration"`
+	Burst         int64    `mapstructure:"burst" validate:"min=0"`
+	MaxUploadSize int64    `mapstructure:"maxUploadSize" validate:"min=0"`
+	Retention     string   `mapstructure:"retention" validate:"omitempty,duration"`
+}
+
+type TracingConfig struct {
+	EnableTracing bool    `mapstructure:"enableTracing"`
+	ServiceName   string  `mapstructure:"serviceName"`
+	OtlpEndpoint  string  `mapstructure:"otlpEndpoint" validate:"omitempty,hostname_port"`
+	Insecure      bool    `mapstructure:"insecure"`
+	SampleRatio   float64 `mapstructure:"sampleRatio" validate:"min=0,max=1"`
+}
+
+// PersistenceConfig keeps the in memory repository on disk when postgres is disabled
+type PersistenceConfig struct {
+	EnablePersistence bool   `mapstructure:"enablePersistence"`
+	DataDir           string `mapstructure:"dataDir" validate:"required_if=EnablePersistence true"`
+	FsyncInterval     string `mapstructure:"fsyncInterval" validate:"omitempty,unit_duration"`
+}
+
+// GrpcConfig is the grpc api, it runs beside the http server on its own port
+type GrpcConfig struct {
+	EnableGrpc bool `mapstructure:"enableGrpc"`
+	Port       int  `mapstructure:"port" validate:"required_if=EnableGrpc true,omitempty,min=1,max=65535"`
+}
+
+// ProfileConfig is the processing of a document type, it is chosen by the type of the upload.
+// An empty languages use the languages of the tenant and a missing psm the engine mode.
+type ProfileConfig struct {
+	Name        string   `mapstructure:"name" validate:"required,profile_name"`
+	Languages   []string `mapstructure:"languages" validate:"dive,tesseract_language"`
+	Psm         *int     `mapstructure:"psm" validate:"omitempty,min=0,max=13"`
+	Preprocess  []string `mapstructure:"preprocess" validate:"dive,oneof=grayscale threshold invert upscale"`
+	Postprocess []string `mapstructure:"postprocess" validate:"dive,oneof=trim_lines collapse_spaces drop_empty_lines uppercase"`
+	Extractors  []string `mapstructure:"extractors" validate:"dive,oneof=date total invoice_number email phone id_number"`
+	// Zones are read instead of the whole page, unless FullText is set
+	Zones    []ZoneConfig `mapstructure:"zones" validate:"dive"`
+	FullText bool         `mapstructure:"fullText"`
+}
+
+// ZoneConfig is a named rectangle of the page recognized on its own, in fractions of the
+// page from the top left unless the unit is pixel
+type ZoneConfig struct {
+	Name      string  `mapstructure:"name" validate:"required,profile_name"`
+	X         float64 `mapstructure:"x" validate:"min=0"`
+	Y         float64 `mapstructure:"y" validate:"min=0"`
+	Width     float64 `mapstructure:"width" validate:"gt=0"`
+	Height    float64 `mapstructure:"height" validate:"gt=0"`
+	Unit      string  `mapstructure:"unit" validate:"omitempty,oneof=pixel normalized"`
+	Psm       *int    `mapstructure:"psm" validate:"omitempty,min=0,max=13"`
+	Whitelist string  `mapstructure:"whitelist" validate:"max=256"`
 }
@@ -0,0 +1,25 @@
+package config
+
+import "testing"
+
+func TestIpRateLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      Config
+		wantRate  int
+		wantBurst int
+	}{
+		{name: "a multiple of the identity limit", conf: Config{Rate: 10, Burst: 20}, wantRate: 40, wantBurst: 80},
+		{name: "no identity limit", conf: Config{}, wantRate: 4, wantBurst: 0},
+		{name: "set", conf: Config{Rate: 10, Burst: 20, IpRate: 100, IpBurst: 150}, wantRate: 100, wantBurst: 150},
+		{name: "only the rate set", conf: Config{Rate: 10, Burst: 20, IpRate: 100}, wantRate: 100, wantBurst: 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, burst := tt.conf.IpRateLimit()
+			if rate != tt.wantRate || burst != tt.wantBurst {
+				t.Fatalf("IpRateLimit() = %d, %d, want %d, %d", rate, burst, tt.wantRate, tt.wantBurst)
+			}
+		})
+	}
+}
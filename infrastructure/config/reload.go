@@ -0,0 +1,160 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// remotePollInterval is how often consul is asked for a new version of the config
+const remotePollInterval = 30 * time.Second
+
+var (
+	mu          sync.RWMutex
+	reloadHooks []func(previous, current Config)
+
+	// configViper and isRemote are kept by Initialize so the same source can be watched
+	configViper *viper.Viper
+	isRemote    bool
+
+	// availableLanguages is given by Watch, the languages are checked on the host like at boot
+	availableLanguages func() []string
+)
+
+// Current returns a copy of the config, the fields applied live must be read with it
+func Current() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return Conf
+}
+
+// OnReload registers a hook called after the live fields have been applied
+func OnReload(hook func(previous, current Config)) {
+	mu.Lock()
+	defer mu.Unlock()
+	reloadHooks = append(reloadHooks, hook)
+}
+
+// Watch reloads the config when the file or the consul key changes, a config that is not
+// valid with the languages installed on the host is not applied
+func Watch(languages func() []string) {
+	if configViper == nil {
+		return
+	}
+	availableLanguages = languages
+
+	if isRemote {
+		go func() {
+			for range time.Tick(remotePollInterval) {
+				if err := configViper.WatchRemoteConfig(); err != nil {
+					log.Warnf("failed watch remote config: %v", err)
+					continue
+				}
+				reload()
+			}
+		}()
+		return
+	}
+
+	if configViper.ConfigFileUsed() == "" {
+		log.Warning("No config file to watch, the config will not be reloaded")
+		return
+	}
+	configViper.OnConfigChange(func(e fsnotify.Event) {
+		log.Infof("config file %s changed, reloading", e.Name)
+		reload()
+	})
+	configViper.WatchConfig()
+}
+
+func reload() {
+	var next Config
+	if err := configViper.Unmarshal(&next); err != nil {
+		log.Errorf("Error un-marshalling reloaded configuration: %s", err.Error())
+		return
+	}
+	// a broken config keeps the current one, like it stops the boot
+	var languages []string
+	if availableLanguages != nil {
+		languages = availableLanguages()
+	}
+	if err := Validate(next, languages); err != nil {
+		log.Errorf("reloaded configuration is not applied, the current one is kept: %s", err.Error())
+		return
+	}
+
+	mu.Lock()
+	previous := Conf
+	if reflect.DeepEqual(previous, next) {
+		mu.Unlock()
+		return
+	}
+
+	// the other fields are read once at boot, they are only reported
+	for _, field := range restartRequiredChanges(previous, next) {
+		log.Warnf("config %s changed, restart required to apply it", field)
+	}
+
+	Conf.Rate = next.Rate
+	Conf.Burst = next.Burst
+	Conf.IpRate = next.IpRate
+	Conf.IpBurst = next.IpBurst
+	Conf.Interval = next.Interval
+	Conf.LogLevel = next.LogLevel
+	Conf.LogFormat = next.LogFormat
+	Conf.TesseractsConfig.Languages = next.TesseractsConfig.Languages
+	Conf.Redis.CacheTTL = next.Redis.CacheTTL
+	Conf.Idempotency.TTL = next.Idempotency.TTL
+	Conf.Profiles = next.Profiles
+	current := Conf
+	hooks := append([]func(previous, current Config){}, reloadHooks...)
+	mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(previous, current)
+	}
+}
+
+// restartRequiredChanges lists the changed fields that are not applied live
+func restartRequiredChanges(previous, next Config) []string {
+	clearLiveFields(&previous)
+	clearLiveFields(&next)
+
+	var changes []string
+	diffFields("", reflect.ValueOf(previous), reflect.ValueOf(next), &changes)
+	return changes
+}
+
+func clearLiveFields(conf *Config) {
+	conf.Rate = 0
+	conf.Burst = 0
+	conf.IpRate = 0
+	conf.IpBurst = 0
+	conf.Interval = ""
+	conf.LogLevel = ""
+	conf.LogFormat = ""
+	conf.TesseractsConfig.Languages = nil
+	conf.Redis.CacheTTL = ""
+	conf.Idempotency.TTL = ""
+	conf.Profiles = nil
+}
+
+// diffFields walks the nested structs and names the changed fields by their config key
+func diffFields(prefix string, previous, next reflect.Value, changes *[]string) {
+	for idx := 0; idx < previous.NumField(); idx++ {
+		field := previous.Type().Field(idx)
+		name := strings.TrimPrefix(prefix+"."+field.Tag.Get("mapstructure"), ".")
+		if previous.Field(idx).Kind() == reflect.Struct {
+			diffFields(name, previous.Field(idx), next.Field(idx), changes)
+			continue
+		}
+		if !reflect.DeepEqual(previous.Field(idx).Interface(), next.Field(idx).Interface()) {
+			*changes = append(*changes, name)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestReloadValidates(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	writeConfig := func(rate int, interval, language string) {
+		content := fmt.Sprintf("rate: %d\nburst: 1\ninterval: %s\nuploadDir: %s\nexportDir: %s\ntesseracts:\n  languages: [%s]\n",
+			rate, interval, filepath.Join(dir, "uploads"), filepath.Join(dir, "exports"), language)
+		if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		rate       int
+		interval   string
+		language   string
+		wantRate   int64
+		wantReload bool
+	}{
+		{name: "valid change is applied", rate: 20, interval: "minute", language: "eng", wantRate: 20, wantReload: true},
+		{name: "interval that is not a unit", rate: 30, interval: "fortnight", language: "eng", wantRate: 10},
+		{name: "negative rate", rate: -1, interval: "second", language: "eng", wantRate: 10},
+		{name: "language not installed", rate: 30, interval: "second", language: "klingon", wantRate: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfig(10, "second", "eng")
+			v := viper.New()
+			initialiseDefaults(v)
+			v.SetConfigFile(configFile)
+			if err := v.ReadInConfig(); err != nil {
+				t.Fatal(err)
+			}
+			if err := v.Unmarshal(&Conf); err != nil {
+				t.Fatal(err)
+			}
+			if err := Validate(Conf, []string{"eng"}); err != nil {
+				t.Fatalf("Validate() of the initial config error = %v", err)
+			}
+			configViper, availableLanguages = v, func() []string { return []string{"eng"} }
+			reloaded := false
+			reloadHooks = []func(previous, current Config){func(previous, current Config) { reloaded = true }}
+			t.Cleanup(func() {
+				Conf, configViper, availableLanguages, reloadHooks = Config{}, nil, nil, nil
+			})
+
+			writeConfig(tt.rate, tt.interval, tt.language)
+			if err := v.ReadInConfig(); err != nil {
+				t.Fatal(err)
+			}
+			reload()
+
+			if current := Current(); current.Rate != tt.wantRate {
+				t.Fatalf("got rate %d after the reload, want %d", current.Rate, tt.wantRate)
+			}
+			if reloaded != tt.wantReload {
+				t.Fatalf("got reload hooks called %v, want %v", reloaded, tt.wantReload)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"go-ocr/utils"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const redactedValue = "******"
+
+var validProfileName = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// maxZones is the most zones of a profile, each one is a recognition
+const maxZones = 20
+
+// ValidationError holds every problem found in the config so they can be fixed at once
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks the struct tags of the config, the installed languages and the upload dir,
+// availableLanguages are the tesseract languages installed on the host
+func Validate(conf Config, availableLanguages []string) error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("mapstructure")
+	})
+	_ = validate.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+		duration, err := time.ParseDuration(fl.Field().String())
+		return err == nil && duration > 0
+	})
+	_ = validate.RegisterValidation("unit_duration", func(fl validator.FieldLevel) bool {
+		return utils.IsDurationUnit(fl.Field().String())
+	})
+	_ = validate.RegisterValidation("tesseract_language", func(fl validator.FieldLevel) bool {
+		return utils.Contains(availableLanguages, fl.Field().String())
+	})
+	_ = validate.RegisterValidation("profile_name", func(fl validator.FieldLevel) bool {
+		return validProfileName.MatchString(fl.Field().String())
+	})
+
+	var problems []string
+	if err := validate.Struct(conf); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return err
+		}
+		for _, fieldError := range validationErrors {
+			problems = append(problems, describe(fieldError, availableLanguages))
+		}
+	}
+
+	profiles := make(map[string]bool, len(conf.Profiles))
+	for _, profile := range conf.Profiles {
+		if profiles[profile.Name] {
+			problems = append(problems, fmt.Sprintf("profiles %q is defined more than once", profile.Name))
+		}
+		profiles[profile.Name] = true
+		problems = append(problems, checkZones(profile)...)
+	}
+
+	// the default sign string is public, jwt needs a real secret or the keys of the issuer
+	if conf.Auth.EnableJwt && conf.Auth.JwksFile == "" && (conf.SignString == "" || conf.SignString == DefaultSignString) {
+		problems = append(problems, "signString must be set to a secret other than the default when auth.enableJwt is true without auth.jwksFile")
+	}
+
+	if conf.UploadDir != "" {
+		if err := checkWritableDir(conf.UploadDir); err != nil {
+			problems = append(problems, fmt.Sprintf("uploadDir %q is not writable: %v", conf.UploadDir, err))
+		}
+	}
+	if conf.ExportDir != "" {
+		if err := checkWritableDir(conf.ExportDir); err != nil {
+			problems = append(problems, fmt.Sprintf("exportDir %q is not writable: %v", conf.ExportDir, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func describe(fieldError validator.FieldError, availableLanguages []string) string {
+	field := strings.TrimPrefix(fieldError.Namespace(), "Config.")
+	value := fieldError.Value()
+
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "required_if":
+		// the param is the go name of the sibling field, show it like the config key
+		condition := strings.SplitN(fieldError.Param(), " ", 2)
+		sibling := strings.ToLower(condition[0][:1]) + condition[0][1:]
+		if idx := strings.LastIndex(field, "."); idx >= 0 {
+			sibling = field[:idx+1] + sibling
+		}
+		return fmt.Sprintf("%s is required when %s is %s", field, sibling, condition[len(condition)-1])
+	case "oneof":
+		return fmt.Sprintf("%s %q must be one of %s", field, value, fieldError.Param())
+	case "min":
+		return fmt.Sprintf("%s %v must be at least %s", field, value, fieldError.Param())
+	case "gt":
+		return fmt.Sprintf("%s %v must be above %s", field, value, fieldError.Param())
+	case "max":
+		return fmt.Sprintf("%s %v must be at most %s", field, value, fieldError.Param())
+	case "duration":
+		return fmt.Sprintf("%s %q is not a duration like 30s, 15m or 24h", field, value)
+	case "unit_duration":
+		return fmt.Sprintf("%s %q must be one of second minute hour day week month year", field, value)
+	case "tesseract_language":
+		return fmt.Sprintf("%s %q is not installed, available languages are %s", field, value, strings.Join(availableLanguages, " "))
+	case "profile_name":
+		return fmt.Sprintf("%s %q must be lower case letters, digits, _ and -", field, value)
+	case "ip|cidr":
+		return fmt.Sprintf("%s %q must be an ip or a cidr like 10.0.0.0/8", field, value)
+	case "file":
+		return fmt.Sprintf("%s %q is not a readable file", field, value)
+	default:
+		return fmt.Sprintf("%s %v is not a valid %s", field, value, fieldError.Tag())
+	}
+}
+
+// checkZones reports the zones of the profile sharing a name or going out of the page
+func checkZones(profile ProfileConfig) (problems []string) {
+	if len(profile.Zones) > maxZones {
+		problems = append(problems, fmt.Sprintf("profiles %q has %d zones, at most %d are allowed", profile.Name, len(profile.Zones), maxZones))
+	}
+	names := make(map[string]bool, len(profile.Zones))
+	for _, zone := range profile.Zones {
+		if names[zone.Name] {
+			problems = append(problems, fmt.Sprintf("profiles %q zone %q is defined more than once", profile.Name, zone.Name))
+		}
+		names[zone.Name] = true
+		if zone.Unit != "pixel" && (zone.X+zone.Width > 1 || zone.Y+zone.Height > 1) {
+			problems = append(problems, fmt.Sprintf("profiles %q zone %q must be inside the page, its coordinates are fractions of it", profile.Name, zone.Name))
+		}
+	}
+	return problems
+}
+
+// checkWritableDir creates the dir when it is missing and writes a probe file in it
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	_ = probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// Redacted returns the effective config keyed like the config file, with the secrets hidden
+func Redacted(conf Config) ([]byte, error) {
+	return json.MarshalIndent(redact(reflect.ValueOf(conf)), "", "  ")
+}
+
+func redact(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Struct:
+		result := make(map[string]interface{}, value.NumField())
+		for idx := 0; idx < value.NumField(); idx++ {
+			field := value.Type().Field(idx)
+			name := field.Tag.Get("mapstructure")
+			if field.Tag.Get("redact") == "true" && !value.Field(idx).IsZero() {
+				result[name] = redactedValue
+				continue
+			}
+			result[name] = redact(value.Field(idx))
+		}
+		return result
+	case reflect.Slice:
+		if value.IsNil() {
+			return nil
+		}
+		result := make([]interface{}, 0, value.Len())
+		for idx := 0; idx < value.Len(); idx++ {
+			result = append(result, redact(value.Index(idx)))
+		}
+		return result
+	default:
+		return value.Interface()
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
+	gormTracing "gorm.io/plugin/opentelemetry/tracing"
 )
 
 const (
@@ -90,7 +91,17 @@ func loadPsqlDb(logMode bool, psqlInfo string, maxOpenConn, maxIdleConn, maxLife
 		}
 	}
 
-	return gorm.Open(postgres.New(postgres.Config{
+	db, err := gorm.Open(postgres.New(postgres.Config{
 		Conn: conn,
 	}), gormConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// record every query as a span of the request
+	if err = db.Use(gormTracing.NewPlugin(gormTracing.WithoutMetrics())); err != nil {
+		return nil, err
+	}
+
+	return db, nil
 }
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"go-ocr/infrastructure/config"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	gormTracing "gorm.io/plugin/opentelemetry/tracing"
+)
+
+// sqlitePragmas let the readers run next to the single writer and wait for the lock instead of failing
+const sqlitePragmas = "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+
+func NewSqliteClient(conf *config.Config) (HandlerDatabase, error) {
+	if err := os.MkdirAll(filepath.Dir(conf.Sqlite.Path), os.ModePerm); err != nil {
+		log.Printf("failed create database sqlite directory: %v", err)
+		return HandlerDatabase{}, err
+	}
+
+	gormConfig := &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+		Logger: nil,
+	}
+	if conf.LogMode {
+		gormConfig.Logger = logger.Default.LogMode(logger.Info)
+	}
+
+	dbConn, err := gorm.Open(sqlite.Open(conf.Sqlite.Path+sqlitePragmas), gormConfig)
+	if err != nil {
+		log.Printf("failed to open database sqlite: %v", err)
+		return HandlerDatabase{}, err
+	}
+
+	// record every query as a span of the request
+	if err = dbConn.Use(gormTracing.NewPlugin(gormTracing.WithoutMetrics())); err != nil {
+		return HandlerDatabase{}, err
+	}
+
+	return HandlerDatabase{
+		DbConn: dbConn,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	TypeStatus   = "status"
+	TypeProgress = "progress"
+	TypeResult   = "result"
+
+	defaultHistorySize = 64
+	defaultRetention   = 10 * time.Minute
+)
+
+var (
+	ErrBrokerClosed = errors.New("events broker closed")
+)
+
+// Event is a message published on a topic, the id increase on every publish of
+// the topic so a subscriber can resume after the last event it received.
+type Event struct {
+	ID   int64           `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Broker is the pub/sub of the job events. The data is published as json so a
+// broker backed by redis can share the events between the instances.
+type Broker interface {
+	Publish(ctx context.Context, topic string, eventType string, data interface{}) (err error)
+	// Subscribe replay the kept events after lastEventID then stream the new ones,
+	// the channel is closed when the context is done or the subscriber is too slow
+	Subscribe(ctx context.Context, topic string, lastEventID int64) (stream <-chan Event, err error)
+	// LastEventID is zero when nothing was published on the topic or it expired
+	LastEventID(ctx context.Context, topic string) (id int64, err error)
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is the number of events a subscriber can lag behind before it is dropped
+const subscriberBuffer = 16
+
+type InMemoryBroker struct {
+	mu          sync.Mutex
+	topics      map[string]*topic
+	historySize int
+	retention   time.Duration
+	prunedAt    time.Time
+}
+
+type topic struct {
+	sequence    int64
+	history     []Event
+	subscribers map[chan Event]struct{}
+	updatedAt   time.Time
+}
+
+// NewInMemoryBroker keep the last events of each topic to resume the subscribers,
+// a topic without subscriber is forgotten after the retention.
+func NewInMemoryBroker(historySize int, retention time.Duration) *InMemoryBroker {
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	return &InMemoryBroker{
+		topics:      make(map[string]*topic),
+		historySize: historySize,
+		retention:   retention,
+		prunedAt:    time.Now(),
+	}
+}
+
+func (b *InMemoryBroker) Publish(ctx context.Context, name string, eventType string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.prune(now)
+
+	t := b.topic(name)
+	t.sequence++
+	t.updatedAt = now
+	event := Event{ID: t.sequence, Type: eventType, Data: raw}
+	t.history = append(t.history, event)
+	if len(t.history) > b.historySize {
+		t.history = t.history[len(t.history)-b.historySize:]
+	}
+
+	for subscriber := range t.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// the subscriber will resume from its last event id when it reconnects
+			delete(t.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+	return nil
+}
+
+func (b *InMemoryBroker) Subscribe(ctx context.Context, name string, lastEventID int64) (<-chan Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(name)
+	var replay []Event
+	for _, event := range t.history {
+		if event.ID > lastEventID {
+			replay = append(replay, event)
+		}
+	}
+
+	subscriber := make(chan Event, len(replay)+subscriberBuffer)
+	for _, event := range replay {
+		subscriber <- event
+	}
+	t.subscribers[subscriber] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := t.subscribers[subscriber]; ok {
+			delete(t.subscribers, subscriber)
+			close(subscriber)
+		}
+	}()
+
+	return subscriber, nil
+}
+
+func (b *InMemoryBroker) LastEventID(ctx context.Context, name string) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t, ok := b.topics[name]; ok {
+		return t.sequence, nil
+	}
+	return 0, nil
+}
+
+// topic returns the topic by name, created when missing, the lock must be held
+func (b *InMemoryBroker) topic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{
+			subscribers: make(map[chan Event]struct{}),
+			updatedAt:   time.Now(),
+		}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// prune forget the expired topics at most once per retention, the lock must be held
+func (b *InMemoryBroker) prune(now time.Time) {
+	if now.Sub(b.prunedAt) < b.retention {
+		return
+	}
+	b.prunedAt = now
+	for name, t := range b.topics {
+		if len(t.subscribers) == 0 && now.Sub(t.updatedAt) > b.retention {
+			delete(b.topics, name)
+		}
+	}
+}
@@ -1,17 +1,22 @@
 package httplib
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
+
+	logger "go-ocr/infrastructure/log"
 
 	"github.com/gin-gonic/gin"
 )
 
 type DefaultResponse struct {
-	Status    string      `json:"status"`
-	Code      int         `json:"code"`
-	Message   string      `json:"message"`
-	Data      interface{} `json:"data"`
-	DataError interface{} `json:"dataError"`
+	Status        string      `json:"status"`
+	Code          int         `json:"code"`
+	Message       string      `json:"message"`
+	Data          interface{} `json:"data"`
+	DataError     interface{} `json:"dataError"`
+	CorrelationID string      `json:"correlationId,omitempty"`
 }
 
 type DefaultPaginationResponse struct {
@@ -25,6 +30,25 @@ type DefaultPaginationResponse struct {
 	Data       interface{} `json:"data"`
 }
 
+// DefaultCursorResponse is the envelope of the lists paginated by cursor, the cursors
+// are empty when there is no next or previous page
+type DefaultCursorResponse struct {
+	Status     string      `json:"status"`
+	Code       int         `json:"code"`
+	Message    string      `json:"message"`
+	Limit      int         `json:"limit"`
+	TotalCount uint64      `json:"totalCount"`
+	NextCursor string      `json:"nextCursor"`
+	PrevCursor string      `json:"prevCursor"`
+	Data       interface{} `json:"data"`
+}
+
+// Link is a link of the RFC 8288 Link header
+type Link struct {
+	URL string
+	Rel string
+}
+
 func SetSuccessResponse(c *gin.Context, code int, message string, data interface{}) {
 	c.JSON(code, DefaultResponse{
 		Status:  http.StatusText(code),
@@ -49,23 +73,70 @@ func SetPaginationResponse(c *gin.Context, code int, message string, data interf
 	return
 }
 
+func SetCursorResponse(c *gin.Context, code int, message string, data interface{}, totalCount uint64, limit int, nextCursor, prevCursor string) {
+	c.JSON(code, DefaultCursorResponse{
+		Status:     http.StatusText(code),
+		Code:       code,
+		Message:    message,
+		Limit:      limit,
+		TotalCount: totalCount,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+		Data:       data,
+	})
+	return
+}
+
+// SetLinkHeader sets the RFC 8288 Link header, nothing is set without link
+func SetLinkHeader(c *gin.Context, links ...Link) {
+	values := make([]string, 0, len(links))
+	for _, link := range links {
+		values = append(values, fmt.Sprintf(`<%s>; rel="%s"`, link.URL, link.Rel))
+	}
+	if len(values) > 0 {
+		c.Header("Link", strings.Join(values, ", "))
+	}
+}
+
+// LinkWithQuery returns a link to the requested url with the query values replaced,
+// the empty values are removed from the query
+func LinkWithQuery(c *gin.Context, rel string, values map[string]string) Link {
+	target := *c.Request.URL
+	query := target.Query()
+	for key, value := range values {
+		if value == "" {
+			query.Del(key)
+			continue
+		}
+		query.Set(key, value)
+	}
+	target.RawQuery = query.Encode()
+	return Link{URL: target.RequestURI(), Rel: rel}
+}
+
 func SetErrorResponse(c *gin.Context, code int, message string) {
 	c.JSON(code, DefaultResponse{
-		Status:  http.StatusText(code),
-		Code:    code,
-		Data:    nil,
-		Message: message,
+		Status:        http.StatusText(code),
+		Code:          code,
+		Data:          nil,
+		Message:       message,
+		CorrelationID: c.GetString(logger.CorrelationID),
 	})
 	return
 }
 
 func SetCustomResponse(c *gin.Context, code int, message string, data interface{}, dataErr interface{}) {
+	var correlationID string
+	if dataErr != nil {
+		correlationID = c.GetString(logger.CorrelationID)
+	}
 	c.JSON(code, DefaultResponse{
-		Status:    http.StatusText(code),
-		Code:      code,
-		Data:      data,
-		Message:   message,
-		DataError: dataErr,
+		Status:        http.StatusText(code),
+		Code:          code,
+		Data:          data,
+		Message:       message,
+		DataError:     dataErr,
+		CorrelationID: correlationID,
 	})
 	return
 }
@@ -1,6 +1,7 @@
 package httplib
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
@@ -13,6 +14,15 @@ import (
 
 const (
 	defaultSize = 10
+	// MaxLimit is the largest page of the cursor pagination
+	MaxLimit = 1000
+)
+
+var (
+	errPageNotValid   = errors.New("page must be a number of at least 1")
+	errSizeNotValid   = errors.New("size must be a number of at least 1")
+	errLimitNotValid  = fmt.Errorf("limit must be a number between 1 and %d", MaxLimit)
+	errCursorNotValid = errors.New("cursor is not valid")
 )
 
 type Query struct {
@@ -28,22 +38,23 @@ func (q *Query) SetSize(sizeQuery string) error {
 		return nil
 	}
 	n, err := strconv.Atoi(sizeQuery)
-	if err != nil {
-		return err
+	if err != nil || n < 1 {
+		return errSizeNotValid
 	}
 	q.Size = n
 
 	return nil
 }
 
+// SetPage sets the 1 based page, the first page is the default
 func (q *Query) SetPage(pageQuery string) error {
 	if pageQuery == "" {
-		q.Page = 0
+		q.Page = 1
 		return nil
 	}
 	n, err := strconv.Atoi(pageQuery)
-	if err != nil {
-		return err
+	if err != nil || n < 1 {
+		return errPageNotValid
 	}
 	q.Page = n
 
@@ -71,7 +82,7 @@ func (q *Query) SetSortOrder(sortOrderByQuery string) {
 }
 
 func (q *Query) GetOffset() int {
-	if q.Page == 0 {
+	if q.Page <= 1 {
 		return 0
 	}
 	return (q.Page - 1) * q.Size
@@ -115,6 +126,35 @@ func GetPaginationFromCtx(c *gin.Context) (*Query, error) {
 	return q, nil
 }
 
+// CursorQuery is the keyset pagination, the cursor is opaque to the caller and empty on the first page
+type CursorQuery struct {
+	Cursor string
+	Limit  int
+}
+
+// IsCursorPagination reports whether the request asks for the cursor pagination instead of the pages
+func IsCursorPagination(c *gin.Context) bool {
+	_, hasCursor := c.GetQuery("cursor")
+	_, hasLimit := c.GetQuery("limit")
+	return hasCursor || hasLimit
+}
+
+func GetCursorFromCtx(c *gin.Context) (*CursorQuery, error) {
+	q := &CursorQuery{Cursor: c.Query("cursor"), Limit: defaultSize}
+	if limitQuery := c.Query("limit"); limitQuery != "" {
+		n, err := strconv.Atoi(limitQuery)
+		if err != nil || n < 1 || n > MaxLimit {
+			return nil, errLimitNotValid
+		}
+		q.Limit = n
+	}
+	if len(q.Cursor) > 1024 {
+		return nil, errCursorNotValid
+	}
+
+	return q, nil
+}
+
 func GetTotalPages(totalCount int, pageSize int) int {
 	d := float64(totalCount) / float64(pageSize)
 	return int(math.Ceil(d))
@@ -0,0 +1,81 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+)
+
+const maxMultipartMemory = 32 << 20
+
+// Fingerprint hash the method, path and body of the request. Multipart
+// bodies are hashed by their fields and file contents instead of the raw
+// bytes, because a retried upload is sent with a different boundary.
+func Fingerprint(req *http.Request) (string, error) {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := req.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return "", err
+		}
+		if err := hashMultipartForm(hash, req); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		// restore the body so the handler can still read it
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashMultipartForm(hash io.Writer, req *http.Request) error {
+	form := req.MultipartForm
+
+	valueKeys := make([]string, 0, len(form.Value))
+	for key := range form.Value {
+		valueKeys = append(valueKeys, key)
+	}
+	sort.Strings(valueKeys)
+	for _, key := range valueKeys {
+		for _, value := range form.Value[key] {
+			hash.Write([]byte("value:" + key + "=" + value + "\n"))
+		}
+	}
+
+	fileKeys := make([]string, 0, len(form.File))
+	for key := range form.File {
+		fileKeys = append(fileKeys, key)
+	}
+	sort.Strings(fileKeys)
+	for _, key := range fileKeys {
+		for _, fileHeader := range form.File[key] {
+			hash.Write([]byte("file:" + key + "=" + fileHeader.Filename + "\n"))
+			file, err := fileHeader.Open()
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(hash, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
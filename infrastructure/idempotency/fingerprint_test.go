@@ -0,0 +1,100 @@
+package idempotency
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// multipartRequest builds an upload of the fields and the file, the boundary is random on every call
+func multipartRequest(t *testing.T, fields map[string]string, fileName, fileContent string) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = part.Write([]byte(fileContent)); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/ocr", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func fingerprint(t *testing.T, req *http.Request) string {
+	t.Helper()
+	result, err := Fingerprint(req)
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	return result
+}
+
+func TestFingerprintMultipart(t *testing.T) {
+	fields := map[string]string{"lang": "eng", "documentType": "invoice"}
+	first := fingerprint(t, multipartRequest(t, fields, "a.png", "image"))
+
+	tests := []struct {
+		name      string
+		req       *http.Request
+		wantEqual bool
+	}{
+		{"same upload with another boundary", multipartRequest(t, fields, "a.png", "image"), true},
+		{"another file content", multipartRequest(t, fields, "a.png", "other image"), false},
+		{"another file name", multipartRequest(t, fields, "b.png", "image"), false},
+		{"another field", multipartRequest(t, map[string]string{"lang": "fra", "documentType": "invoice"}, "a.png", "image"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprint(t, tt.req); (got == first) != tt.wantEqual {
+				t.Fatalf("Fingerprint() equal to the first upload = %v, want %v", got == first, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestFingerprintBody(t *testing.T) {
+	newRequest := func(method, path, body string) *http.Request {
+		return httptest.NewRequest(method, path, strings.NewReader(body))
+	}
+
+	req := newRequest(http.MethodPost, "/v1/ocr/export", `{"format":"csv"}`)
+	first := fingerprint(t, req)
+
+	// the handler still reads the whole body
+	body, err := io.ReadAll(req.Body)
+	if err != nil || string(body) != `{"format":"csv"}` {
+		t.Fatalf("body after Fingerprint() = %q, error = %v", body, err)
+	}
+
+	tests := []struct {
+		name      string
+		req       *http.Request
+		wantEqual bool
+	}{
+		{"same request", newRequest(http.MethodPost, "/v1/ocr/export", `{"format":"csv"}`), true},
+		{"another body", newRequest(http.MethodPost, "/v1/ocr/export", `{"format":"json"}`), false},
+		{"another path", newRequest(http.MethodPost, "/v1/ocr/batches", `{"format":"csv"}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprint(t, tt.req); (got == first) != tt.wantEqual {
+				t.Fatalf("Fingerprint() equal to the first request = %v, want %v", got == first, tt.wantEqual)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderReplayed       = "Idempotent-Replayed"
+
+	StatusProcessing = "PROCESSING"
+	StatusCompleted  = "COMPLETED"
+
+	keyPrefix  = "idempotency:%s"
+	defaultTTL = 24 * time.Hour
+)
+
+var (
+	ErrKeyAlreadyExists = errors.New("idempotency key already exists")
+)
+
+// Record is what gets stored for every idempotency key, the fingerprint is
+// used to detect a replay with a different body and the rest is the
+// response that will be replayed to the client.
+type Record struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      string `json:"status"`
+	Code        int    `json:"code"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// Store persist the idempotency records, Reserve must be atomic so only one
+// request at a time can own the key.
+type Store interface {
+	Reserve(ctx context.Context, key string, record Record, ttl time.Duration) (err error)
+	Get(ctx context.Context, key string) (record Record, found bool, err error)
+	Save(ctx context.Context, key string, record Record, ttl time.Duration) (err error)
+	Delete(ctx context.Context, key string) (err error)
+	Close()
+}
+
+// ParseTTL parse the configured ttl, fallback to 24 hours when it's empty or invalid.
+func ParseTTL(input string) time.Duration {
+	if input == "" {
+		return defaultTTL
+	}
+	ttl, err := time.ParseDuration(input)
+	if err != nil || ttl <= 0 {
+		return defaultTTL
+	}
+	return ttl
+}
@@ -0,0 +1,115 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const cleanupInterval = time.Minute
+
+type inMemoryEntry struct {
+	record    Record
+	expiredAt time.Time
+}
+
+// InMemoryStore stores the idempotency records in memory, used when redis is disabled.
+type InMemoryStore struct {
+	entries  map[string]inMemoryEntry
+	mu       sync.Mutex
+	stop     chan struct{} // closed by Close to end the cleanup
+	stopped  chan struct{} // closed once the cleanup returned
+	stopOnce sync.Once
+}
+
+// NewInMemoryStore creates a new instance of Store backed by a map.
+func NewInMemoryStore() Store {
+	store := &InMemoryStore{
+		entries: make(map[string]inMemoryEntry),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go store.cleanupExpired()
+
+	return store
+}
+
+func (i *InMemoryStore) cleanupExpired() {
+	defer close(i.stopped)
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.stop:
+			return
+		case now := <-ticker.C:
+			i.mu.Lock()
+			for key, entry := range i.entries {
+				if now.After(entry.expiredAt) {
+					delete(i.entries, key)
+				}
+			}
+			i.mu.Unlock()
+		}
+	}
+}
+
+// Close stops the cleanup of the expired keys, the store still works afterwards.
+func (i *InMemoryStore) Close() {
+	i.stopOnce.Do(func() {
+		close(i.stop)
+	})
+	<-i.stopped
+}
+
+// Reserve set the key only when it doesn't exist yet or already expired.
+func (i *InMemoryStore) Reserve(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	entry, ok := i.entries[key]
+	if ok && time.Now().Before(entry.expiredAt) {
+		return ErrKeyAlreadyExists
+	}
+	i.entries[key] = inMemoryEntry{
+		record:    record,
+		expiredAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// Get retrieves the record by the idempotency key.
+func (i *InMemoryStore) Get(ctx context.Context, key string) (Record, bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	entry, ok := i.entries[key]
+	if !ok || time.Now().After(entry.expiredAt) {
+		return Record{}, false, nil
+	}
+	return entry.record, true, nil
+}
+
+// Save overwrite the record of the idempotency key.
+func (i *InMemoryStore) Save(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.entries[key] = inMemoryEntry{
+		record:    record,
+		expiredAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// Delete removes the idempotency key.
+func (i *InMemoryStore) Delete(ctx context.Context, key string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.entries, key)
+	return nil
+}
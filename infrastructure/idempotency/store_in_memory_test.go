@@ -0,0 +1,33 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInMemoryStoreClose(t *testing.T) {
+	store := NewInMemoryStore()
+
+	done := make(chan struct{})
+	go func() {
+		store.Close()
+		// a second close is a no op
+		store.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Close() didn't stop the cleanup")
+	}
+
+	ctx := context.Background()
+	if err := store.Reserve(ctx, "key", Record{Status: StatusProcessing}, time.Hour); err != nil {
+		t.Fatalf("Reserve() after Close() error = %v", err)
+	}
+	record, found, err := store.Get(ctx, "key")
+	if err != nil || !found || record.Status != StatusProcessing {
+		t.Fatalf("Get() after Close() = %+v, %v, %v, want the reserved record", record, found, err)
+	}
+}
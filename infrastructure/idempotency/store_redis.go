@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	redisLocal "go-ocr/infrastructure/redis"
+)
+
+// RedisStore stores the idempotency records on redis so every instance share the same keys.
+type RedisStore struct {
+	redisInterface redisLocal.LibInterface
+}
+
+// NewRedisStore creates a new instance of Store backed by redis.
+func NewRedisStore(redisInterface redisLocal.LibInterface) Store {
+	return &RedisStore{
+		redisInterface: redisInterface,
+	}
+}
+
+// Reserve set the key only when it doesn't exist yet.
+func (r *RedisStore) Reserve(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	err = r.redisInterface.SetIdempotencyKey(ctx, fmt.Sprintf(keyPrefix, key), recordBytes, ttl)
+	if errors.Is(err, redisLocal.ErrMultipleKeyInCache) {
+		return ErrKeyAlreadyExists
+	}
+	return err
+}
+
+// Get retrieves the record by the idempotency key.
+func (r *RedisStore) Get(ctx context.Context, key string) (Record, bool, error) {
+	value := r.redisInterface.Get(ctx, fmt.Sprintf(keyPrefix, key))
+	if value == "" {
+		return Record{}, false, nil
+	}
+	var record Record
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return Record{}, false, err
+	}
+	return record, true, nil
+}
+
+// Save overwrite the record of the idempotency key.
+func (r *RedisStore) Save(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return r.redisInterface.Set(ctx, fmt.Sprintf(keyPrefix, key), recordBytes, ttl)
+}
+
+// Delete removes the idempotency key.
+func (r *RedisStore) Delete(ctx context.Context, key string) error {
+	return r.redisInterface.DeleteKey(ctx, fmt.Sprintf(keyPrefix, key))
+}
+
+// Close does nothing, the redis client is closed by its owner.
+func (r *RedisStore) Close() {}
@@ -0,0 +1,218 @@
+// Package imaging holds the preprocessing steps run on an image before the recognition
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+)
+
+// the preprocessing steps, they run in the order of the profile
+const (
+	// StepGrayscale drops the colors
+	StepGrayscale = "grayscale"
+	// StepThreshold turns the image black and white with the otsu threshold
+	StepThreshold = "threshold"
+	// StepInvert makes a light text on a dark background dark on light
+	StepInvert = "invert"
+	// StepUpscale doubles the size, tesseract reads the small text better
+	StepUpscale = "upscale"
+)
+
+var steps = map[string]func(image.Image) image.Image{
+	StepGrayscale: grayscale,
+	StepThreshold: threshold,
+	StepInvert:    invert,
+	StepUpscale:   upscale,
+}
+
+// IsValidStep reports whether the step is one of the preprocessing steps
+func IsValidStep(step string) bool {
+	_, ok := steps[step]
+	return ok
+}
+
+// Apply runs the steps on the image in order
+func Apply(img image.Image, names []string) (image.Image, error) {
+	for _, name := range names {
+		step, ok := steps[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown preprocessing step %q", name)
+		}
+		img = step(img)
+	}
+	return img, nil
+}
+
+// Scale returns how many times the steps enlarge the image
+func Scale(names []string) int {
+	scale := 1
+	for _, name := range names {
+		if name == StepUpscale {
+			scale *= 2
+		}
+	}
+	return scale
+}
+
+// Preprocess decodes the png, jpeg or gif image, runs the steps on it and returns it as png
+func Preprocess(r io.Reader, names []string) ([]byte, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	img, err = Apply(img, names)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(img)
+}
+
+// Encode returns the image as png
+func Encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Crop returns the part of the image inside the rectangle, its origin is moved to 0, 0.
+// The rectangle is relative to the top left of the image and is clipped to it.
+func Crop(img image.Image, rect image.Rectangle) image.Image {
+	bounds := img.Bounds()
+	rect = rect.Add(bounds.Min).Intersect(bounds)
+	result := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(result, result.Bounds(), img, rect.Min, draw.Src)
+	return result
+}
+
+func grayscale(img image.Image) image.Image {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray
+	}
+	gray := image.NewGray(img.Bounds())
+	draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+	return gray
+}
+
+func threshold(img image.Image) image.Image {
+	gray := grayscale(img).(*image.Gray)
+
+	var histogram [256]int
+	for _, value := range gray.Pix {
+		histogram[value]++
+	}
+	level := otsuLevel(histogram, len(gray.Pix))
+
+	result := image.NewGray(gray.Bounds())
+	for idx, value := range gray.Pix {
+		if value > level {
+			result.Pix[idx] = 255
+		}
+	}
+	return result
+}
+
+// otsuLevel returns the gray level splitting the histogram in the two most separated classes
+func otsuLevel(histogram [256]int, total int) uint8 {
+	var sum float64
+	for level, count := range histogram {
+		sum += float64(level * count)
+	}
+
+	var best float64
+	var level uint8
+	var backgroundSum float64
+	background := 0
+	for idx, count := range histogram {
+		background += count
+		if background == 0 {
+			continue
+		}
+		foreground := total - background
+		if foreground == 0 {
+			break
+		}
+		backgroundSum += float64(idx * count)
+		backgroundMean := backgroundSum / float64(background)
+		foregroundMean := (sum - backgroundSum) / float64(foreground)
+		between := float64(background) * float64(foreground) * (backgroundMean - foregroundMean) * (backgroundMean - foregroundMean)
+		if between > best {
+			best = between
+			level = uint8(idx)
+		}
+	}
+	return level
+}
+
+func invert(img image.Image) image.Image {
+	if gray, ok := img.(*image.Gray); ok {
+		result := image.NewGray(gray.Bounds())
+		for idx, value := range gray.Pix {
+			result.Pix[idx] = 255 - value
+		}
+		return result
+	}
+
+	bounds := img.Bounds()
+	result := image.NewRGBA(bounds)
+	draw.Draw(result, bounds, img, bounds.Min, draw.Src)
+	for idx := 0; idx < len(result.Pix); idx += 4 {
+		// the alpha is kept, the colors are premultiplied by it
+		alpha := result.Pix[idx+3]
+		for channel := 0; channel < 3; channel++ {
+			result.Pix[idx+channel] = alpha - result.Pix[idx+channel]
+		}
+	}
+	return result
+}
+
+// upscale doubles the image with a bilinear interpolation
+func upscale(img image.Image) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return img
+	}
+
+	at := func(x, y int) (r, g, b, a float64) {
+		cr, cg, cb, ca := img.At(bounds.Min.X+min(x, width-1), bounds.Min.Y+min(y, height-1)).RGBA()
+		return float64(cr), float64(cg), float64(cb), float64(ca)
+	}
+	mix := func(a, b, weight float64) float64 {
+		return a + (b-a)*weight
+	}
+
+	_, isGray := img.(*image.Gray)
+	var result draw.Image = image.NewRGBA64(image.Rect(0, 0, width*2, height*2))
+	if isGray {
+		result = image.NewGray(image.Rect(0, 0, width*2, height*2))
+	}
+	for y := 0; y < height*2; y++ {
+		sourceY := max(float64(y)/2-0.25, 0)
+		top, weightY := int(sourceY), sourceY-float64(int(sourceY))
+		for x := 0; x < width*2; x++ {
+			sourceX := max(float64(x)/2-0.25, 0)
+			left, weightX := int(sourceX), sourceX-float64(int(sourceX))
+
+			r00, g00, b00, a00 := at(left, top)
+			r10, g10, b10, a10 := at(left+1, top)
+			r01, g01, b01, a01 := at(left, top+1)
+			r11, g11, b11, a11 := at(left+1, top+1)
+			result.Set(x, y, color.RGBA64{
+				R: uint16(mix(mix(r00, r10, weightX), mix(r01, r11, weightX), weightY)),
+				G: uint16(mix(mix(g00, g10, weightX), mix(g01, g11, weightX), weightY)),
+				B: uint16(mix(mix(b00, b10, weightX), mix(b01, b11, weightX), weightY)),
+				A: uint16(mix(mix(a00, a10, weightX), mix(a01, a11, weightX), weightY)),
+			})
+		}
+	}
+	return result
+}
@@ -1,55 +1,182 @@
 package limiter
 
-import "time"
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// LimiterInterface is implemented by the in process and the redis limiter
+type LimiterInterface interface {
+	Allow(ctx context.Context, key string) Result
+	Update(rate int, interval time.Duration, burst int)
+	Close()
+}
 
 type RateLimiter struct {
-	rate       int           // Number of actions allowed per time window
-	interval   time.Duration // Time window duration
-	tokenCount int           // Available tokens at the moment
-	tokens     chan struct{} // Channel to hold tokens
+	rate        int           // Number of actions allowed per time window
+	interval    time.Duration // Time window duration
+	burst       int           // Maximum tokens a single bucket can hold
+	idleTimeout time.Duration // Idle buckets older than this are evicted
+	buckets     map[string]*bucket
+	mu          sync.Mutex
+	stop        chan struct{} // closed by Close to end the eviction
+	stopped     chan struct{} // closed once the eviction returned
+	stopOnce    sync.Once
+}
+
+// bucket is the token bucket of a single client
+type bucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// Result is the outcome of Allow, used to build the rate limit headers
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until the next token, zero when allowed
+}
+
+func NewRateLimiter(rate int, interval time.Duration, burst int) *RateLimiter {
+	limiter := &RateLimiter{
+		buckets: make(map[string]*bucket),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	limiter.setLimits(rate, interval, burst)
+
+	go limiter.evictIdleBuckets()
+
+	return limiter
 }
 
-func NewRateLimiter(rate int, interval time.Duration) *RateLimiter {
-	if rate == 0 {
+// normalizeLimits apply the defaults, at least one action per second and a burst of one interval
+func normalizeLimits(rate int, interval time.Duration, burst int) (int, time.Duration, int) {
+	if rate <= 0 {
 		rate = 1
 	}
 
-	if interval == 0 {
+	if interval <= 0 {
 		interval = time.Second
 	}
 
-	limiter := &RateLimiter{
-		rate:       rate,
-		interval:   interval,
-		tokenCount: rate,
-		tokens:     make(chan struct{}, rate),
+	if burst <= 0 {
+		burst = rate
 	}
 
-	go limiter.refillTokens()
+	return rate, interval, burst
+}
 
-	return limiter
+// Update changes the limits live, the existing buckets keep their tokens up to the new burst
+func (limiter *RateLimiter) Update(rate int, interval time.Duration, burst int) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.setLimits(rate, interval, burst)
+}
+
+// setLimits the caller must hold the lock once the limiter is shared
+func (limiter *RateLimiter) setLimits(rate int, interval time.Duration, burst int) {
+	limiter.rate, limiter.interval, limiter.burst = normalizeLimits(rate, interval, burst)
+
+	// a bucket idle for the time it needs to be full again is the same as a new one
+	limiter.idleTimeout = limiter.durationForTokens(float64(limiter.burst))
+	if limiter.idleTimeout < time.Second {
+		limiter.idleTimeout = time.Second
+	}
+}
+
+// tokensPerSecond is the refill speed, rate tokens spread over the interval
+func (limiter *RateLimiter) tokensPerSecond() float64 {
+	return float64(limiter.rate) / limiter.interval.Seconds()
+}
+
+func (limiter *RateLimiter) durationForTokens(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / limiter.tokensPerSecond() * float64(time.Second)))
 }
 
-func (limiter *RateLimiter) refillTokens() {
+func (limiter *RateLimiter) evictIdleBuckets() {
+	defer close(limiter.stopped)
+
+	limiter.mu.Lock()
+	idleTimeout := limiter.idleTimeout
+	limiter.mu.Unlock()
+
+	ticker := time.NewTicker(idleTimeout)
+	defer ticker.Stop()
+
 	for {
 		select {
-		case <-time.After(limiter.interval / time.Duration(limiter.rate)):
-			for i := 0; i < limiter.rate; i++ {
-				select {
-				case limiter.tokens <- struct{}{}:
-				default:
-					//just next the request, don't block it
+		case <-limiter.stop:
+			return
+		case now := <-ticker.C:
+			limiter.mu.Lock()
+			for key, b := range limiter.buckets {
+				if now.Sub(b.lastRefill) >= limiter.idleTimeout {
+					delete(limiter.buckets, key)
 				}
 			}
+			// follow the limits updated live
+			if idleTimeout != limiter.idleTimeout {
+				idleTimeout = limiter.idleTimeout
+				ticker.Reset(idleTimeout)
+			}
+			limiter.mu.Unlock()
 		}
 	}
 }
 
-func (limiter *RateLimiter) Allow() bool {
-	select {
-	case <-limiter.tokens:
-		return true
-	default:
-		return false
+// Close stops the eviction of the idle buckets, the limiter still allows afterwards
+func (limiter *RateLimiter) Close() {
+	limiter.stopOnce.Do(func() {
+		close(limiter.stop)
+	})
+	<-limiter.stopped
+}
+
+// Allow takes one token from the bucket of the given key
+func (limiter *RateLimiter) Allow(ctx context.Context, key string) Result {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	b, ok := limiter.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens:     float64(limiter.burst),
+			lastRefill: now,
+		}
+		limiter.buckets[key] = b
+	}
+
+	// refill based on the elapsed time since the last request
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.tokens = math.Min(float64(limiter.burst), b.tokens+elapsed*limiter.tokensPerSecond())
+	b.lastRefill = now
+
+	result := Result{
+		Limit: limiter.burst,
 	}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = limiter.durationForTokens(1 - b.tokens)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.ResetAfter = limiter.durationForTokens(float64(limiter.burst) - b.tokens)
+
+	return result
 }
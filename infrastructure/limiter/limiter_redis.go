@@ -0,0 +1,139 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	redisLocal "go-ocr/infrastructure/redis"
+
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	redisKeyRateLimit = "rate_limit:%s"
+	// redisRetryInterval is how long the fallback is used before redis is tried again
+	redisRetryInterval = 5 * time.Second
+)
+
+// gcraScript implements the generic cell rate algorithm, the state of a key
+// is only its theoretical arrival time (tat) in microseconds, taken from the
+// redis clock so every instance agrees on the time. redis before 5 refuses a
+// write after TIME unless the script replicates its commands instead of itself.
+//
+// ARGV[1] burst, ARGV[2] emission interval in microseconds
+// returns {allowed, remaining, retry after, reset after} in microseconds
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local burst = tonumber(ARGV[1])
+local emission = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000000 + tonumber(clock[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local newTat = tat + emission
+local allowAt = newTat - emission * burst
+local diff = now - allowAt
+if diff < 0 then
+	return {0, 0, -diff, tat - now}
+end
+
+local resetAfter = newTat - now
+redis.call('SET', KEYS[1], newTat, 'PX', math.ceil(resetAfter / 1000))
+return {1, math.floor(diff / emission), 0, resetAfter}
+`)
+
+// RedisRateLimiter shares the buckets across every instance using redis,
+// the in process limiter is used as fallback when redis is unreachable.
+type RedisRateLimiter struct {
+	redisClient *redis.Client
+	rate        int
+	interval    time.Duration
+	burst       int
+	mu          sync.RWMutex
+	fallback    *RateLimiter
+	degraded    atomic.Bool
+	failedAt    atomic.Int64
+}
+
+func NewRedisRateLimiter(redisClient *redis.Client, rate int, interval time.Duration, burst int) *RedisRateLimiter {
+	rate, interval, burst = normalizeLimits(rate, interval, burst)
+
+	return &RedisRateLimiter{
+		redisClient: redisClient,
+		rate:        rate,
+		interval:    interval,
+		burst:       burst,
+		fallback:    NewRateLimiter(rate, interval, burst),
+	}
+}
+
+// Update changes the limits live, the state stored in redis is kept
+func (limiter *RedisRateLimiter) Update(rate int, interval time.Duration, burst int) {
+	limiter.mu.Lock()
+	limiter.rate, limiter.interval, limiter.burst = normalizeLimits(rate, interval, burst)
+	limiter.mu.Unlock()
+
+	limiter.fallback.Update(rate, interval, burst)
+}
+
+// Close stops the fallback, the state stored in redis is kept
+func (limiter *RedisRateLimiter) Close() {
+	limiter.fallback.Close()
+}
+
+// Allow takes one token from the shared bucket of the given key
+func (limiter *RedisRateLimiter) Allow(ctx context.Context, key string) Result {
+	limiter.mu.RLock()
+	burst := limiter.burst
+	emission := limiter.interval.Microseconds() / int64(limiter.rate)
+	limiter.mu.RUnlock()
+	if emission <= 0 {
+		emission = 1
+	}
+
+	// don't wait for the redis timeout on every request while it's down
+	if limiter.degraded.Load() && time.Since(time.Unix(0, limiter.failedAt.Load())) < redisRetryInterval {
+		return limiter.fallback.Allow(ctx, key)
+	}
+
+	values, err := gcraScript.Run(redisLocal.WithTracing(ctx, limiter.redisClient), []string{fmt.Sprintf(redisKeyRateLimit, key)}, burst, emission).Result()
+	if err != nil {
+		limiter.failedAt.Store(time.Now().UnixNano())
+		if !limiter.degraded.Swap(true) {
+			log.Warnf("redis rate limiter unreachable, fallback to in process limiter: %v", err)
+		}
+		return limiter.fallback.Allow(ctx, key)
+	}
+	if limiter.degraded.Swap(false) {
+		log.Info("redis rate limiter reachable again")
+	}
+
+	result, ok := values.([]interface{})
+	if !ok || len(result) != 4 {
+		log.Warnf("redis rate limiter got unexpected result: %v", values)
+		return limiter.fallback.Allow(ctx, key)
+	}
+
+	return Result{
+		Allowed:    toInt64(result[0]) == 1,
+		Limit:      burst,
+		Remaining:  int(toInt64(result[1])),
+		RetryAfter: time.Duration(toInt64(result[2])) * time.Microsecond,
+		ResetAfter: time.Duration(toInt64(result[3])) * time.Microsecond,
+	}
+}
+
+func toInt64(value interface{}) int64 {
+	if v, ok := value.(int64); ok {
+		return v
+	}
+	return 0
+}
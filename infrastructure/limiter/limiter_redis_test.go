@@ -0,0 +1,75 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+func TestRedisRateLimiterScript(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	// one token per minute with a burst of two, the time is the clock of redis
+	base := time.Unix(1700000000, 0)
+	server.SetTime(base)
+	rateLimiter := NewRedisRateLimiter(client, 1, time.Minute, 2)
+
+	tests := []struct {
+		name           string
+		elapsed        time.Duration
+		wantAllowed    bool
+		wantRemaining  int
+		wantRetryAfter time.Duration
+	}{
+		{name: "first request", wantAllowed: true, wantRemaining: 1},
+		{name: "burst", wantAllowed: true, wantRemaining: 0},
+		{name: "over the burst", wantAllowed: false, wantRetryAfter: time.Minute},
+		{name: "a token came back", elapsed: time.Minute, wantAllowed: true, wantRemaining: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.SetTime(base.Add(tt.elapsed))
+			got := rateLimiter.Allow(context.Background(), "client")
+
+			// the fallback limits the same way, so only the state of redis tells the script ran
+			if rateLimiter.degraded.Load() {
+				t.Fatalf("Allow() used the fallback, the script failed")
+			}
+			if got.Allowed != tt.wantAllowed || got.Remaining != tt.wantRemaining || got.RetryAfter != tt.wantRetryAfter {
+				t.Fatalf("Allow() = %+v, want allowed %v remaining %d retry after %v", got, tt.wantAllowed, tt.wantRemaining, tt.wantRetryAfter)
+			}
+			if got.Limit != 2 {
+				t.Fatalf("Allow() limit = %d, want 2", got.Limit)
+			}
+		})
+	}
+
+	key := fmt.Sprintf(redisKeyRateLimit, "client")
+	if !server.Exists(key) || server.TTL(key) <= 0 {
+		t.Fatalf("%s got no state with an expiry in redis", key)
+	}
+}
+
+func TestRedisRateLimiterFallback(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	server.Close()
+
+	rateLimiter := NewRedisRateLimiter(client, 1, time.Minute, 1)
+	if got := rateLimiter.Allow(context.Background(), "client"); !got.Allowed {
+		t.Fatalf("Allow() = %+v, want the first request allowed by the fallback", got)
+	}
+	if !rateLimiter.degraded.Load() {
+		t.Fatalf("Allow() with redis down is not degraded")
+	}
+	if got := rateLimiter.Allow(context.Background(), "client"); got.Allowed {
+		t.Fatalf("Allow() = %+v, want the fallback to keep limiting", got)
+	}
+}
@@ -0,0 +1,44 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEvictIdleBuckets(t *testing.T) {
+	// the idle timeout is the time to refill the burst, at least a second
+	rateLimiter := NewRateLimiter(10, time.Second, 1)
+	rateLimiter.Allow(context.Background(), "client")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rateLimiter.mu.Lock()
+		remaining := len(rateLimiter.buckets)
+		rateLimiter.mu.Unlock()
+		if remaining == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("evictIdleBuckets() kept %d idle buckets", remaining)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		rateLimiter.Close()
+		// a second close is a no op
+		rateLimiter.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Close() didn't stop the eviction")
+	}
+
+	if got := rateLimiter.Allow(context.Background(), "client"); !got.Allowed {
+		t.Fatalf("Allow() after Close() = %+v, want allowed", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"go-ocr/utils"
 
 	"github.com/gookit/event"
+	log "github.com/sirupsen/logrus"
 )
 
 type Listener struct {
@@ -35,15 +36,19 @@ func (l *Listener) ListenForShutdownEvent() {
 // TriggerShutdown sends a signal to the repository and performs shutdown actions.
 func (l *Listener) TriggerShutdown() {
 	//need to call save in memory data to json file
-	if !config.Conf.Postgres.EnablePostgres {
-		//l.ocrHttp.SaveToFile()
+	if !config.Conf.Postgres.EnablePostgres && !config.Conf.Sqlite.EnableSqlite && config.Conf.Persistence.EnablePersistence {
+		if err := l.ocrHttp.SaveToFile(); err != nil {
+			log.Errorf("failed save in memory data to file: %v", err)
+		}
 	}
 }
 
 // TriggerStartUp sends a signal to the repository and performs start up actions.
 // this call should be not initiated on event because we can just call it on the main.go
 func (l *Listener) TriggerStartUp() {
-	if !config.Conf.Postgres.EnablePostgres {
-		//l.ocrHttp.LoadFromFile()
+	if !config.Conf.Postgres.EnablePostgres && !config.Conf.Sqlite.EnableSqlite && config.Conf.Persistence.EnablePersistence {
+		if err := l.ocrHttp.LoadFromFile(); err != nil {
+			log.Fatalf("failed load in memory data from file: %v", err)
+		}
 	}
 }
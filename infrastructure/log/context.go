@@ -0,0 +1,64 @@
+package log
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextField is a value of the context added to every log entry under its name
+type contextField struct {
+	name  string
+	value func(ctx context.Context) (interface{}, bool)
+}
+
+var (
+	contextFields []contextField
+	// detachers copy a value of the request context to the context given by Detach
+	detachers []func(from, to context.Context) context.Context
+)
+
+// RegisterField logs the value read from the context under the name, the packages that
+// put a value on the context register it so the logger doesn't depend on them. It must
+// be called from an init function, before anything is logged.
+func RegisterField(name string, value func(ctx context.Context) (interface{}, bool)) {
+	contextFields = append(contextFields, contextField{name: name, value: value})
+}
+
+// RegisterDetach keeps a value of the request context on the context given by Detach,
+// like RegisterField it must be called from an init function
+func RegisterDetach(keep func(from, to context.Context) context.Context) {
+	detachers = append(detachers, keep)
+}
+
+// WithCorrelationID return a copy of the context carrying the correlation id
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, CorrelationID, correlationID)
+}
+
+// CorrelationIDFromContext get the correlation id of the request, empty when there is none
+func CorrelationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	correlationID, _ := ctx.Value(CorrelationID).(string)
+	return correlationID
+}
+
+// Detach returns a background context that keeps the correlation id, the span and the
+// registered values of the request, like the caller identity and the tenant. It must be
+// used by the goroutines that outlive the request, because the request context is
+// canceled and the gin context is reused once the handler returns.
+func Detach(ctx context.Context) context.Context {
+	detached := context.Background()
+	if correlationID := CorrelationIDFromContext(ctx); correlationID != "" {
+		detached = WithCorrelationID(detached, correlationID)
+	}
+	for _, keep := range detachers {
+		detached = keep(ctx, detached)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		detached = trace.ContextWithSpanContext(detached, spanContext)
+	}
+	return detached
+}
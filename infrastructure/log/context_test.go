@@ -0,0 +1,51 @@
+package log_test
+
+import (
+	"context"
+	"testing"
+
+	"go-ocr/infrastructure/auth"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+)
+
+func TestDetach(t *testing.T) {
+	identity := auth.Identity{ID: "1", Type: auth.TypeApiKey}
+	tests := []struct {
+		name              string
+		ctx               context.Context
+		wantCorrelationID string
+		wantIdentity      bool
+		wantTenant        string
+	}{
+		{name: "empty request", ctx: context.Background(), wantTenant: tenant.DefaultTenantID},
+		{
+			name:              "request of a caller",
+			ctx:               tenant.WithTenant(auth.WithIdentity(logger.WithCorrelationID(context.Background(), "abc"), identity), "acme"),
+			wantCorrelationID: "abc",
+			wantIdentity:      true,
+			wantTenant:        "acme",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(tt.ctx)
+			cancel()
+
+			// the values registered by auth and tenant are kept, the cancellation is not
+			detached := logger.Detach(ctx)
+			if detached.Err() != nil {
+				t.Fatalf("Detach() error = %v, want a context not canceled", detached.Err())
+			}
+			if got := logger.CorrelationIDFromContext(detached); got != tt.wantCorrelationID {
+				t.Fatalf("Detach() correlation id = %q, want %q", got, tt.wantCorrelationID)
+			}
+			if got, ok := auth.FromContext(detached); ok != tt.wantIdentity || (ok && got.String() != identity.String()) {
+				t.Fatalf("Detach() identity = %v, %v, want %v", got, ok, tt.wantIdentity)
+			}
+			if got := tenant.FromContext(detached); got != tt.wantTenant {
+				t.Fatalf("Detach() tenant = %q, want %q", got, tt.wantTenant)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"io"
 	"os"
 	"strings"
 
@@ -10,6 +11,15 @@ import (
 
 const CorrelationID string = "X-Correlation-ID"
 
+// output is kept so a later Init, on config reload, does not move the logs back to stdout
+var output io.Writer = os.Stdout
+
+// SetOutput moves the logs, the command line writes them on stderr to keep stdout for the results
+func SetOutput(w io.Writer) {
+	output = w
+	log.SetOutput(w)
+}
+
 func Init(logFormat, logLevel string) {
 	switch strings.ToLower(logFormat) {
 	case "json":
@@ -31,14 +41,20 @@ func Init(logFormat, logLevel string) {
 	default:
 		log.SetLevel(log.InfoLevel)
 	}
-	log.SetOutput(os.Stdout)
+	log.SetOutput(output)
 }
 
 func getEntry(ctx context.Context, ctxName string) *log.Entry {
-	return log.WithFields(log.Fields{
+	fields := log.Fields{
 		"context":       ctxName,
 		"correlationId": ctx.Value(CorrelationID),
-	})
+	}
+	for _, field := range contextFields {
+		if value, ok := field.value(ctx); ok {
+			fields[field.name] = value
+		}
+	}
+	return log.WithFields(fields)
 }
 
 func Info(ctx context.Context, ctxName string, format string, args ...interface{}) {
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "go_ocr"
+
+const (
+	CacheRedis = "redis"
+
+	CacheResultHit  = "hit"
+	CacheResultMiss = "miss"
+
+	LimiterScopeGlobal   = "global"
+	LimiterScopeIdentity = "identity"
+	LimiterScopeTenant   = "tenant"
+)
+
+var (
+	HttpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total of the http requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the http requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	OcrDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ocr_duration_seconds",
+		Help:      "Duration of the tesseract recognition by language and page segmentation mode.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"language", "psm"})
+
+	EnginePoolSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ocr_engine_pool_size",
+		Help:      "Number of tesseract engines available.",
+	})
+
+	EngineInUse = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ocr_engine_in_use",
+		Help:      "Number of tesseract engines currently recognizing.",
+	})
+
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ocr_queue_depth",
+		Help:      "Number of requests waiting for a tesseract engine.",
+	})
+
+	CacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Total of the cache lookups by cache and result, the hit ratio is hit / (hit + miss).",
+	}, []string{"cache", "result"})
+
+	RateLimitRejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Total of the requests rejected by the rate limiter.",
+	}, []string{"scope"})
+
+	UploadSizeBytes = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ocr_upload_size_bytes",
+		Help:      "Size of the uploaded files.",
+		Buckets:   prometheus.ExponentialBuckets(16*1024, 4, 8),
+	})
+)
+
+// Handler serve the metrics in the prometheus text format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
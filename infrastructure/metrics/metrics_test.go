@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// a vector is only exposed once it has a series
+	HttpRequestsTotal.WithLabelValues(http.MethodGet, "/api/v1/ocr/:id", "200")
+	HttpRequestDuration.WithLabelValues(http.MethodGet, "/api/v1/ocr/:id", "200")
+	OcrDuration.WithLabelValues("eng", "default")
+	CacheRequestsTotal.WithLabelValues(CacheRedis, CacheResultHit)
+	RateLimitRejectionsTotal.WithLabelValues(LimiterScopeTenant)
+
+	engine := gin.New()
+	engine.GET("/metrics", Handler())
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /metrics status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	body := recorder.Body.String()
+
+	tests := []struct {
+		name       string
+		wantSeries string
+	}{
+		{name: "http requests", wantSeries: `go_ocr_http_requests_total{method="GET",route="/api/v1/ocr/:id",status="200"}`},
+		{name: "http latency", wantSeries: `go_ocr_http_request_duration_seconds_bucket{method="GET",route="/api/v1/ocr/:id",status="200",le="+Inf"}`},
+		{name: "recognition duration", wantSeries: `go_ocr_ocr_duration_seconds_bucket{language="eng",psm="default",le="60"}`},
+		{name: "engine pool", wantSeries: "go_ocr_ocr_engine_pool_size "},
+		{name: "engine in use", wantSeries: "go_ocr_ocr_engine_in_use "},
+		{name: "queue depth", wantSeries: "go_ocr_ocr_queue_depth "},
+		{name: "cache", wantSeries: `go_ocr_cache_requests_total{cache="redis",result="hit"}`},
+		{name: "rate limit", wantSeries: `go_ocr_rate_limit_rejections_total{scope="tenant"}`},
+		{name: "upload size", wantSeries: `go_ocr_ocr_upload_size_bytes_bucket{le="16384"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(body, tt.wantSeries) {
+				t.Fatalf("GET /metrics is missing %s", tt.wantSeries)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/httplib"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthMiddleware authenticate the caller using the X-API-Key or the
+// Authorization Bearer header and put the identity into the gin and request
+// context. A bearer that looks like a jwt is verified by the jwt verifier,
+// anything else is treated as an api key. A nil authenticator or verifier
+// disable that kind of credential.
+func AuthMiddleware(authenticator auth.Authenticator, jwtVerifier *auth.JwtVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logCtx := "middleware.AuthMiddleware"
+
+		identity, err := authenticate(c, authenticator, jwtVerifier, c.GetHeader(HeaderApiKey), c.GetHeader("Authorization"))
+		if err != nil {
+			if errors.Is(err, auth.ErrMissingCredential) || errors.Is(err, auth.ErrInvalidCredential) {
+				logger.Debug(c, logCtx, "authentication failed: %v", err)
+				httplib.SetErrorResponse(c, http.StatusUnauthorized, unwrapAuthError(err).Error())
+				c.Abort()
+				return
+			}
+			logger.Error(c, utils.ErrorLogFormat, err.Error(), logCtx, "authenticate")
+			httplib.SetErrorResponse(c, http.StatusInternalServerError, "oops, something went wrong!")
+			c.Abort()
+			return
+		}
+
+		c.Set(auth.IdentityKey, identity)
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+		c.Next()
+	}
+}
+
+// authenticate resolve the identity of the api key or the Authorization header,
+// shared by the http middleware and the grpc interceptor
+func authenticate(ctx context.Context, authenticator auth.Authenticator, jwtVerifier *auth.JwtVerifier, apiKey, authorization string) (identity auth.Identity, err error) {
+	bearer := ""
+	if strings.HasPrefix(authorization, "Bearer ") {
+		bearer = strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+
+	switch {
+	case apiKey == "" && bearer == "":
+		err = auth.ErrMissingCredential
+	case apiKey == "" && jwtVerifier != nil && auth.LooksLikeJwt(bearer):
+		identity, err = jwtVerifier.Verify(bearer)
+	case authenticator != nil:
+		if apiKey == "" {
+			apiKey = bearer
+		}
+		identity, err = authenticator.AuthenticateApiKey(ctx, apiKey)
+	default:
+		err = auth.ErrInvalidCredential
+	}
+	return identity, err
+}
+
+// unwrapAuthError hide the detail of the verification from the client
+func unwrapAuthError(err error) error {
+	if errors.Is(err, auth.ErrMissingCredential) {
+		return auth.ErrMissingCredential
+	}
+	return auth.ErrInvalidCredential
+}
+
+// RequirePermission only let through the identity that has the given permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := auth.FromContext(c)
+		if !ok {
+			httplib.SetErrorResponse(c, http.StatusUnauthorized, auth.ErrMissingCredential.Error())
+			c.Abort()
+			return
+		}
+		if !identity.HasPermission(permission) {
+			httplib.SetErrorResponse(c, http.StatusForbidden, "missing permission "+permission)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireReadWritePermission require the read permission for the safe
+// methods and the write permission for everything else
+func RequireReadWritePermission(readPermission, writePermission string) gin.HandlerFunc {
+	requireRead := RequirePermission(readPermission)
+	requireWrite := RequirePermission(writePermission)
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			requireRead(c)
+		default:
+			requireWrite(c)
+		}
+	}
+}
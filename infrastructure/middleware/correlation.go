@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+
+	logger "go-ocr/infrastructure/log"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var validCorrelationID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// CorrelationIDMiddleware accept the X-Correlation-ID header or generate a new
+// one, store it in the gin and request context and echo it in the response.
+func CorrelationIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		correlationID := c.GetHeader(logger.CorrelationID)
+		if !validCorrelationID.MatchString(correlationID) {
+			correlationID = newCorrelationID()
+		}
+
+		c.Set(logger.CorrelationID, correlationID)
+		c.Request = c.Request.WithContext(logger.WithCorrelationID(c.Request.Context(), correlationID))
+		c.Header(logger.CorrelationID, correlationID)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("correlation.id", correlationID))
+
+		c.Next()
+	}
+}
+
+// newCorrelationID generate a random uuid v4
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go-ocr/infrastructure/httplib"
+	logger "go-ocr/infrastructure/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+var generatedCorrelationID = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestCorrelationIDMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+	logger.Init("json", "info")
+	t.Cleanup(func() {
+		logger.SetOutput(os.Stdout)
+		logger.Init("text", "info")
+	})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(CorrelationIDMiddleware())
+	engine.GET("/", func(c *gin.Context) {
+		// the services only get the request context
+		logger.Info(c.Request.Context(), "test", "request of %s", logger.CorrelationIDFromContext(c.Request.Context()))
+		httplib.SetErrorResponse(c, http.StatusBadRequest, "invalid")
+	})
+
+	tests := []struct {
+		name          string
+		header        string
+		wantGenerated bool
+	}{
+		{name: "given id", header: "abc-123"},
+		{name: "id of another system", header: "req:42.a_b"},
+		{name: "missing id", wantGenerated: true},
+		{name: "id with spaces", header: "abc 123", wantGenerated: true},
+		{name: "id too long", header: strings.Repeat("a", 129), wantGenerated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(logger.CorrelationID, tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, req)
+
+			echoed := recorder.Header().Get(logger.CorrelationID)
+			if tt.wantGenerated && !generatedCorrelationID.MatchString(echoed) {
+				t.Fatalf("echoed correlation id = %q, want a generated uuid", echoed)
+			}
+			if !tt.wantGenerated && echoed != tt.header {
+				t.Fatalf("echoed correlation id = %q, want %q", echoed, tt.header)
+			}
+
+			var body httplib.DefaultResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.CorrelationID != echoed {
+				t.Fatalf("error body correlation id = %q, want %q", body.CorrelationID, echoed)
+			}
+			var entry struct {
+				Msg           string `json:"msg"`
+				CorrelationID string `json:"correlationId"`
+			}
+			if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+				t.Fatalf("log = %q is not json: %v", logs.String(), err)
+			}
+			if entry.Msg != "request of "+echoed || entry.CorrelationID != echoed {
+				t.Fatalf("log = %q, want the correlation id %q", logs.String(), echoed)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/limiter"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/metrics"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcStep is the grpc version of a gin middleware, it runs before the method and
+// returns the context given to the method or a status error to stop the call
+type GrpcStep func(ctx context.Context, fullMethod string) (context.Context, error)
+
+// UnaryInterceptor runs the steps in order before the unary methods and recover their panic
+func UnaryInterceptor(steps ...GrpcStep) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverGrpc(ctx, info.FullMethod, &err)
+
+		for _, step := range steps {
+			if ctx, err = step(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor runs the steps in order before the stream methods and recover their panic
+func StreamInterceptor(steps ...GrpcStep) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := stream.Context()
+		defer recoverGrpc(ctx, info.FullMethod, &err)
+
+		for _, step := range steps {
+			if ctx, err = step(ctx, info.FullMethod); err != nil {
+				return err
+			}
+		}
+		return handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// contextServerStream gives the context of the steps to the stream methods
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func recoverGrpc(ctx context.Context, fullMethod string, err *error) {
+	if recovered := recover(); recovered != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, fmt.Sprintf("panic %v: %s", recovered, debug.Stack()), "middleware.grpc", fullMethod)
+		*err = status.Error(codes.Internal, "oops, something went wrong!")
+	}
+}
+
+// GrpcCorrelationID accept the x-correlation-id metadata or generate a new one,
+// store it in the context and echo it in the response header
+func GrpcCorrelationID() GrpcStep {
+	return func(ctx context.Context, fullMethod string) (context.Context, error) {
+		correlationID := incomingMetadata(ctx, logger.CorrelationID)
+		if !validCorrelationID.MatchString(correlationID) {
+			correlationID = newCorrelationID()
+		}
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(logger.CorrelationID, correlationID))
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("correlation.id", correlationID))
+		return logger.WithCorrelationID(ctx, correlationID), nil
+	}
+}
+
+// GrpcAuth authenticate the caller like the AuthMiddleware using the x-api-key or the
+// authorization metadata, then check the permission of the method. The methods
+// without permission are let through without credential.
+func GrpcAuth(authenticator auth.Authenticator, jwtVerifier *auth.JwtVerifier, permissions map[string]string) GrpcStep {
+	return func(ctx context.Context, fullMethod string) (context.Context, error) {
+		logCtx := "middleware.GrpcAuth"
+
+		permission, ok := permissions[fullMethod]
+		if !ok {
+			return ctx, nil
+		}
+
+		identity, err := authenticate(ctx, authenticator, jwtVerifier,
+			incomingMetadata(ctx, HeaderApiKey), incomingMetadata(ctx, "Authorization"))
+		if err != nil {
+			if errors.Is(err, auth.ErrMissingCredential) || errors.Is(err, auth.ErrInvalidCredential) {
+				logger.Debug(ctx, logCtx, "authentication failed: %v", err)
+				return ctx, status.Error(codes.Unauthenticated, unwrapAuthError(err).Error())
+			}
+			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "authenticate")
+			return ctx, status.Error(codes.Internal, "oops, something went wrong!")
+		}
+
+		if !identity.HasPermission(permission) {
+			return ctx, status.Error(codes.PermissionDenied, "missing permission "+permission)
+		}
+		return auth.WithIdentity(ctx, identity), nil
+	}
+}
+
+// GrpcTenant resolve the tenant like the TenantMiddleware using the x-tenant-id metadata
+func GrpcTenant() GrpcStep {
+	return func(ctx context.Context, fullMethod string) (context.Context, error) {
+		tenantID, err := resolveTenant(ctx, incomingMetadata(ctx, tenant.HeaderTenantID))
+		if err != nil {
+			if errors.Is(err, errTenantIdNotValid) {
+				return ctx, status.Error(codes.InvalidArgument, err.Error())
+			}
+			return ctx, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return tenant.WithTenant(ctx, tenantID), nil
+	}
+}
+
+// GrpcRateLimiter apply the global limiter by peer ip, like the http one it runs before GrpcAuth
+func GrpcRateLimiter(rateLimiter limiter.LimiterInterface) GrpcStep {
+	return func(ctx context.Context, fullMethod string) (context.Context, error) {
+		return ctx, applyGrpcRateLimit(ctx, rateLimiter, ipRateLimitKey(peerIP(ctx)), metrics.LimiterScopeGlobal)
+	}
+}
+
+// GrpcIdentityRateLimiter apply the global limiter by the verified identity, it must run after GrpcAuth
+func GrpcIdentityRateLimiter(rateLimiter limiter.LimiterInterface) GrpcStep {
+	return func(ctx context.Context, fullMethod string) (context.Context, error) {
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return ctx, nil
+		}
+		return ctx, applyGrpcRateLimit(ctx, rateLimiter, identityRateLimitKey(identity), metrics.LimiterScopeIdentity)
+	}
+}
+
+// GrpcTenantRateLimiter apply the rate limit override of the tenant, it must run after GrpcTenant
+func GrpcTenantRateLimiter(limiters map[string]limiter.LimiterInterface) GrpcStep {
+	return func(ctx context.Context, fullMethod string) (context.Context, error) {
+		tenantID := tenant.FromContext(ctx)
+		tenantLimiter, ok := limiters[tenantID]
+		if !ok {
+			return ctx, nil
+		}
+		return ctx, applyGrpcRateLimit(ctx, tenantLimiter, "tenant:"+tenantID+":"+grpcRateLimitKey(ctx), metrics.LimiterScopeTenant)
+	}
+}
+
+// applyGrpcRateLimit send the rate limit headers and returns an error when the limit is exceeded
+func applyGrpcRateLimit(ctx context.Context, rateLimiter limiter.LimiterInterface, key, scope string) error {
+	result := rateLimiter.Allow(ctx, key)
+
+	header := metadata.Pairs(
+		HeaderRateLimitLimit, strconv.Itoa(result.Limit),
+		HeaderRateLimitRemaining, strconv.Itoa(result.Remaining),
+		HeaderRateLimitReset, durationToSeconds(result.ResetAfter),
+	)
+	if result.Allowed {
+		_ = grpc.SetHeader(ctx, header)
+		return nil
+	}
+
+	metrics.RateLimitRejectionsTotal.WithLabelValues(scope).Inc()
+	header.Set(HeaderRetryAfter, durationToSeconds(result.RetryAfter))
+	_ = grpc.SetHeader(ctx, header)
+	return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
+
+// grpcRateLimitKey identify the caller like the http one, by the verified identity or by the peer ip
+func grpcRateLimitKey(ctx context.Context) string {
+	if identity, ok := auth.FromContext(ctx); ok {
+		return identityRateLimitKey(identity)
+	}
+	return ipRateLimitKey(peerIP(ctx))
+}
+
+// peerIP returns the ip of the connection, grpc has no forwarded header to trust
+func peerIP(ctx context.Context) string {
+	address := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		address = p.Addr.String()
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			address = host
+		}
+	}
+	return address
+}
+
+// incomingMetadata returns the first value of the metadata key, the keys are lower case on grpc
+func incomingMetadata(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(key))
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
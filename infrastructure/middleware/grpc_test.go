@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/limiter"
+	"go-ocr/infrastructure/tenant"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const testMethod = "/ocr.v1.OcrService/GetResult"
+
+type stepsKey struct{}
+
+// recordStep appends its name to the steps run so far
+func recordStep(name string) GrpcStep {
+	return func(ctx context.Context, fullMethod string) (context.Context, error) {
+		steps, _ := ctx.Value(stepsKey{}).([]string)
+		return context.WithValue(ctx, stepsKey{}, append(steps, name)), nil
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	failing := func(ctx context.Context, fullMethod string) (context.Context, error) {
+		return ctx, status.Error(codes.PermissionDenied, "denied")
+	}
+
+	tests := []struct {
+		name      string
+		steps     []GrpcStep
+		panics    bool
+		wantSteps []string
+		wantCode  codes.Code
+	}{
+		{name: "steps run in order", steps: []GrpcStep{recordStep("first"), recordStep("second")}, wantSteps: []string{"first", "second"}, wantCode: codes.OK},
+		{name: "a failing step stops the call", steps: []GrpcStep{recordStep("first"), failing, recordStep("second")}, wantCode: codes.PermissionDenied},
+		{name: "the panic of the method is recovered", steps: []GrpcStep{recordStep("first")}, panics: true, wantCode: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSteps []string
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				if tt.panics {
+					panic("boom")
+				}
+				gotSteps, _ = ctx.Value(stepsKey{}).([]string)
+				return "response", nil
+			}
+
+			_, err := UnaryInterceptor(tt.steps...)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, handler)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("UnaryInterceptor() error = %v, want code %s", err, tt.wantCode)
+			}
+			if tt.wantCode == codes.PermissionDenied && called {
+				t.Fatalf("UnaryInterceptor() called the method after a failing step")
+			}
+			if len(gotSteps) != len(tt.wantSteps) {
+				t.Fatalf("method got steps %v, want %v", gotSteps, tt.wantSteps)
+			}
+			for idx := range gotSteps {
+				if gotSteps[idx] != tt.wantSteps[idx] {
+					t.Fatalf("method got steps %v, want %v", gotSteps, tt.wantSteps)
+				}
+			}
+		})
+	}
+}
+
+// fakeAuthenticator knows the keys of the map, the "broken" key stands for a storage failure
+type fakeAuthenticator map[string]auth.Identity
+
+func (f fakeAuthenticator) AuthenticateApiKey(ctx context.Context, rawKey string) (auth.Identity, error) {
+	if rawKey == "broken" {
+		return auth.Identity{}, errors.New("storage down")
+	}
+	identity, ok := f[rawKey]
+	if !ok {
+		return auth.Identity{}, auth.ErrInvalidCredential
+	}
+	return identity, nil
+}
+
+func TestGrpcAuth(t *testing.T) {
+	authenticator := fakeAuthenticator{
+		"reader-key": {ID: "reader", Type: auth.TypeApiKey, Permissions: []string{auth.PermissionOcrRead}},
+		"writer-key": {ID: "writer", Type: auth.TypeApiKey, Permissions: []string{auth.PermissionOcrRead, auth.PermissionOcrWrite}},
+	}
+	permissions := map[string]string{testMethod: auth.PermissionOcrWrite}
+
+	tests := []struct {
+		name         string
+		method       string
+		metadata     []string
+		wantCode     codes.Code
+		wantIdentity string
+	}{
+		{name: "method without permission", method: "/ocr.v1.OcrService/Public", wantCode: codes.OK},
+		{name: "missing credential", method: testMethod, wantCode: codes.Unauthenticated},
+		{name: "unknown key", method: testMethod, metadata: []string{HeaderApiKey, "unknown"}, wantCode: codes.Unauthenticated},
+		{name: "failing authenticator", method: testMethod, metadata: []string{HeaderApiKey, "broken"}, wantCode: codes.Internal},
+		{name: "missing permission", method: testMethod, metadata: []string{HeaderApiKey, "reader-key"}, wantCode: codes.PermissionDenied},
+		{name: "api key", method: testMethod, metadata: []string{HeaderApiKey, "writer-key"}, wantCode: codes.OK, wantIdentity: "writer"},
+		{name: "api key as bearer", method: testMethod, metadata: []string{"authorization", "Bearer writer-key"}, wantCode: codes.OK, wantIdentity: "writer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tt.metadata...))
+			ctx, err := GrpcAuth(authenticator, nil, permissions)(ctx, tt.method)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("GrpcAuth() error = %v, want code %s", err, tt.wantCode)
+			}
+			identity, _ := auth.FromContext(ctx)
+			if identity.ID != tt.wantIdentity {
+				t.Fatalf("GrpcAuth() identity = %q, want %q", identity.ID, tt.wantIdentity)
+			}
+		})
+	}
+}
+
+func TestGrpcTenant(t *testing.T) {
+	reader := auth.Identity{ID: "reader", Type: auth.TypeApiKey, TenantID: "acme", Permissions: []string{auth.PermissionOcrRead}}
+
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		requested  string
+		wantCode   codes.Code
+		wantTenant string
+	}{
+		{name: "anonymous without tenant", wantCode: codes.OK, wantTenant: tenant.DefaultTenantID},
+		{name: "anonymous with tenant", requested: "acme", wantCode: codes.OK, wantTenant: "acme"},
+		{name: "malformed tenant", requested: "../acme", wantCode: codes.InvalidArgument},
+		{name: "tenant of the credential", identity: &reader, wantCode: codes.OK, wantTenant: "acme"},
+		{name: "another tenant than the credential", identity: &reader, requested: "globex", wantCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.requested != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(tenant.HeaderTenantID, tt.requested))
+			}
+			if tt.identity != nil {
+				ctx = auth.WithIdentity(ctx, *tt.identity)
+			}
+			ctx, err := GrpcTenant()(ctx, testMethod)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("GrpcTenant() error = %v, want code %s", err, tt.wantCode)
+			}
+			if err == nil && tenant.FromContext(ctx) != tt.wantTenant {
+				t.Fatalf("GrpcTenant() tenant = %q, want %q", tenant.FromContext(ctx), tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestGrpcRateLimiter(t *testing.T) {
+	// one call per minute and per peer ip
+	step := GrpcRateLimiter(limiter.NewRateLimiter(1, time.Minute, 1))
+	withPeer := func(address string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(address), Port: 5000}})
+	}
+
+	tests := []struct {
+		name     string
+		address  string
+		wantCode codes.Code
+	}{
+		{name: "first call", address: "192.0.2.1", wantCode: codes.OK},
+		{name: "second call of the same ip", address: "192.0.2.1", wantCode: codes.ResourceExhausted},
+		{name: "another ip", address: "192.0.2.2", wantCode: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := step(withPeer(tt.address), testMethod)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("GrpcRateLimiter() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
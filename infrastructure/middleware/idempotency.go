@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/httplib"
+	"go-ocr/infrastructure/idempotency"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLength = 255
+
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware honor the Idempotency-Key header on POST requests.
+// A replay with the same key and body returns the stored response, a replay
+// with a different body or while the first request still running returns 409.
+func IdempotencyMiddleware(store idempotency.Store, ttl func() time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logCtx := "middleware.IdempotencyMiddleware"
+
+		key := c.GetHeader(idempotency.HeaderIdempotencyKey)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			httplib.SetErrorResponse(c, http.StatusBadRequest, "idempotency key is too long")
+			c.Abort()
+			return
+		}
+
+		// the ttl is read per request because it can be reloaded
+		recordTTL := ttl()
+
+		// scope the key so two callers can't replay each other's response
+		key = tenant.FromContext(c) + ":" + key
+		if identity, ok := auth.FromContext(c); ok {
+			key = identity.String() + ":" + key
+		}
+
+		fingerprint, err := idempotency.Fingerprint(c.Request)
+		if err != nil {
+			logger.Error(c, utils.ErrorLogFormat, err.Error(), logCtx, "idempotency.Fingerprint")
+			httplib.SetErrorResponse(c, http.StatusBadRequest, "oops, something wrong with body request, please recheck!")
+			c.Abort()
+			return
+		}
+
+		err = store.Reserve(c.Request.Context(), key, idempotency.Record{
+			Fingerprint: fingerprint,
+			Status:      idempotency.StatusProcessing,
+		}, recordTTL)
+		if err != nil {
+			if !errors.Is(err, idempotency.ErrKeyAlreadyExists) {
+				logger.Error(c, utils.ErrorLogFormat, err.Error(), logCtx, "store.Reserve")
+				httplib.SetErrorResponse(c, http.StatusInternalServerError, "oops, something went wrong!")
+				c.Abort()
+				return
+			}
+			replayIdempotentResponse(c, store, key, fingerprint)
+			return
+		}
+
+		writer := &bodyCaptureWriter{
+			ResponseWriter: c.Writer,
+			body:           &bytes.Buffer{},
+		}
+		c.Writer = writer
+
+		c.Next()
+
+		// server errors are not stored, so the client can retry with the same key
+		if writer.Status() >= http.StatusInternalServerError {
+			if errDelete := store.Delete(c.Request.Context(), key); errDelete != nil {
+				logger.Error(c, utils.ErrorLogFormat, errDelete.Error(), logCtx, "store.Delete")
+			}
+			return
+		}
+
+		errSave := store.Save(c.Request.Context(), key, idempotency.Record{
+			Fingerprint: fingerprint,
+			Status:      idempotency.StatusCompleted,
+			Code:        writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}, recordTTL)
+		if errSave != nil {
+			logger.Error(c, utils.ErrorLogFormat, errSave.Error(), logCtx, "store.Save")
+		}
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, store idempotency.Store, key, fingerprint string) {
+	logCtx := "middleware.replayIdempotentResponse"
+
+	record, found, err := store.Get(c.Request.Context(), key)
+	if err != nil {
+		logger.Error(c, utils.ErrorLogFormat, err.Error(), logCtx, "store.Get")
+		httplib.SetErrorResponse(c, http.StatusInternalServerError, "oops, something went wrong!")
+		c.Abort()
+		return
+	}
+
+	switch {
+	case !found:
+		// the first request failed and released the key in the meantime
+		httplib.SetErrorResponse(c, http.StatusConflict, "request with the same idempotency key just failed, please retry")
+	case record.Fingerprint != fingerprint:
+		httplib.SetErrorResponse(c, http.StatusConflict, "idempotency key already used with a different request body")
+	case record.Status != idempotency.StatusCompleted:
+		httplib.SetErrorResponse(c, http.StatusConflict, "request with the same idempotency key is still being processed")
+	default:
+		c.Header(idempotency.HeaderReplayed, "true")
+		c.Data(record.Code, record.ContentType, record.Body)
+	}
+	c.Abort()
+}
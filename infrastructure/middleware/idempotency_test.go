@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/idempotency"
+	"go-ocr/infrastructure/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyRequest is a request of an idempotency case and the response it must get
+type idempotencyRequest struct {
+	path         string
+	key          string
+	body         string
+	identityID   string
+	tenantID     string
+	wantStatus   int
+	wantReplayed bool
+	// wantCalls is how many times the handler ran once the request is done
+	wantCalls int
+}
+
+func newIdempotencyEngine(calls *int, started, release chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	// stands for the AuthMiddleware and the TenantMiddleware
+	engine.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-Test-Identity"); id != "" {
+			identity := auth.Identity{ID: id, Type: auth.TypeApiKey}
+			c.Set(auth.IdentityKey, identity)
+			c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+		}
+		if tenantID := c.GetHeader("X-Test-Tenant"); tenantID != "" {
+			c.Set(tenant.Key, tenantID)
+			c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+		}
+		c.Next()
+	})
+	engine.Use(IdempotencyMiddleware(idempotency.NewInMemoryStore(), func() time.Duration { return time.Hour }))
+	engine.POST("/ocr", func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusCreated, gin.H{"call": *calls})
+	})
+	engine.POST("/fail", func(c *gin.Context) {
+		*calls++
+		c.Status(http.StatusInternalServerError)
+	})
+	engine.POST("/slow", func(c *gin.Context) {
+		*calls++
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusCreated)
+	})
+	return engine
+}
+
+func serveIdempotencyRequest(engine *gin.Engine, request idempotencyRequest) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, request.path, strings.NewReader(request.body))
+	req.Header.Set("Content-Type", "application/json")
+	if request.key != "" {
+		req.Header.Set(idempotency.HeaderIdempotencyKey, request.key)
+	}
+	if request.identityID != "" {
+		req.Header.Set("X-Test-Identity", request.identityID)
+	}
+	if request.tenantID != "" {
+		req.Header.Set("X-Test-Tenant", request.tenantID)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests []idempotencyRequest
+	}{
+		{
+			name: "a replay with the same body gets the stored response",
+			requests: []idempotencyRequest{
+				{path: "/ocr", key: "a", body: `{"lang":"eng"}`, wantStatus: http.StatusCreated, wantCalls: 1},
+				{path: "/ocr", key: "a", body: `{"lang":"eng"}`, wantStatus: http.StatusCreated, wantReplayed: true, wantCalls: 1},
+			},
+		},
+		{
+			name: "a replay with another body is a conflict",
+			requests: []idempotencyRequest{
+				{path: "/ocr", key: "a", body: `{"lang":"eng"}`, wantStatus: http.StatusCreated, wantCalls: 1},
+				{path: "/ocr", key: "a", body: `{"lang":"fra"}`, wantStatus: http.StatusConflict, wantCalls: 1},
+			},
+		},
+		{
+			name: "the same key on another path is a conflict",
+			requests: []idempotencyRequest{
+				{path: "/ocr", key: "a", body: `{}`, wantStatus: http.StatusCreated, wantCalls: 1},
+				{path: "/fail", key: "a", body: `{}`, wantStatus: http.StatusConflict, wantCalls: 1},
+			},
+		},
+		{
+			name: "requests without a key are never replayed",
+			requests: []idempotencyRequest{
+				{path: "/ocr", body: `{}`, wantStatus: http.StatusCreated, wantCalls: 1},
+				{path: "/ocr", body: `{}`, wantStatus: http.StatusCreated, wantCalls: 2},
+			},
+		},
+		{
+			name: "the key of another caller is not replayed",
+			requests: []idempotencyRequest{
+				{path: "/ocr", key: "a", body: `{}`, identityID: "key-1", wantStatus: http.StatusCreated, wantCalls: 1},
+				{path: "/ocr", key: "a", body: `{}`, identityID: "key-2", wantStatus: http.StatusCreated, wantCalls: 2},
+				{path: "/ocr", key: "a", body: `{}`, wantStatus: http.StatusCreated, wantCalls: 3},
+				{path: "/ocr", key: "a", body: `{}`, identityID: "key-1", wantStatus: http.StatusCreated, wantReplayed: true, wantCalls: 3},
+			},
+		},
+		{
+			name: "the key of another tenant is not replayed",
+			requests: []idempotencyRequest{
+				{path: "/ocr", key: "a", body: `{}`, tenantID: "acme", wantStatus: http.StatusCreated, wantCalls: 1},
+				{path: "/ocr", key: "a", body: `{}`, tenantID: "other", wantStatus: http.StatusCreated, wantCalls: 2},
+			},
+		},
+		{
+			name: "a server error releases the key",
+			requests: []idempotencyRequest{
+				{path: "/fail", key: "a", body: `{}`, wantStatus: http.StatusInternalServerError, wantCalls: 1},
+				{path: "/fail", key: "a", body: `{}`, wantStatus: http.StatusInternalServerError, wantCalls: 2},
+			},
+		},
+		{
+			name: "a key too long is rejected",
+			requests: []idempotencyRequest{
+				{path: "/ocr", key: strings.Repeat("a", maxIdempotencyKeyLength+1), body: `{}`, wantStatus: http.StatusBadRequest},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			engine := newIdempotencyEngine(&calls, nil, nil)
+			var firstBody string
+			for idx, request := range tt.requests {
+				recorder := serveIdempotencyRequest(engine, request)
+
+				if recorder.Code != request.wantStatus {
+					t.Fatalf("request %d got status %d, want %d", idx+1, recorder.Code, request.wantStatus)
+				}
+				if replayed := recorder.Header().Get(idempotency.HeaderReplayed) == "true"; replayed != request.wantReplayed {
+					t.Fatalf("request %d got replayed %v, want %v", idx+1, replayed, request.wantReplayed)
+				}
+				if calls != request.wantCalls {
+					t.Fatalf("request %d got %d handler calls, want %d", idx+1, calls, request.wantCalls)
+				}
+				if idx == 0 {
+					firstBody = recorder.Body.String()
+				}
+				if request.wantReplayed && recorder.Body.String() != firstBody {
+					t.Fatalf("request %d got body %s, want the stored %s", idx+1, recorder.Body.String(), firstBody)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareInFlight(t *testing.T) {
+	calls := 0
+	started, release := make(chan struct{}), make(chan struct{})
+	engine := newIdempotencyEngine(&calls, started, release)
+	request := idempotencyRequest{path: "/slow", key: "a", body: `{}`}
+
+	done := make(chan int)
+	go func() {
+		done <- serveIdempotencyRequest(engine, request).Code
+	}()
+
+	// the first request holds the key until it is released
+	<-started
+	if code := serveIdempotencyRequest(engine, request).Code; code != http.StatusConflict {
+		t.Fatalf("request while the first one runs got status %d, want %d", code, http.StatusConflict)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusCreated {
+		t.Fatalf("first request got status %d, want %d", code, http.StatusCreated)
+	}
+	recorder := serveIdempotencyRequest(engine, request)
+	if recorder.Code != http.StatusCreated || recorder.Header().Get(idempotency.HeaderReplayed) != "true" {
+		t.Fatalf("request after the first one got status %d replayed %q, want the stored response", recorder.Code, recorder.Header().Get(idempotency.HeaderReplayed))
+	}
+	if calls != 1 {
+		t.Fatalf("got %d handler calls, want 1", calls)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"go-ocr/infrastructure/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware count and time every request by its route template, so
+// /api/v1/ocr/1 and /api/v1/ocr/2 are the same series.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HttpRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
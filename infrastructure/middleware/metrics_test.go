@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-ocr/infrastructure/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MetricsMiddleware())
+	engine.GET("/metrics-test/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantRoute  string
+		wantStatus string
+	}{
+		{name: "the route template is the label", path: "/metrics-test/1", wantRoute: "/metrics-test/:id", wantStatus: "200"},
+		{name: "another id is the same series", path: "/metrics-test/2", wantRoute: "/metrics-test/:id", wantStatus: "200"},
+		{name: "status", path: "/metrics-test/missing", wantRoute: "/metrics-test/:id", wantStatus: "404"},
+		{name: "unmatched path", path: "/metrics-test-unknown/1", wantRoute: "unmatched", wantStatus: "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.HttpRequestsTotal.WithLabelValues(http.MethodGet, tt.wantRoute, tt.wantStatus)
+			before := testutil.ToFloat64(counter)
+
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Fatalf("http_requests_total{route=%q,status=%q} grew by %v, want 1", tt.wantRoute, tt.wantStatus, got)
+			}
+		})
+	}
+}
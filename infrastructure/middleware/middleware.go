@@ -1,21 +1,88 @@
 package middleware
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"go-ocr/infrastructure/auth"
 	"go-ocr/infrastructure/httplib"
 	"go-ocr/infrastructure/limiter"
+	"go-ocr/infrastructure/metrics"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RateLimiterMiddleware(rateLimiter *limiter.RateLimiter) gin.HandlerFunc {
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+	HeaderApiKey             = "X-API-Key"
+)
+
+// RateLimiterMiddleware limit the callers by client ip, it runs before the credential is
+// verified so an unverified api key or bearer can't pick its own bucket
+func RateLimiterMiddleware(rateLimiter limiter.LimiterInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !applyRateLimit(c, rateLimiter, ipRateLimitKey(c.ClientIP()), metrics.LimiterScopeGlobal) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// IdentityRateLimiterMiddleware limit the authenticated callers by their identity, it must run
+// after the AuthMiddleware. The anonymous callers are only limited by ip.
+func IdentityRateLimiterMiddleware(rateLimiter limiter.LimiterInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if rateLimiter.Allow() {
+		identity, ok := auth.FromContext(c)
+		if !ok {
 			c.Next()
 			return
 		}
-		httplib.SetErrorResponse(c, http.StatusTooManyRequests, "rate limit exceeded")
-		c.Abort()
+		if !applyRateLimit(c, rateLimiter, identityRateLimitKey(identity), metrics.LimiterScopeIdentity) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// applyRateLimit set the rate limit headers and abort the request when the limit is exceeded
+func applyRateLimit(c *gin.Context, rateLimiter limiter.LimiterInterface, key, scope string) bool {
+	result := rateLimiter.Allow(c.Request.Context(), key)
+
+	c.Header(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+	c.Header(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	c.Header(HeaderRateLimitReset, durationToSeconds(result.ResetAfter))
+
+	if result.Allowed {
+		return true
+	}
+	metrics.RateLimitRejectionsTotal.WithLabelValues(scope).Inc()
+	c.Header(HeaderRetryAfter, durationToSeconds(result.RetryAfter))
+	httplib.SetErrorResponse(c, http.StatusTooManyRequests, "rate limit exceeded")
+	c.Abort()
+	return false
+}
+
+// rateLimitKey identify the caller by its verified identity, otherwise by the client ip
+func rateLimitKey(c *gin.Context) string {
+	if identity, ok := auth.FromContext(c); ok {
+		return identityRateLimitKey(identity)
 	}
+	return ipRateLimitKey(c.ClientIP())
+}
+
+func ipRateLimitKey(ip string) string {
+	return "ip:" + ip
+}
+
+func identityRateLimitKey(identity auth.Identity) string {
+	return "id:" + identity.String()
+}
+
+func durationToSeconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
 }
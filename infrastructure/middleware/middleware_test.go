@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/limiter"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitRequest is a request of a rate limit case and the status it must get
+type rateLimitRequest struct {
+	remoteAddr    string
+	forwardedFor  string
+	apiKey        string
+	identityID    string
+	wantStatus    int
+	wantRemaining string
+}
+
+func newRateLimitEngine(t *testing.T, trustedProxies []string, ipBurst int) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	// one request per minute, so a second request of the same identity is always rejected,
+	// the ip allows ipBurst requests per minute
+	ipLimiter := limiter.NewRateLimiter(ipBurst, time.Minute, ipBurst)
+	rateLimiter := limiter.NewRateLimiter(1, time.Minute, 1)
+	engine := gin.New()
+	if err := engine.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	engine.Use(RateLimiterMiddleware(ipLimiter))
+	// stands for the AuthMiddleware, the test header is the verified identity
+	engine.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-Test-Identity"); id != "" {
+			identity := auth.Identity{ID: id, Type: auth.TypeApiKey}
+			c.Set(auth.IdentityKey, identity)
+			c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+		}
+		c.Next()
+	})
+	engine.Use(IdentityRateLimiterMiddleware(rateLimiter))
+	engine.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return engine
+}
+
+func TestRateLimiterKeying(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		ipBurst        int
+		requests       []rateLimitRequest
+	}{
+		{
+			name: "a new api key on each request keeps the bucket of the ip",
+			requests: []rateLimitRequest{
+				{remoteAddr: "192.0.2.1:1000", apiKey: "random-1", wantStatus: http.StatusOK, wantRemaining: "0"},
+				{remoteAddr: "192.0.2.1:1000", apiKey: "random-2", wantStatus: http.StatusTooManyRequests},
+				{remoteAddr: "192.0.2.1:1000", apiKey: "random-3", wantStatus: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "a forwarded header from an untrusted client is ignored",
+			requests: []rateLimitRequest{
+				{remoteAddr: "192.0.2.1:1000", forwardedFor: "198.51.100.1", wantStatus: http.StatusOK},
+				{remoteAddr: "192.0.2.1:1000", forwardedFor: "198.51.100.2", wantStatus: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:           "a forwarded header from a trusted proxy gives the client ip",
+			trustedProxies: []string{"10.0.0.0/8"},
+			requests: []rateLimitRequest{
+				{remoteAddr: "10.0.0.1:1000", forwardedFor: "198.51.100.1", wantStatus: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1000", forwardedFor: "198.51.100.2", wantStatus: http.StatusOK},
+				{remoteAddr: "10.0.0.2:1000", forwardedFor: "198.51.100.1", wantStatus: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "the different ips of an identity share its bucket",
+			requests: []rateLimitRequest{
+				{remoteAddr: "192.0.2.1:1000", identityID: "key-1", wantStatus: http.StatusOK},
+				{remoteAddr: "192.0.2.2:1000", identityID: "key-1", wantStatus: http.StatusTooManyRequests},
+				{remoteAddr: "192.0.2.3:1000", identityID: "key-2", wantStatus: http.StatusOK},
+			},
+		},
+		{
+			name:    "the ip limit is apart from the one of the identity and more generous",
+			ipBurst: 3,
+			requests: []rateLimitRequest{
+				{remoteAddr: "192.0.2.1:1000", identityID: "key-1", wantStatus: http.StatusOK, wantRemaining: "0"},
+				{remoteAddr: "192.0.2.1:1000", identityID: "key-1", wantStatus: http.StatusTooManyRequests},
+				{remoteAddr: "192.0.2.1:1000", identityID: "key-2", wantStatus: http.StatusOK},
+				{remoteAddr: "192.0.2.1:1000", wantStatus: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "anonymous callers are only limited by ip",
+			requests: []rateLimitRequest{
+				{remoteAddr: "192.0.2.1:1000", wantStatus: http.StatusOK},
+				{remoteAddr: "192.0.2.2:1000", wantStatus: http.StatusOK},
+				{remoteAddr: "192.0.2.2:1000", wantStatus: http.StatusTooManyRequests},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ipBurst := tt.ipBurst
+			if ipBurst == 0 {
+				ipBurst = 1
+			}
+			engine := newRateLimitEngine(t, tt.trustedProxies, ipBurst)
+			for idx, request := range tt.requests {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = request.remoteAddr
+				if request.forwardedFor != "" {
+					req.Header.Set("X-Forwarded-For", request.forwardedFor)
+				}
+				if request.apiKey != "" {
+					req.Header.Set(HeaderApiKey, request.apiKey)
+				}
+				if request.identityID != "" {
+					req.Header.Set("X-Test-Identity", request.identityID)
+				}
+				recorder := httptest.NewRecorder()
+				engine.ServeHTTP(recorder, req)
+
+				if recorder.Code != request.wantStatus {
+					t.Fatalf("request %d got status %d, want %d", idx+1, recorder.Code, request.wantStatus)
+				}
+				if request.wantRemaining != "" && recorder.Header().Get(HeaderRateLimitRemaining) != request.wantRemaining {
+					t.Fatalf("request %d got remaining %q, want %q", idx+1, recorder.Header().Get(HeaderRateLimitRemaining), request.wantRemaining)
+				}
+				if recorder.Code == http.StatusTooManyRequests {
+					if _, err := strconv.Atoi(recorder.Header().Get(HeaderRetryAfter)); err != nil {
+						t.Fatalf("request %d got Retry-After %q", idx+1, recorder.Header().Get(HeaderRetryAfter))
+					}
+				}
+			}
+		})
+	}
+}
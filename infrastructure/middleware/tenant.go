@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/httplib"
+	"go-ocr/infrastructure/limiter"
+	"go-ocr/infrastructure/metrics"
+	"go-ocr/infrastructure/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errTenantIdNotValid = errors.New("tenant id given value is not valid")
+	errTenantNotAllowed = errors.New("credential is not allowed to access the tenant")
+)
+
+// TenantMiddleware resolve the tenant of the request and put it into the gin
+// and request context. The tenant of the credential always wins, a credential
+// without tenant belongs to the default one. The X-Tenant-ID header is only
+// used by anonymous callers or admins.
+func TenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, err := resolveTenant(c, c.GetHeader(tenant.HeaderTenantID))
+		if err != nil {
+			statusCode := http.StatusForbidden
+			if errors.Is(err, errTenantIdNotValid) {
+				statusCode = http.StatusBadRequest
+			}
+			httplib.SetErrorResponse(c, statusCode, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.Set(tenant.Key, tenantID)
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
+
+// resolveTenant returns the tenant of the identity of the context, the requested
+// tenant is only used by anonymous callers or admins
+func resolveTenant(ctx context.Context, requested string) (string, error) {
+	if requested != "" && !tenant.IsValidID(requested) {
+		return "", errTenantIdNotValid
+	}
+
+	tenantID := requested
+	identity, ok := auth.FromContext(ctx)
+	if ok && !identity.HasPermission(auth.PermissionOcrAdmin) {
+		// a credential without tenant can't choose one with the header
+		ownTenantID := identity.TenantID
+		if ownTenantID == "" {
+			ownTenantID = tenant.DefaultTenantID
+		}
+		if requested != "" && requested != ownTenantID {
+			return "", errTenantNotAllowed
+		}
+		tenantID = ownTenantID
+	}
+	if tenantID == "" && ok && identity.TenantID != "" {
+		tenantID = identity.TenantID
+	}
+	if tenantID == "" {
+		tenantID = tenant.DefaultTenantID
+	}
+	return tenantID, nil
+}
+
+// TenantRateLimiterMiddleware apply the rate limit override of the tenant, the
+// tenants without override are only limited by the global limiter.
+func TenantRateLimiterMiddleware(limiters map[string]limiter.LimiterInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := tenant.FromContext(c)
+		tenantLimiter, ok := limiters[tenantID]
+		if !ok {
+			c.Next()
+			return
+		}
+		if !applyRateLimit(c, tenantLimiter, "tenant:"+tenantID+":"+rateLimitKey(c), metrics.LimiterScopeTenant) {
+			return
+		}
+		c.Next()
+	}
+}
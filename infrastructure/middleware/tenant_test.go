@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/tenant"
+)
+
+func TestResolveTenant(t *testing.T) {
+	reader := func(tenantID string) *auth.Identity {
+		return &auth.Identity{ID: "key-1", Type: auth.TypeApiKey, TenantID: tenantID, Permissions: []string{auth.PermissionOcrRead}}
+	}
+	admin := func(tenantID string) *auth.Identity {
+		return &auth.Identity{ID: "admin", Type: auth.TypeJwt, TenantID: tenantID, Permissions: []string{auth.PermissionOcrAdmin}}
+	}
+
+	tests := []struct {
+		name      string
+		identity  *auth.Identity
+		requested string
+		want      string
+		wantErr   error
+	}{
+		{name: "anonymous without header", want: tenant.DefaultTenantID},
+		{name: "anonymous with header", requested: "acme", want: "acme"},
+		{name: "malformed header", requested: "../acme", wantErr: errTenantIdNotValid},
+		{name: "credential tenant", identity: reader("acme"), want: "acme"},
+		{name: "credential tenant with the same header", identity: reader("acme"), requested: "acme", want: "acme"},
+		{name: "credential tenant with another header", identity: reader("acme"), requested: "other", wantErr: errTenantNotAllowed},
+		{name: "credential without tenant", identity: reader(""), want: tenant.DefaultTenantID},
+		{name: "credential without tenant with the default header", identity: reader(""), requested: tenant.DefaultTenantID, want: tenant.DefaultTenantID},
+		{name: "credential without tenant with another header", identity: reader(""), requested: "acme", wantErr: errTenantNotAllowed},
+		{name: "admin with header", identity: admin("acme"), requested: "other", want: "other"},
+		{name: "admin without header", identity: admin("acme"), want: "acme"},
+		{name: "admin without tenant nor header", identity: admin(""), want: tenant.DefaultTenantID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = auth.WithIdentity(ctx, *tt.identity)
+			}
+			got, err := resolveTenant(ctx, tt.requested)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got err %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("got tenant %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+
+	DialectPostgres = "postgres"
+	DialectSqlite   = "sqlite"
+
+	// advisoryLockKey is shared by every instance so only one of them can migrate at a time
+	advisoryLockKey int64 = 7_201_946_315
+
+	createTableQuery = `create table if not exists schema_migrations (
+    version bigint PRIMARY KEY not null,
+    name varchar(255) not null,
+    applied_at timestamp not null default CURRENT_TIMESTAMP
+)`
+)
+
+var (
+	ErrUnknownDirection = errors.New("unknown migration direction, use up or down")
+	ErrMissingDown      = errors.New("migration has no down file")
+
+	fileNamePattern    = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+	placeholderPattern = regexp.MustCompile(`\$\d+`)
+)
+
+// Migration is one version of the schema, read from the <version>_<name>.<up|down>.sql files
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status tells whether a migration has been applied and when
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Runner struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// NewRunner load the migrations from the given file system, the files must be on its root
+func NewRunner(db *sql.DB, files fs.FS, dialect string) (*Runner, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func load(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, migration.Name, matches[2])
+		}
+		if matches[3] == DirectionUp {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies every pending migration, it returns the applied ones
+func (r *Runner) Up(ctx context.Context) (applied []Migration, err error) {
+	err = r.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range r.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			if err = r.apply(ctx, conn, migration, DirectionUp); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last applied migrations, steps lower than one reverts only the latest
+func (r *Runner) Down(ctx context.Context, steps int) (reverted []Migration, err error) {
+	if steps < 1 {
+		steps = 1
+	}
+	err = r.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(r.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := r.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrMissingDown, migration.Version, migration.Name)
+			}
+			if err = r.apply(ctx, conn, migration, DirectionDown); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status list every known migration and whether it has been applied
+func (r *Runner) Status(ctx context.Context) (statuses []Status, err error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, createTableQuery); err != nil {
+		return nil, err
+	}
+	versions, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range r.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := versions[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Run is the entry point of the migrate subcommand
+func (r *Runner) Run(ctx context.Context, direction string, steps int) ([]Migration, error) {
+	switch direction {
+	case DirectionUp:
+		return r.Up(ctx)
+	case DirectionDown:
+		return r.Down(ctx, steps)
+	default:
+		return nil, ErrUnknownDirection
+	}
+}
+
+// withLock holds a postgres session advisory lock for the whole run, advisory
+// locks belong to the session so everything runs on the same connection.
+// sqlite has no advisory lock, a concurrent run fails on the version primary key and rolls back
+func (r *Runner) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if r.dialect == DialectSqlite {
+		if _, err = conn.ExecContext(ctx, createTableQuery); err != nil {
+			return err
+		}
+		return fn(conn)
+	}
+
+	if _, err = conn.ExecContext(ctx, "select pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, errUnlock := conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", advisoryLockKey); errUnlock != nil && err == nil {
+			err = fmt.Errorf("release migration lock: %w", errUnlock)
+		}
+	}()
+
+	if _, err = conn.ExecContext(ctx, createTableQuery); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (r *Runner) apply(ctx context.Context, conn *sql.Conn, migration Migration, direction string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := migration.Up
+	if direction == DirectionDown {
+		query = migration.Down
+	}
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	if direction == DirectionUp {
+		_, err = tx.ExecContext(ctx, r.bind("insert into schema_migrations (version, name) values ($1, $2)"), migration.Version, migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, r.bind("delete from schema_migrations where version = $1"), migration.Version)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// bind rewrites the postgres placeholders for sqlite
+func (r *Runner) bind(query string) string {
+	if r.dialect == DialectSqlite {
+		return placeholderPattern.ReplaceAllString(query, "?")
+	}
+	return query
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "select version, applied_at from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	return versions, rows.Err()
+}
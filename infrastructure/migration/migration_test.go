@@ -0,0 +1,280 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/database"
+	"go-ocr/migrations"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// postgresDsnEnv enables the postgres runs, the database must be empty
+const postgresDsnEnv = "GO_OCR_TEST_POSTGRES_DSN"
+
+// baseline schemas are the ocr table created by the init_sql.sql of the deployments before the migrations
+const (
+	postgresBaseline = `create table ocr (
+    id bigserial PRIMARY KEY not null,
+    image_url varchar(255) null,
+    text text null,
+    status varchar(255) null,
+    created_at timestamp default now(),
+    updated_at timestamp null,
+    deleted_at timestamp null
+)`
+	sqliteBaseline = `create table ocr (
+    id integer PRIMARY KEY autoincrement not null,
+    image_url varchar(255) null,
+    text text null,
+    status varchar(255) null,
+    created_at timestamp default CURRENT_TIMESTAMP,
+    updated_at timestamp null,
+    deleted_at timestamp null
+)`
+)
+
+func sqliteDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conf := &config.Config{Sqlite: config.SqliteConfig{Path: filepath.Join(t.TempDir(), "go-ocr.db")}}
+	db, err := database.NewSqliteClient(conf)
+	if err != nil {
+		t.Fatalf("NewSqliteClient() error = %v", err)
+	}
+	sqlDB, err := db.DbConn.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	return sqlDB
+}
+
+func postgresDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(postgresDsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDsnEnv)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	return sqlDB
+}
+
+func sqliteFiles(t *testing.T) fs.FS {
+	t.Helper()
+	files, err := fs.Sub(migrations.Sqlite, "sqlite")
+	if err != nil {
+		t.Fatalf("fs.Sub() error = %v", err)
+	}
+	return files
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int64
+		wantErr  bool
+	}{
+		{
+			name: "sorted by version, other files ignored",
+			files: fstest.MapFS{
+				"000002_b.up.sql":   {Data: []byte("b")},
+				"000001_a.up.sql":   {Data: []byte("a")},
+				"000001_a.down.sql": {Data: []byte("a")},
+				"migrations.go":     {Data: []byte("package migrations")},
+			},
+			versions: []int64{1, 2},
+		},
+		{
+			name:    "down without up",
+			files:   fstest.MapFS{"000001_a.down.sql": {Data: []byte("a")}},
+			wantErr: true,
+		},
+		{
+			name: "version used twice",
+			files: fstest.MapFS{
+				"000001_a.up.sql": {Data: []byte("a")},
+				"000001_b.up.sql": {Data: []byte("b")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := load(tt.files)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(loaded) != len(tt.versions) {
+				t.Fatalf("load() got %d migrations, want %d", len(loaded), len(tt.versions))
+			}
+			for idx, version := range tt.versions {
+				if loaded[idx].Version != version {
+					t.Fatalf("migration %d got version %d, want %d", idx, loaded[idx].Version, version)
+				}
+			}
+		})
+	}
+}
+
+// TestBackendsShareVersions keeps the migration history the same on postgres and sqlite
+func TestBackendsShareVersions(t *testing.T) {
+	postgresMigrations, err := load(migrations.FS)
+	if err != nil {
+		t.Fatalf("load(postgres) error = %v", err)
+	}
+	sqliteMigrations, err := load(sqliteFiles(t))
+	if err != nil {
+		t.Fatalf("load(sqlite) error = %v", err)
+	}
+	if len(postgresMigrations) != len(sqliteMigrations) {
+		t.Fatalf("postgres has %d migrations, sqlite %d", len(postgresMigrations), len(sqliteMigrations))
+	}
+	for idx := range postgresMigrations {
+		pg, lite := postgresMigrations[idx], sqliteMigrations[idx]
+		if pg.Version != lite.Version || pg.Name != lite.Name {
+			t.Errorf("postgres has %d_%s where sqlite has %d_%s", pg.Version, pg.Name, lite.Version, lite.Name)
+		}
+		if pg.Down == "" || lite.Down == "" {
+			t.Errorf("%d_%s has no down file", pg.Version, pg.Name)
+		}
+	}
+}
+
+func TestRunnerUpgradesBaseline(t *testing.T) {
+	tests := []struct {
+		name     string
+		db       func(t *testing.T) *sql.DB
+		files    func(t *testing.T) fs.FS
+		dialect  string
+		baseline string
+	}{
+		{name: "sqlite", db: sqliteDB, files: sqliteFiles, dialect: DialectSqlite, baseline: sqliteBaseline},
+		{name: "postgres", db: postgresDB, files: func(t *testing.T) fs.FS { return migrations.FS }, dialect: DialectPostgres, baseline: postgresBaseline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := tt.db(t)
+			if _, err := db.ExecContext(ctx, tt.baseline); err != nil {
+				t.Fatalf("baseline error = %v", err)
+			}
+			if _, err := db.ExecContext(ctx, "insert into ocr (image_url, text, status) values ('uploads/a.png', 'invoice', 'SUCCESSFUL')"); err != nil {
+				t.Fatalf("insert error = %v", err)
+			}
+
+			runner, err := NewRunner(db, tt.files(t), tt.dialect)
+			if err != nil {
+				t.Fatalf("NewRunner() error = %v", err)
+			}
+			applied, err := runner.Up(ctx)
+			if err != nil {
+				t.Fatalf("Up() error = %v", err)
+			}
+			if len(applied) != len(runner.migrations) {
+				t.Fatalf("Up() applied %d migrations, want %d", len(applied), len(runner.migrations))
+			}
+
+			// the baseline row belongs to the default tenant
+			var tenantID, text string
+			if err = db.QueryRowContext(ctx, "select tenant_id, text from ocr").Scan(&tenantID, &text); err != nil {
+				t.Fatalf("select error = %v", err)
+			}
+			if tenantID != "default" || text != "invoice" {
+				t.Fatalf("got tenant %q and text %q, want default and invoice", tenantID, text)
+			}
+
+			// a second run has nothing to do
+			if applied, err = runner.Up(ctx); err != nil || len(applied) != 0 {
+				t.Fatalf("second Up() applied %d migrations, error = %v", len(applied), err)
+			}
+
+			reverted, err := runner.Down(ctx, len(runner.migrations))
+			if err != nil {
+				t.Fatalf("Down() error = %v", err)
+			}
+			if len(reverted) != len(runner.migrations) {
+				t.Fatalf("Down() reverted %d migrations, want %d", len(reverted), len(runner.migrations))
+			}
+			statuses, err := runner.Status(ctx)
+			if err != nil {
+				t.Fatalf("Status() error = %v", err)
+			}
+			for _, status := range statuses {
+				if status.Applied {
+					t.Fatalf("%d_%s is still applied after Down()", status.Version, status.Name)
+				}
+			}
+			if _, err = db.ExecContext(ctx, "drop table if exists schema_migrations"); err != nil {
+				t.Fatalf("drop error = %v", err)
+			}
+		})
+	}
+}
+
+// TestRunnerLockPostgres runs the migrations from several instances at once, the advisory lock
+// lets one of them apply everything and the others find nothing to do
+func TestRunnerLockPostgres(t *testing.T) {
+	db := postgresDB(t)
+	ctx := context.Background()
+
+	const instances = 4
+	var wg sync.WaitGroup
+	appliedCounts := make([]int, instances)
+	errs := make([]error, instances)
+	for idx := 0; idx < instances; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			runner, err := NewRunner(db, migrations.FS, DialectPostgres)
+			if err != nil {
+				errs[idx] = err
+				return
+			}
+			applied, err := runner.Up(ctx)
+			appliedCounts[idx], errs[idx] = len(applied), err
+		}(idx)
+	}
+	wg.Wait()
+
+	total := 0
+	for idx := 0; idx < instances; idx++ {
+		if errs[idx] != nil {
+			t.Fatalf("instance %d Up() error = %v", idx, errs[idx])
+		}
+		total += appliedCounts[idx]
+	}
+	runner, _ := NewRunner(db, migrations.FS, DialectPostgres)
+	if total != len(runner.migrations) {
+		t.Fatalf("the instances applied %d migrations, want %d", total, len(runner.migrations))
+	}
+	if _, err := runner.Down(ctx, len(runner.migrations)); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+}
+
+func TestRunUnknownDirection(t *testing.T) {
+	runner, err := NewRunner(sqliteDB(t), sqliteFiles(t), DialectSqlite)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if _, err = runner.Run(context.Background(), "sideways", 1); !errors.Is(err, ErrUnknownDirection) {
+		t.Fatalf("Run() error = %v, want %v", err, ErrUnknownDirection)
+	}
+}
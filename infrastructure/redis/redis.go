@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -43,10 +44,10 @@ type client struct {
 }
 
 type LibInterface interface {
-	SetIdempotencyKey(key string, value interface{}, ttl time.Duration) (err error)
-	DeleteKey(key string) (err error)
-	Get(key string) (value string)
-	Set(key string, value interface{}, ttl time.Duration) (err error)
+	SetIdempotencyKey(ctx context.Context, key string, value interface{}, ttl time.Duration) (err error)
+	DeleteKey(ctx context.Context, key string) (err error)
+	Get(ctx context.Context, key string) (value string)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) (err error)
 }
 
 func newLib(redisClient *redis.Client) LibInterface {
@@ -55,12 +56,8 @@ func newLib(redisClient *redis.Client) LibInterface {
 	}
 }
 
-func (r client) SetIdempotencyKey(key string, value interface{}, ttl time.Duration) (err error) {
-	valueInRedis := r.redisClient.Get(key).Val()
-	if len(valueInRedis) > 0 {
-		return
-	}
-	success, err := r.redisClient.SetNX(key, value, ttl).Result()
+func (r client) SetIdempotencyKey(ctx context.Context, key string, value interface{}, ttl time.Duration) (err error) {
+	success, err := WithTracing(ctx, r.redisClient).SetNX(key, value, ttl).Result()
 	if err != nil {
 		return
 	}
@@ -71,18 +68,14 @@ func (r client) SetIdempotencyKey(key string, value interface{}, ttl time.Durati
 	return
 }
 
-func (r client) DeleteKey(key string) (err error) {
-	val := r.redisClient.Get(key).Val()
-	if len(val) > 0 {
-		return r.redisClient.Del(key).Err()
-	}
-	return
+func (r client) DeleteKey(ctx context.Context, key string) (err error) {
+	return WithTracing(ctx, r.redisClient).Del(key).Err()
 }
 
-func (r client) Get(key string) string {
-	return r.redisClient.Get(key).Val()
+func (r client) Get(ctx context.Context, key string) string {
+	return WithTracing(ctx, r.redisClient).Get(key).Val()
 }
 
-func (r client) Set(key string, value interface{}, ttl time.Duration) error {
-	return r.redisClient.Set(key, value, ttl).Err()
+func (r client) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return WithTracing(ctx, r.redisClient).Set(key, value, ttl).Err()
 }
@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"context"
+	"strings"
+
+	"go-ocr/infrastructure/tracing"
+
+	"github.com/go-redis/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing returns a copy of the client bound to the context, every command
+// of the copy is recorded as a child span of the span in the context.
+func WithTracing(ctx context.Context, redisClient *redis.Client) *redis.Client {
+	if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return redisClient
+	}
+
+	tracedClient := redisClient.WithContext(ctx)
+	tracedClient.WrapProcess(func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			_, span := tracing.Start(ctx, "Redis."+strings.ToUpper(cmd.Name()),
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation", cmd.Name()),
+			)
+			err := oldProcess(cmd)
+			if err == redis.Nil {
+				tracing.EndWithError(span, nil)
+				return err
+			}
+			tracing.EndWithError(span, err)
+			return err
+		}
+	})
+	return tracedClient
+}
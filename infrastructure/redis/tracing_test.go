@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"go-ocr/infrastructure/tracing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTracing(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	if err := server.Set("present", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		traced     bool
+		key        string
+		down       bool
+		wantSpan   bool
+		wantStatus codes.Code
+	}{
+		{name: "without span the command isn't traced", key: "present"},
+		{name: "command", traced: true, key: "present", wantSpan: true, wantStatus: codes.Unset},
+		{name: "a missing key isn't an error", traced: true, key: "missing", wantSpan: true, wantStatus: codes.Unset},
+		{name: "redis down", traced: true, key: "present", down: true, wantSpan: true, wantStatus: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			if tt.down {
+				server.Close()
+			}
+			ctx := context.Background()
+			var caller trace.Span
+			if tt.traced {
+				ctx, caller = tracing.Start(ctx, "Caller")
+				defer caller.End()
+			}
+
+			_ = WithTracing(ctx, client).Get(tt.key).Err()
+
+			spans := recorder.Ended()
+			if !tt.wantSpan {
+				if len(spans) != 0 {
+					t.Fatalf("got %d spans, want none", len(spans))
+				}
+				return
+			}
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "Redis.GET" || span.Parent().SpanID() != caller.SpanContext().SpanID() {
+				t.Fatalf("got span %s with parent %s, want Redis.GET child of the caller", span.Name(), span.Parent().SpanID())
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Fatalf("span %s status = %v, want %v", span.Name(), span.Status().Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go-ocr/infrastructure/config"
+	logger "go-ocr/infrastructure/log"
+)
+
+const (
+	// Key is the key of the tenant id on both the gin and the request context
+	Key string = "X-Tenant-ID"
+	// HeaderTenantID is the header used when the tenant doesn't come from the credential
+	HeaderTenantID = "X-Tenant-ID"
+	// DefaultTenantID is used when the request doesn't carry any tenant
+	DefaultTenantID = "default"
+)
+
+var validTenantID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Settings are the per tenant overrides, zero values mean use the global config
+type Settings struct {
+	Languages     []string
+	Rate          int
+	Interval      string
+	Burst         int
+	MaxUploadSize int64
+	Retention     time.Duration
+}
+
+func init() {
+	// the background jobs of the request keep working on its tenant
+	logger.RegisterDetach(func(from, to context.Context) context.Context {
+		return WithTenant(to, FromContext(from))
+	})
+}
+
+// IsValidID check the tenant id is safe to be used on a storage path and a cache key
+func IsValidID(tenantID string) bool {
+	return validTenantID.MatchString(tenantID)
+}
+
+// WithTenant return a copy of the context carrying the tenant id
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, Key, tenantID)
+}
+
+// FromContext get the tenant id of the request, the default tenant when there is none
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return DefaultTenantID
+	}
+	tenantID, ok := ctx.Value(Key).(string)
+	if !ok || tenantID == "" {
+		return DefaultTenantID
+	}
+	return tenantID
+}
+
+// SettingsFor returns the configured overrides of the tenant
+func SettingsFor(tenantID string) Settings {
+	for _, tenantConfig := range config.Conf.Tenants {
+		if tenantConfig.ID != tenantID {
+			continue
+		}
+		settings := Settings{
+			Languages:     tenantConfig.Languages,
+			Rate:          int(tenantConfig.Rate),
+			Interval:      tenantConfig.Interval,
+			Burst:         int(tenantConfig.Burst),
+			MaxUploadSize: tenantConfig.MaxUploadSize,
+		}
+		if tenantConfig.Retention != "" {
+			retention, err := time.ParseDuration(tenantConfig.Retention)
+			if err == nil && retention > 0 {
+				settings.Retention = retention
+			}
+		}
+		return settings
+	}
+	return Settings{}
+}
+
+// Languages returns the tesseract languages of the tenant, fallback to the global config
+func Languages(tenantID string) []string {
+	if languages := SettingsFor(tenantID).Languages; len(languages) > 0 {
+		return languages
+	}
+	return config.Current().TesseractsConfig.Languages
+}
@@ -11,3 +11,12 @@ func NewClient() *gosseract.Client {
 	client.Languages = languagesAvailable
 	return client
 }
+
+// AvailableLanguages returns the languages installed in the tessdata path of the host
+func AvailableLanguages() []string {
+	languages, err := gosseract.GetAvailableLanguages()
+	if err != nil {
+		return nil
+	}
+	return languages
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go-ocr/infrastructure/config"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TracerName         = "go-ocr"
+	defaultServiceName = "go-ocr"
+)
+
+// Init set the global tracer provider and the W3C trace context propagator.
+// Spans are exported over OTLP when an endpoint is configured, otherwise to
+// stdout. When tracing is disabled only the propagator is set, so the
+// incoming trace context is still passed along.
+func Init(conf *config.Config) (shutdown func(ctx context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	noop := func(ctx context.Context) error { return nil }
+	if !conf.Tracing.EnableTracing {
+		return noop, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	if conf.Tracing.OtlpEndpoint != "" {
+		options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(conf.Tracing.OtlpEndpoint)}
+		if conf.Tracing.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(context.Background(), options...)
+		if err != nil {
+			return noop, err
+		}
+		log.Printf("Exporting traces over OTLP to %s", conf.Tracing.OtlpEndpoint)
+	} else {
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return noop, err
+		}
+		log.Printf("No OTLP collector configured, exporting traces to stdout")
+	}
+
+	serviceName := conf.Tracing.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	sampleRatio := conf.Tracing.SampleRatio
+	if sampleRatio <= 0 {
+		sampleRatio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(serviceName),
+			attribute.String("deployment.environment", conf.Env),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start a span as a child of the span in the context
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndWithError record the error on the span when there is one and end it
+func EndWithError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
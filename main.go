@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"go-ocr/utils"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,19 +16,79 @@ import (
 
 	"go-ocr/boot"
 	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/listener"
 	"go-ocr/router"
 
 	"github.com/gookit/event"
+	"google.golang.org/grpc"
 )
 
+const usage = `usage: go-ocr [-env name] <command> [args]
+
+commands:
+  serve              run the http server, the default command
+  ocr <files...>     recognize the files or dirs and print the results, see ocr -h
+  worker             run the background jobs without the http server
+  migrate            run the database migrations, up | down [steps] | status
+  config check       print the effective config and validate it
+`
+
+// drainTimeout is how long the running exports and batches get to finish on shutdown
+const drainTimeout = 10 * time.Second
+
 func main() {
 	flag.StringVar(&config.Env, "env", "local", "A config name that used by server")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	command, args := "serve", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		serve()
+	case "ocr":
+		boot.RunOcr(args)
+	case "worker":
+		worker()
+	case "migrate":
+		boot.RunMigrate(args)
+	case "config":
+		if len(args) == 0 || args[0] != "check" {
+			flag.Usage()
+			os.Exit(2)
+		}
+		boot.RunConfigCheck()
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func serve() {
 	setup := boot.MakeHandler()
+
+	//restore the in memory data and save it back on shutdown
+	listen := listener.NewListener(setup.OcrHttp)
+	listen.TriggerStartUp()
+	listen.ListenForShutdownEvent()
 	handlerRouter := router.NewHandlerRouter(setup)
 	app := handlerRouter.RouterWithMiddleware()
 
+	//the background jobs also run on the server, a separate worker is optional
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobsDone := make(chan struct{})
+	go func() {
+		boot.RunBackgroundJobs(jobsCtx, setup)
+		close(jobsDone)
+	}()
+
 	port := fmt.Sprintf(":%v", config.Conf.Port)
 	if port == "" {
 		port = fmt.Sprintf(":%v", 1234)
@@ -46,26 +107,89 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shut down the server with
-	// a timeout of 1 second.
-	quit := make(chan os.Signal)
-	// kill (no param) default sends syscall.SIGTERM
-	// kill -2 is syscall.SIGINT
-	// kill -9 is syscall. SIGKILL but can"t be caught, so don't need to add it
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutdown Server ...")
+	//start the grpc server on its own port
+	var grpcServer *grpc.Server
+	if config.Conf.Grpc.EnableGrpc {
+		grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%v", config.Conf.Grpc.Port))
+		if err != nil {
+			log.Fatalf("failed listen grpc port: %v", err)
+		}
+		grpcServer = handlerRouter.GrpcServer()
+		log.Printf("Grpc server running on port :%v", config.Conf.Grpc.Port)
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				log.Fatal("shutting down the grpc server")
+			}
+		}()
+	}
 
-	event.MustFire(utils.ShutDownEvent, nil)
+	waitForSignal()
+	log.Println("Shutdown Server ...")
 
+	//stop taking requests and jobs first, so nothing is written after the snapshot of the shutdown event
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 	if err := serve.Shutdown(ctx); err != nil {
-		log.Fatal("Server Shutdown:", err)
+		log.Println("Server Shutdown:", err)
 	}
-	select {
-	case <-ctx.Done():
-		log.Println("timeout of 1 seconds.")
+	if grpcServer != nil {
+		stopGrpc(grpcServer, 1*time.Second)
 	}
+	//the exports and batches only live in this process, let them finish then cancel the rest
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelDrain()
+	setup.OcrService.DrainJobs(drainCtx)
+	stopJobs()
+	<-jobsDone
+	event.MustFire(utils.ShutDownEvent, nil)
 	log.Println("Server exiting")
 }
+
+func worker() {
+	setup := boot.MakeHandler()
+
+	//restore the in memory data and save it back on shutdown
+	listen := listener.NewListener(setup.OcrHttp)
+	listen.TriggerStartUp()
+	listen.ListenForShutdownEvent()
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		boot.RunBackgroundJobs(jobsCtx, setup)
+		close(done)
+	}()
+	log.Println("Worker running")
+
+	waitForSignal()
+	log.Println("Shutdown Worker ...")
+
+	stopJobs()
+	<-done
+	event.MustFire(utils.ShutDownEvent, nil)
+	log.Println("Worker exiting")
+}
+
+// stopGrpc let the running calls finish, then cancel the ones still running after the timeout
+func stopGrpc(server *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		server.Stop()
+	}
+}
+
+// waitForSignal blocks until the process is asked to stop
+func waitForSignal() {
+	quit := make(chan os.Signal, 1)
+	// kill (no param) default sends syscall.SIGTERM
+	// kill -2 is syscall.SIGINT
+	// kill -9 is syscall. SIGKILL but can"t be caught, so don't need to add it
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+}
@@ -1,9 +1,9 @@
-create table ocr (
-    id bigserial PRIMARY KEY not null,
-    image_url varchar(255) null,
-    text text null,
-    status varchar(255) null,
-    created_at timestamp default now(),
-    updated_at timestamp null,
-    deleted_at timestamp null
-)
\ No newline at end of file
+create table if not exists ocr (
+    id bigserial PRIMARY KEY not null,
+    image_url varchar(255) null,
+    text text null,
+    status varchar(255) null,
+    created_at timestamp default now(),
+    updated_at timestamp null,
+    deleted_at timestamp null
+);
@@ -0,0 +1,12 @@
+create table if not exists api_key (
+    id bigserial PRIMARY KEY not null,
+    name varchar(255) not null,
+    tenant_id varchar(64) null,
+    prefix varchar(32) not null unique,
+    secret_hash varchar(128) not null,
+    roles varchar(255) null,
+    created_at timestamp default now(),
+    updated_at timestamp null,
+    rotated_at timestamp null,
+    revoked_at timestamp null
+);
@@ -0,0 +1,4 @@
+alter table ocr add column if not exists tenant_id varchar(64) not null default 'default';
+alter table ocr add column if not exists created_by varchar(255) null;
+
+create index if not exists idx_ocr_tenant_id on ocr (tenant_id, id);
@@ -0,0 +1,4 @@
+create index if not exists idx_ocr_tenant_created_at on ocr (tenant_id, created_at);
+create index if not exists idx_ocr_tenant_status on ocr (tenant_id, status);
+create index if not exists idx_ocr_deleted_at on ocr (deleted_at);
+create index if not exists idx_api_key_tenant_id on api_key (tenant_id);
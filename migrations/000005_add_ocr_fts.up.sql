@@ -0,0 +1,3 @@
+-- the full text search is an fts5 table on sqlite, postgres keeps filtering the text with ilike.
+-- the version is kept so both backends share the same migration history.
+select 1;
@@ -0,0 +1,9 @@
+drop index if exists idx_ocr_tenant_content_hash;
+drop index if exists idx_ocr_tenant_document_type;
+drop index if exists idx_ocr_tenant_updated_at;
+
+alter table ocr drop column if exists content_hash;
+alter table ocr drop column if exists tags;
+alter table ocr drop column if exists confidence;
+alter table ocr drop column if exists language;
+alter table ocr drop column if exists document_type;
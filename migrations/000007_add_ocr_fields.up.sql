@@ -0,0 +1 @@
+alter table ocr add column if not exists fields text not null default '';
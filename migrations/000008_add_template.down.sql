@@ -0,0 +1,5 @@
+drop table if exists template;
+
+alter table ocr drop column if exists image_height;
+alter table ocr drop column if exists image_width;
+alter table ocr drop column if exists words;
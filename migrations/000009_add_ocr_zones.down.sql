@@ -0,0 +1 @@
+alter table ocr drop column if exists zones;
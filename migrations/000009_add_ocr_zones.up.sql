@@ -0,0 +1 @@
+alter table ocr add column if not exists zones text not null default '';
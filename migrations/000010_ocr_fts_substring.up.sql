@@ -0,0 +1,3 @@
+-- the sqlite fts5 table is rebuilt with the trigram tokenizer so it matches substrings like ilike,
+-- postgres has nothing to change. the version is kept so both backends share the same migration history.
+select 1;
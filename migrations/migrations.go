@@ -0,0 +1,15 @@
+package migrations
+
+import "embed"
+
+// FS holds the versioned postgres sql files, named <version>_<name>.<up|down>.sql. Version 1 is
+// the schema of the deployments before the migrations, so they upgrade like a new database.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Sqlite holds the same versions for sqlite under the sqlite directory, a version only needed
+// by one backend is a no-op on the other so both keep the same history
+//
+//go:embed sqlite/*.sql
+var Sqlite embed.FS
@@ -0,0 +1,9 @@
+create table if not exists ocr (
+    id integer PRIMARY KEY autoincrement not null,
+    image_url varchar(255) null,
+    text text null,
+    status varchar(255) null,
+    created_at timestamp default CURRENT_TIMESTAMP,
+    updated_at timestamp null,
+    deleted_at timestamp null
+);
@@ -0,0 +1,3 @@
+drop index if exists idx_ocr_tenant_id;
+alter table ocr drop column created_by;
+alter table ocr drop column tenant_id;
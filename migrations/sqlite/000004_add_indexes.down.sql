@@ -0,0 +1,4 @@
+drop index if exists idx_api_key_tenant_id;
+drop index if exists idx_ocr_deleted_at;
+drop index if exists idx_ocr_tenant_status;
+drop index if exists idx_ocr_tenant_created_at;
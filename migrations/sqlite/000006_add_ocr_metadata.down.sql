@@ -0,0 +1,9 @@
+drop index if exists idx_ocr_tenant_content_hash;
+drop index if exists idx_ocr_tenant_document_type;
+drop index if exists idx_ocr_tenant_updated_at;
+
+alter table ocr drop column content_hash;
+alter table ocr drop column tags;
+alter table ocr drop column confidence;
+alter table ocr drop column language;
+alter table ocr drop column document_type;
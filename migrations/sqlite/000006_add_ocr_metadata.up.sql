@@ -0,0 +1,9 @@
+alter table ocr add column document_type varchar(64) not null default '';
+alter table ocr add column language varchar(255) not null default '';
+alter table ocr add column confidence real not null default 0;
+alter table ocr add column tags varchar(1024) not null default '';
+alter table ocr add column content_hash varchar(64) not null default '';
+
+create index if not exists idx_ocr_tenant_updated_at on ocr (tenant_id, updated_at);
+create index if not exists idx_ocr_tenant_document_type on ocr (tenant_id, document_type);
+create index if not exists idx_ocr_tenant_content_hash on ocr (tenant_id, content_hash);
@@ -0,0 +1 @@
+alter table ocr add column fields text not null default '';
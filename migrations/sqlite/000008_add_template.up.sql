@@ -0,0 +1,16 @@
+alter table ocr add column words text not null default '';
+alter table ocr add column image_width integer not null default 0;
+alter table ocr add column image_height integer not null default 0;
+
+create table if not exists template (
+    id integer PRIMARY KEY autoincrement not null,
+    tenant_id varchar(64) not null default 'default',
+    name varchar(64) not null,
+    document_type varchar(64) not null,
+    rules text not null,
+    created_at timestamp default CURRENT_TIMESTAMP,
+    updated_at timestamp null
+);
+
+create unique index if not exists idx_template_tenant_name on template (tenant_id, name);
+create index if not exists idx_template_tenant_document_type on template (tenant_id, document_type);
@@ -0,0 +1 @@
+alter table ocr drop column zones;
@@ -0,0 +1 @@
+alter table ocr add column zones text not null default '';
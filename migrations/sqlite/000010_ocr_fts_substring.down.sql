@@ -0,0 +1,22 @@
+-- back to the word tokenizer of 000005
+drop trigger if exists ocr_fts_update;
+drop trigger if exists ocr_fts_delete;
+drop trigger if exists ocr_fts_insert;
+drop table if exists ocr_fts;
+
+create virtual table if not exists ocr_fts using fts5(text, content='ocr', content_rowid='id');
+
+insert into ocr_fts (rowid, text) select id, text from ocr;
+
+create trigger if not exists ocr_fts_insert after insert on ocr begin
+    insert into ocr_fts (rowid, text) values (new.id, new.text);
+end;
+
+create trigger if not exists ocr_fts_delete after delete on ocr begin
+    insert into ocr_fts (ocr_fts, rowid, text) values ('delete', old.id, old.text);
+end;
+
+create trigger if not exists ocr_fts_update after update of text on ocr begin
+    insert into ocr_fts (ocr_fts, rowid, text) values ('delete', old.id, old.text);
+    insert into ocr_fts (rowid, text) values (new.id, new.text);
+end;
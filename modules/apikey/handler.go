@@ -0,0 +1,145 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go-ocr/infrastructure/httplib"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/validator"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Http struct {
+	serviceApiKey ServiceInterface
+}
+
+func NewHttp(serviceApiKey ServiceInterface) InterfaceHttp {
+	return &Http{
+		serviceApiKey: serviceApiKey,
+	}
+}
+
+type InterfaceHttp interface {
+	GroupApiKey(group *gin.RouterGroup)
+}
+
+func (h *Http) GroupApiKey(g *gin.RouterGroup) {
+	g.POST("", h.CreateApiKey)
+	g.GET("", h.ListApiKey)
+	g.POST("/:id/rotate", h.RotateApiKey)
+	g.DELETE("/:id", h.RevokeApiKey)
+}
+
+func (h *Http) CreateApiKey(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.CreateApiKey")
+
+	var requestBody primitive.ApiKeyRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Error(ctx, logCtx, "ctx.ShouldBindJSON got err : %v", err)
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.SomethingWrongWithTheBodyRequest)
+		return
+	}
+
+	errValidateStruct := validator.ValidateStructResponseSliceString(requestBody)
+	if errValidateStruct != nil {
+		logger.Error(ctx, logCtx, "validator.ValidateStructResponseSliceString got err : %v", errValidateStruct)
+		httplib.SetCustomResponse(ctx, http.StatusBadRequest, http.StatusText(http.StatusBadRequest), nil, errValidateStruct)
+		return
+	}
+
+	response, err := h.serviceApiKey.CreateApiKey(ctx, requestBody)
+	if err != nil {
+		if errors.Is(err, primitive.ErrorTenantIdIsNotValid) {
+			httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.TenantIdIsNotValid)
+			return
+		}
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceApiKey.CreateApiKey")
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, primitive.SomethingWentWrong)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusCreated, primitive.CreateApiKeySuccess, response)
+	return
+}
+
+func (h *Http) ListApiKey(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.ListApiKey")
+
+	data, err := h.serviceApiKey.ListApiKey(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceApiKey.ListApiKey")
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, primitive.SomethingWentWrong)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), data)
+	return
+}
+
+func (h *Http) RotateApiKey(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.RotateApiKey")
+
+	idInt, err := getIdParam(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "getIdParam")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.ParamIdIsZeroOrNullString)
+		return
+	}
+
+	response, err := h.serviceApiKey.RotateApiKey(ctx, idInt)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceApiKey.RotateApiKey")
+		setApiKeyErrorResponse(ctx, err)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, primitive.RotateApiKeySuccess, response)
+	return
+}
+
+func (h *Http) RevokeApiKey(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.RevokeApiKey")
+
+	idInt, err := getIdParam(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "getIdParam")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.ParamIdIsZeroOrNullString)
+		return
+	}
+
+	response, err := h.serviceApiKey.RevokeApiKey(ctx, idInt)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceApiKey.RevokeApiKey")
+		setApiKeyErrorResponse(ctx, err)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, primitive.RevokeApiKeySuccess, response)
+	return
+}
+
+func getIdParam(ctx *gin.Context) (int64, error) {
+	idInt, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || idInt == 0 {
+		return 0, errors.New(primitive.ParamIdIsZeroOrNullString)
+	}
+	return idInt, nil
+}
+
+func setApiKeyErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case utils.ContainsError(err, []error{gorm.ErrRecordNotFound, primitive.ErrorApiKeyNotFound}):
+		httplib.SetErrorResponse(ctx, http.StatusNotFound, primitive.ErrApiKeyNotFound)
+	case errors.Is(err, primitive.ErrorApiKeyAlreadyRevoked):
+		httplib.SetErrorResponse(ctx, http.StatusConflict, primitive.ErrApiKeyAlreadyRevoked)
+	default:
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, primitive.SomethingWentWrong)
+	}
+}
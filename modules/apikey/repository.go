@@ -0,0 +1,87 @@
+package apikey
+
+import (
+	"context"
+
+	"go-ocr/modules/primitive"
+
+	"gorm.io/gorm"
+)
+
+type RepositoryInterface interface {
+	CreateApiKey(ctx context.Context, request primitive.ApiKey) (result primitive.ApiKey, err error)
+	UpdateApiKey(ctx context.Context, request primitive.ApiKey) (result primitive.ApiKey, err error)
+	FindApiKeyByID(ctx context.Context, id int64) (result primitive.ApiKey, err error)
+	FindApiKeyByPrefix(ctx context.Context, prefix string) (result primitive.ApiKey, err error)
+	FindAllApiKey(ctx context.Context) (result []primitive.ApiKey, err error)
+}
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (repo *Repository) CreateApiKey(ctx context.Context, request primitive.ApiKey) (result primitive.ApiKey, err error) {
+	err = repo.db.WithContext(ctx).Table("api_key").Create(&request).Scan(&result).Error
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (repo *Repository) UpdateApiKey(ctx context.Context, request primitive.ApiKey) (result primitive.ApiKey, err error) {
+	err = repo.db.WithContext(ctx).Table("api_key").
+		Where("id = ?", request.ID).
+		Updates(map[string]interface{}{
+			"name":        request.Name,
+			"prefix":      request.Prefix,
+			"secret_hash": request.SecretHash,
+			"roles":       request.Roles,
+			"updated_at":  request.UpdatedAt,
+			"rotated_at":  request.RotatedAt,
+			"revoked_at":  request.RevokedAt,
+		}).
+		Error
+	if err != nil {
+		return result, err
+	}
+	return repo.FindApiKeyByID(ctx, request.ID)
+}
+
+func (repo *Repository) FindApiKeyByID(ctx context.Context, id int64) (result primitive.ApiKey, err error) {
+	err = repo.db.WithContext(ctx).Table("api_key").
+		Where("id = ?", id).
+		First(&result).
+		Error
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (repo *Repository) FindApiKeyByPrefix(ctx context.Context, prefix string) (result primitive.ApiKey, err error) {
+	err = repo.db.WithContext(ctx).Table("api_key").
+		Where("prefix = ?", prefix).
+		First(&result).
+		Error
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (repo *Repository) FindAllApiKey(ctx context.Context) (result []primitive.ApiKey, err error) {
+	err = repo.db.WithContext(ctx).Table("api_key").
+		Order("id desc").
+		Find(&result).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
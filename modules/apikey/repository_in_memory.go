@@ -0,0 +1,92 @@
+package apikey
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go-ocr/modules/primitive"
+)
+
+// InMemoryRepository stores api keys in memory.
+type InMemoryRepository struct {
+	apiKeys    map[int64]primitive.ApiKey
+	idSequence int64
+	mu         sync.RWMutex
+}
+
+// CreateApiKey adds a new api key to the in-memory repository.
+func (i *InMemoryRepository) CreateApiKey(ctx context.Context, request primitive.ApiKey) (result primitive.ApiKey, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	request.ID = i.idSequence
+	i.idSequence++
+	i.apiKeys[request.ID] = request
+
+	return request, nil
+}
+
+// UpdateApiKey replaces the stored api key with the same ID.
+func (i *InMemoryRepository) UpdateApiKey(ctx context.Context, request primitive.ApiKey) (result primitive.ApiKey, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.apiKeys[request.ID]; !ok {
+		return primitive.ApiKey{}, primitive.ErrorApiKeyNotFound
+	}
+	i.apiKeys[request.ID] = request
+
+	return request, nil
+}
+
+// FindApiKeyByID retrieves an api key by its ID.
+func (i *InMemoryRepository) FindApiKeyByID(ctx context.Context, id int64) (result primitive.ApiKey, err error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	apiKey, ok := i.apiKeys[id]
+	if !ok {
+		return primitive.ApiKey{}, primitive.ErrorApiKeyNotFound
+	}
+
+	return apiKey, nil
+}
+
+// FindApiKeyByPrefix retrieves an api key by its public prefix.
+func (i *InMemoryRepository) FindApiKeyByPrefix(ctx context.Context, prefix string) (result primitive.ApiKey, err error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, apiKey := range i.apiKeys {
+		if apiKey.Prefix == prefix {
+			return apiKey, nil
+		}
+	}
+
+	return primitive.ApiKey{}, primitive.ErrorApiKeyNotFound
+}
+
+// FindAllApiKey returns every api key ordered by the newest first.
+func (i *InMemoryRepository) FindAllApiKey(ctx context.Context) (result []primitive.ApiKey, err error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	result = make([]primitive.ApiKey, 0, len(i.apiKeys))
+	for _, apiKey := range i.apiKeys {
+		result = append(result, apiKey)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].ID > result[b].ID
+	})
+
+	return result, nil
+}
+
+// NewInMemoryRepository creates a new instance of InMemoryRepository.
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		apiKeys:    make(map[int64]primitive.ApiKey),
+		idSequence: 1,
+	}
+}
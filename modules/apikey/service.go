@@ -0,0 +1,252 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/config"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	keyPrefix       = "gocr"
+	keyPrefixBytes  = 6
+	keySecretBytes  = 32
+	keyPartsCount   = 3
+	bootstrapKeyID  = "bootstrap"
+	bootstrapKeyTag = "bootstrap admin key"
+)
+
+type ServiceInterface interface {
+	CreateApiKey(ctx context.Context, payload primitive.ApiKeyRequest) (primitive.ApiKeyCreatedResponse, error)
+	ListApiKey(ctx context.Context) ([]primitive.ApiKeyResponse, error)
+	RotateApiKey(ctx context.Context, id int64) (primitive.ApiKeyCreatedResponse, error)
+	RevokeApiKey(ctx context.Context, id int64) (primitive.ApiKeyResponse, error)
+	AuthenticateApiKey(ctx context.Context, rawKey string) (auth.Identity, error)
+}
+
+type Service struct {
+	repository RepositoryInterface
+}
+
+func NewService(repository RepositoryInterface) ServiceInterface {
+	return &Service{
+		repository: repository,
+	}
+}
+
+// generateKey returns the plain key given to the client, its public prefix
+// used for the lookup and the hash of the whole key that get stored.
+func generateKey() (plainKey, prefix, secretHash string, err error) {
+	prefixBytes := make([]byte, keyPrefixBytes)
+	if _, err = rand.Read(prefixBytes); err != nil {
+		return
+	}
+	secretBytes := make([]byte, keySecretBytes)
+	if _, err = rand.Read(secretBytes); err != nil {
+		return
+	}
+	prefix = hex.EncodeToString(prefixBytes)
+	plainKey = strings.Join([]string{keyPrefix, prefix, base64.RawURLEncoding.EncodeToString(secretBytes)}, "_")
+	secretHash = hashKey(plainKey)
+	return
+}
+
+func hashKey(plainKey string) string {
+	sum := sha256.Sum256([]byte(plainKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func splitRoles(roles string) []string {
+	result := make([]string, 0)
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			result = append(result, role)
+		}
+	}
+	return result
+}
+
+func toApiKeyResponse(data primitive.ApiKey) primitive.ApiKeyResponse {
+	return primitive.ApiKeyResponse{
+		ID:        data.ID,
+		Name:      data.Name,
+		TenantID:  data.TenantID,
+		Prefix:    data.Prefix,
+		Roles:     splitRoles(data.Roles),
+		CreatedAt: data.CreatedAt,
+		UpdatedAt: data.UpdatedAt,
+		RotatedAt: data.RotatedAt,
+		RevokedAt: data.RevokedAt,
+	}
+}
+
+func (s *Service) CreateApiKey(ctx context.Context, payload primitive.ApiKeyRequest) (primitive.ApiKeyCreatedResponse, error) {
+	logCtx := "service.CreateApiKey"
+
+	if payload.TenantID != "" && !tenant.IsValidID(payload.TenantID) {
+		return primitive.ApiKeyCreatedResponse{}, primitive.ErrorTenantIdIsNotValid
+	}
+
+	plainKey, prefix, secretHash, err := generateKey()
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "generateKey")
+		return primitive.ApiKeyCreatedResponse{}, err
+	}
+
+	now := time.Now()
+	data, err := s.repository.CreateApiKey(ctx, primitive.ApiKey{
+		Name:       payload.Name,
+		TenantID:   payload.TenantID,
+		Prefix:     prefix,
+		SecretHash: secretHash,
+		Roles:      strings.Join(payload.Roles, ","),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.CreateApiKey")
+		return primitive.ApiKeyCreatedResponse{}, err
+	}
+
+	return primitive.ApiKeyCreatedResponse{
+		ApiKeyResponse: toApiKeyResponse(data),
+		Key:            plainKey,
+	}, nil
+}
+
+func (s *Service) ListApiKey(ctx context.Context) ([]primitive.ApiKeyResponse, error) {
+	logCtx := "service.ListApiKey"
+
+	listData, err := s.repository.FindAllApiKey(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.FindAllApiKey")
+		return nil, err
+	}
+
+	list := make([]primitive.ApiKeyResponse, 0, len(listData))
+	for _, val := range listData {
+		list = append(list, toApiKeyResponse(val))
+	}
+
+	return list, nil
+}
+
+func (s *Service) RotateApiKey(ctx context.Context, id int64) (primitive.ApiKeyCreatedResponse, error) {
+	logCtx := "service.RotateApiKey"
+
+	data, err := s.repository.FindApiKeyByID(ctx, id)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.FindApiKeyByID")
+		return primitive.ApiKeyCreatedResponse{}, err
+	}
+	if data.RevokedAt != nil {
+		return primitive.ApiKeyCreatedResponse{}, primitive.ErrorApiKeyAlreadyRevoked
+	}
+
+	plainKey, prefix, secretHash, err := generateKey()
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "generateKey")
+		return primitive.ApiKeyCreatedResponse{}, err
+	}
+
+	now := time.Now()
+	data.Prefix = prefix
+	data.SecretHash = secretHash
+	data.UpdatedAt = now
+	data.RotatedAt = &now
+	data, err = s.repository.UpdateApiKey(ctx, data)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.UpdateApiKey")
+		return primitive.ApiKeyCreatedResponse{}, err
+	}
+
+	return primitive.ApiKeyCreatedResponse{
+		ApiKeyResponse: toApiKeyResponse(data),
+		Key:            plainKey,
+	}, nil
+}
+
+func (s *Service) RevokeApiKey(ctx context.Context, id int64) (primitive.ApiKeyResponse, error) {
+	logCtx := "service.RevokeApiKey"
+
+	data, err := s.repository.FindApiKeyByID(ctx, id)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.FindApiKeyByID")
+		return primitive.ApiKeyResponse{}, err
+	}
+	if data.RevokedAt != nil {
+		return primitive.ApiKeyResponse{}, primitive.ErrorApiKeyAlreadyRevoked
+	}
+
+	now := time.Now()
+	data.UpdatedAt = now
+	data.RevokedAt = &now
+	data, err = s.repository.UpdateApiKey(ctx, data)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.UpdateApiKey")
+		return primitive.ApiKeyResponse{}, err
+	}
+
+	return toApiKeyResponse(data), nil
+}
+
+func (s *Service) AuthenticateApiKey(ctx context.Context, rawKey string) (auth.Identity, error) {
+	// the admin key from the config is used to create the first keys
+	adminKey := config.Conf.Auth.AdminKey
+	if adminKey != "" && subtle.ConstantTimeCompare([]byte(rawKey), []byte(adminKey)) == 1 {
+		roles := []string{auth.RoleAdmin}
+		return auth.Identity{
+			ID:          bootstrapKeyID,
+			Name:        bootstrapKeyTag,
+			Type:        auth.TypeApiKey,
+			Roles:       roles,
+			Permissions: auth.PermissionsForRoles(roles),
+		}, nil
+	}
+
+	// the secret is base64url so it can hold the separator too
+	parts := strings.SplitN(rawKey, "_", keyPartsCount)
+	if len(parts) != keyPartsCount || parts[0] != keyPrefix {
+		return auth.Identity{}, auth.ErrInvalidCredential
+	}
+
+	data, err := s.repository.FindApiKeyByPrefix(ctx, parts[1])
+	if err != nil {
+		if utils.ContainsError(err, []error{gorm.ErrRecordNotFound, primitive.ErrorApiKeyNotFound}) {
+			return auth.Identity{}, auth.ErrInvalidCredential
+		}
+		return auth.Identity{}, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashKey(rawKey)), []byte(data.SecretHash)) != 1 {
+		return auth.Identity{}, auth.ErrInvalidCredential
+	}
+	if data.RevokedAt != nil {
+		return auth.Identity{}, auth.ErrInvalidCredential
+	}
+
+	roles := splitRoles(data.Roles)
+	return auth.Identity{
+		ID:          strconv.FormatInt(data.ID, 10),
+		Name:        data.Name,
+		Type:        auth.TypeApiKey,
+		Roles:       roles,
+		Permissions: auth.PermissionsForRoles(roles),
+		TenantID:    data.TenantID,
+	}, nil
+}
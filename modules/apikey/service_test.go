@@ -0,0 +1,134 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/config"
+	"go-ocr/modules/primitive"
+)
+
+func TestApiKeyLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repository := NewInMemoryRepository()
+	service := NewService(repository)
+
+	created, err := service.CreateApiKey(ctx, primitive.ApiKeyRequest{Name: "scanner", TenantID: "acme", Roles: []string{"reader"}})
+	if err != nil {
+		t.Fatalf("CreateApiKey() error = %v", err)
+	}
+	if !strings.HasPrefix(created.Key, keyPrefix+"_"+created.Prefix+"_") {
+		t.Fatalf("CreateApiKey() key %q does not start with its prefix %q", created.Key, created.Prefix)
+	}
+
+	// only the hash of the key is stored
+	secret := created.Key[strings.LastIndex(created.Key, "_")+1:]
+	stored, err := repository.FindApiKeyByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("FindApiKeyByID() error = %v", err)
+	}
+	if strings.Contains(stored.SecretHash, secret) {
+		t.Fatalf("the stored hash %q holds the secret", stored.SecretHash)
+	}
+	if stored.SecretHash != hashKey(created.Key) {
+		t.Fatalf("the stored hash %q is not the hash of the key", stored.SecretHash)
+	}
+
+	identity, err := service.AuthenticateApiKey(ctx, created.Key)
+	if err != nil {
+		t.Fatalf("AuthenticateApiKey() error = %v", err)
+	}
+	if identity.TenantID != "acme" || identity.Type != auth.TypeApiKey || !identity.HasPermission(auth.PermissionOcrRead) || identity.HasPermission(auth.PermissionOcrWrite) {
+		t.Fatalf("AuthenticateApiKey() = %+v, want a reader of acme", identity)
+	}
+
+	invalidKeys := map[string]string{
+		"empty":                "",
+		"malformed":            "not-a-key",
+		"another prefix":       strings.Join([]string{keyPrefix, "000000000000", secret}, "_"),
+		"another secret":       strings.Join([]string{keyPrefix, created.Prefix, strings.Repeat("A", len(secret))}, "_"),
+		"another key prefix":   strings.Join([]string{"other", created.Prefix, secret}, "_"),
+		"the stored hash":      stored.SecretHash,
+		"a trailing character": created.Key + "A",
+	}
+	for name, rawKey := range invalidKeys {
+		if _, err = service.AuthenticateApiKey(ctx, rawKey); !errors.Is(err, auth.ErrInvalidCredential) {
+			t.Errorf("AuthenticateApiKey() with %s error = %v, want %v", name, err, auth.ErrInvalidCredential)
+		}
+	}
+
+	// the rotated key replaces the old one
+	rotated, err := service.RotateApiKey(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("RotateApiKey() error = %v", err)
+	}
+	if _, err = service.AuthenticateApiKey(ctx, created.Key); !errors.Is(err, auth.ErrInvalidCredential) {
+		t.Fatalf("AuthenticateApiKey() with the key before rotation error = %v, want %v", err, auth.ErrInvalidCredential)
+	}
+	if _, err = service.AuthenticateApiKey(ctx, rotated.Key); err != nil {
+		t.Fatalf("AuthenticateApiKey() with the rotated key error = %v", err)
+	}
+
+	if _, err = service.RevokeApiKey(ctx, created.ID); err != nil {
+		t.Fatalf("RevokeApiKey() error = %v", err)
+	}
+	if _, err = service.AuthenticateApiKey(ctx, rotated.Key); !errors.Is(err, auth.ErrInvalidCredential) {
+		t.Fatalf("AuthenticateApiKey() with a revoked key error = %v, want %v", err, auth.ErrInvalidCredential)
+	}
+	if _, err = service.RotateApiKey(ctx, created.ID); !errors.Is(err, primitive.ErrorApiKeyAlreadyRevoked) {
+		t.Fatalf("RotateApiKey() of a revoked key error = %v, want %v", err, primitive.ErrorApiKeyAlreadyRevoked)
+	}
+}
+
+func TestAuthenticateApiKeySecretWithSeparator(t *testing.T) {
+	ctx := context.Background()
+	repository := NewInMemoryRepository()
+	plainKey := strings.Join([]string{keyPrefix, "0123456789ab", "se_cr-et"}, "_")
+	if _, err := repository.CreateApiKey(ctx, primitive.ApiKey{Prefix: "0123456789ab", SecretHash: hashKey(plainKey), Roles: "writer"}); err != nil {
+		t.Fatalf("CreateApiKey() error = %v", err)
+	}
+
+	identity, err := NewService(repository).AuthenticateApiKey(ctx, plainKey)
+	if err != nil {
+		t.Fatalf("AuthenticateApiKey() error = %v", err)
+	}
+	if !identity.HasPermission(auth.PermissionOcrWrite) {
+		t.Fatalf("AuthenticateApiKey() = %+v, want a writer", identity)
+	}
+}
+
+func TestCreateApiKeyTenant(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	_, err := service.CreateApiKey(context.Background(), primitive.ApiKeyRequest{Name: "scanner", TenantID: "../acme"})
+	if !errors.Is(err, primitive.ErrorTenantIdIsNotValid) {
+		t.Fatalf("CreateApiKey() error = %v, want %v", err, primitive.ErrorTenantIdIsNotValid)
+	}
+}
+
+func TestAuthenticateAdminKey(t *testing.T) {
+	previous := config.Conf.Auth.AdminKey
+	t.Cleanup(func() { config.Conf.Auth.AdminKey = previous })
+
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+
+	config.Conf.Auth.AdminKey = ""
+	if _, err := service.AuthenticateApiKey(ctx, ""); !errors.Is(err, auth.ErrInvalidCredential) {
+		t.Fatalf("AuthenticateApiKey() without an admin key configured error = %v, want %v", err, auth.ErrInvalidCredential)
+	}
+
+	config.Conf.Auth.AdminKey = "the-bootstrap-key"
+	identity, err := service.AuthenticateApiKey(ctx, "the-bootstrap-key")
+	if err != nil {
+		t.Fatalf("AuthenticateApiKey() error = %v", err)
+	}
+	if identity.ID != bootstrapKeyID || !identity.HasRole(auth.RoleAdmin) || !identity.HasPermission(auth.PermissionOcrAdmin) {
+		t.Fatalf("AuthenticateApiKey() = %+v, want the bootstrap admin", identity)
+	}
+	if _, err = service.AuthenticateApiKey(ctx, "the-bootstrap-ke"); !errors.Is(err, auth.ErrInvalidCredential) {
+		t.Fatalf("AuthenticateApiKey() with a prefix of the admin key error = %v, want %v", err, auth.ErrInvalidCredential)
+	}
+}
@@ -0,0 +1,26 @@
+package health
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type SqliteRepository struct {
+	db *gorm.DB
+}
+
+func NewSqliteRepository(db *gorm.DB) *SqliteRepository {
+	return &SqliteRepository{
+		db: db,
+	}
+}
+
+func (r SqliteRepository) CheckUpTimeDB(ctx context.Context) (err error) {
+	db, err := r.db.WithContext(ctx).DB()
+	if err != nil {
+		return err
+	}
+
+	return db.PingContext(ctx)
+}
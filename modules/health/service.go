@@ -31,7 +31,7 @@ func (u *Service) CheckUpTime(ctx context.Context) (primitive.HealthResponse, er
 	ctxName := "CheckUpTime"
 
 	var postgresStatus string
-	if config.Conf.Postgres.EnablePostgres {
+	if config.Conf.Postgres.EnablePostgres || config.Conf.Sqlite.EnableSqlite {
 		if u.repository == nil {
 			err := errors.New("repository doesn't initiate on the boot file")
 			return primitive.HealthResponse{}, err
@@ -44,7 +44,7 @@ func (u *Service) CheckUpTime(ctx context.Context) (primitive.HealthResponse, er
 		}
 		postgresStatus = "healthy"
 	} else {
-		postgresStatus = "postgres is not enabled"
+		postgresStatus = "database is not enabled"
 	}
 
 	var redisStatus string
@@ -0,0 +1,341 @@
+package ocr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"time"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/events"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+)
+
+const (
+	// MaxBatchFiles is the most files of a batch, each one is a recognition
+	MaxBatchFiles = 20
+	// batchRetention is how long a finished batch is kept, its records are kept like the others
+	batchRetention   = 24 * time.Hour
+	eventsTopicBatch = "ocr:%s:batch:%s:events"
+)
+
+// batchUpload is an upload of a batch copied to a temporary file, the request is done before it is recognized
+type batchUpload struct {
+	name string
+	path string
+}
+
+// CreateBatchOcr copies the uploads then recognizes them one after the other in the background,
+// the progress is read on the batch or streamed by its events
+func (s *Service) CreateBatchOcr(ctx context.Context, payload primitive.OcrRequest, files []*multipart.FileHeader) (primitive.OcrBatchResponse, error) {
+	logCtx := fmt.Sprintf("service.CreateBatchOcr")
+
+	if len(files) == 0 {
+		return primitive.OcrBatchResponse{}, errors.New(primitive.BatchHasNoFile)
+	}
+	if len(files) > MaxBatchFiles {
+		return primitive.OcrBatchResponse{}, fmt.Errorf("%s, at most %d", primitive.BatchHasTooManyFiles, MaxBatchFiles)
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "newRandomID")
+		return primitive.OcrBatchResponse{}, err
+	}
+
+	uploads := make([]batchUpload, 0, len(files))
+	for _, fileHeader := range files {
+		path, errCopy := copyBatchUpload(fileHeader)
+		if errCopy != nil {
+			logger.Error(ctx, utils.ErrorLogFormat, errCopy.Error(), logCtx, "copyBatchUpload")
+			removeBatchUploads(uploads)
+			return primitive.OcrBatchResponse{}, errCopy
+		}
+		uploads = append(uploads, batchUpload{name: fileHeader.Filename, path: path})
+	}
+
+	batch := &primitive.OcrBatch{
+		ID:        id,
+		TenantID:  tenant.FromContext(ctx),
+		Status:    primitive.BatchStatusPending,
+		Files:     make([]primitive.OcrBatchFile, len(uploads)),
+		CreatedAt: time.Now(),
+	}
+	for idx, upload := range uploads {
+		batch.Files[idx] = primitive.OcrBatchFile{Name: upload.name, Status: primitive.BatchStatusPending}
+	}
+	if identity, ok := auth.FromContext(ctx); ok {
+		batch.CreatedBy = identity.String()
+	}
+
+	s.batchesMu.Lock()
+	s.batches[id] = batch
+	response := toOcrBatchResponse(*batch)
+	s.batchesMu.Unlock()
+
+	err = s.startJob(ctx, id, func(ctx context.Context) {
+		s.runBatch(ctx, id, payload, uploads)
+	})
+	if err != nil {
+		s.batchesMu.Lock()
+		delete(s.batches, id)
+		s.batchesMu.Unlock()
+		removeBatchUploads(uploads)
+		return primitive.OcrBatchResponse{}, err
+	}
+
+	return response, nil
+}
+
+// copyBatchUpload copies the upload to a temporary file, the multipart files are removed with the request
+func copyBatchUpload(fileHeader *multipart.FileHeader) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	tmp, err := os.CreateTemp("", "ocr-batch-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, file)
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func removeBatchUploads(uploads []batchUpload) {
+	for _, upload := range uploads {
+		_ = os.Remove(upload.path)
+	}
+}
+
+func (s *Service) runBatch(ctx context.Context, id string, payload primitive.OcrRequest, uploads []batchUpload) {
+	logCtx := fmt.Sprintf("service.runBatch")
+	defer removeBatchUploads(uploads)
+
+	s.batchesMu.Lock()
+	batch := s.batches[id]
+	batch.Status = primitive.BatchStatusRunning
+	running := toOcrBatchResponse(*batch)
+	s.batchesMu.Unlock()
+	topic := fmt.Sprintf(eventsTopicBatch, batch.TenantID, id)
+	s.publishBatchEvent(ctx, topic, events.TypeStatus, running)
+
+	for idx, upload := range uploads {
+		var record *primitive.OCrResponse
+		var err error
+		if ctx.Err() != nil {
+			// canceled by the drain of the shutdown, the files left fail without being recognized
+			err = primitive.ErrorShuttingDown
+		} else {
+			var file *os.File
+			file, err = os.Open(upload.path)
+			if err == nil {
+				var data primitive.OCrResponse
+				data, err = s.ProcessImage(ctx, payload, upload.name, file)
+				file.Close()
+				record = &data
+			}
+		}
+
+		s.batchesMu.Lock()
+		if err != nil {
+			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.ProcessImage")
+			batch.Files[idx].Status = primitive.BatchStatusFailed
+			batch.Files[idx].Error = err.Error()
+			record = nil
+		} else {
+			batch.Files[idx].Status = primitive.BatchStatusSuccessful
+			batch.Files[idx].RecordID = record.ID
+		}
+		state := toOcrBatchResponse(*batch)
+		s.batchesMu.Unlock()
+
+		s.publishBatchEvent(ctx, topic, events.TypeResult, primitive.OcrBatchResultEvent{ID: id, File: state.Files[idx], Record: record})
+		s.publishBatchEvent(ctx, topic, events.TypeProgress, primitive.OcrBatchProgressEvent{
+			ID: id, Completed: state.Completed, Failed: state.Failed, Total: state.Total, Percent: state.Percent,
+		})
+	}
+
+	s.batchesMu.RLock()
+	final := *batch
+	s.batchesMu.RUnlock()
+	final.FinishedAt = time.Now()
+	state := toOcrBatchResponse(final)
+	switch {
+	case state.Failed == 0:
+		final.Status = primitive.BatchStatusSuccessful
+	case state.Completed == 0:
+		final.Status = primitive.BatchStatusFailed
+	default:
+		final.Status = primitive.BatchStatusPartial
+	}
+	state.Status = final.Status
+
+	// the final status is the last event, the batch is finished once it is published so
+	// a subscriber never misses it
+	s.publishBatchEvent(ctx, topic, events.TypeStatus, state)
+	s.batchesMu.Lock()
+	batch.Status = final.Status
+	batch.FinishedAt = final.FinishedAt
+	s.batchesMu.Unlock()
+}
+
+// isBatchFinished reports whether the status of the batch is final
+func isBatchFinished(status string) bool {
+	return status == primitive.BatchStatusSuccessful || status == primitive.BatchStatusPartial || status == primitive.BatchStatusFailed
+}
+
+func (s *Service) publishBatchEvent(ctx context.Context, topic string, eventType string, data interface{}) {
+	logCtx := fmt.Sprintf("service.publishBatchEvent")
+
+	if s.broker == nil {
+		return
+	}
+	if err := s.broker.Publish(ctx, topic, eventType, data); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.broker.Publish")
+	}
+}
+
+// GetBatchOcr returns the state of a batch of the tenant
+func (s *Service) GetBatchOcr(ctx context.Context, id string) (primitive.OcrBatchResponse, error) {
+	batch, err := s.findBatch(ctx, id)
+	if err != nil {
+		return primitive.OcrBatchResponse{}, err
+	}
+	return toOcrBatchResponse(batch), nil
+}
+
+// SubscribeBatchEvents stream the events of the batch after lastEventID, the events are replayed
+// while the broker keeps them, then only the final state of a finished batch can be given
+func (s *Service) SubscribeBatchEvents(ctx context.Context, id string, lastEventID int64) (<-chan events.Event, error) {
+	logCtx := fmt.Sprintf("service.SubscribeBatchEvents")
+
+	batch, err := s.findBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	finished := !batch.FinishedAt.IsZero()
+
+	topic := fmt.Sprintf(eventsTopicBatch, batch.TenantID, batch.ID)
+	var lastPublishedID int64
+	if s.broker != nil {
+		lastPublishedID, err = s.broker.LastEventID(ctx, topic)
+		if err != nil {
+			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.broker.LastEventID")
+			return nil, err
+		}
+	}
+
+	if finished && lastPublishedID == 0 {
+		// the events expired, give the final state as the only event
+		if lastEventID >= 1 {
+			return nil, primitive.ErrorNoMoreEvents
+		}
+		raw, errMarshal := json.Marshal(toOcrBatchResponse(batch))
+		if errMarshal != nil {
+			return nil, errMarshal
+		}
+		stream := make(chan events.Event, 1)
+		stream <- events.Event{ID: 1, Type: events.TypeStatus, Data: raw}
+		close(stream)
+		return stream, nil
+	}
+
+	// the batch is final, a client that got every event must not reconnect
+	if finished && lastEventID >= lastPublishedID {
+		return nil, primitive.ErrorNoMoreEvents
+	}
+	if s.broker == nil {
+		return nil, events.ErrBrokerClosed
+	}
+	return s.broker.Subscribe(ctx, topic, lastEventID)
+}
+
+func (s *Service) findBatch(ctx context.Context, id string) (primitive.OcrBatch, error) {
+	s.batchesMu.RLock()
+	defer s.batchesMu.RUnlock()
+
+	batch, ok := s.batches[id]
+	if !ok || batch.TenantID != tenant.FromContext(ctx) {
+		return primitive.OcrBatch{}, primitive.ErrorBatchNotFound
+	}
+	copied := *batch
+	copied.Files = append([]primitive.OcrBatchFile(nil), batch.Files...)
+	return copied, nil
+}
+
+// newRandomID returns a random id of a batch, an export or a stored upload, it can't be guessed to read the one of someone else
+func newRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// PurgeExpiredBatches forgets the batches finished for longer than the retention
+func (s *Service) PurgeExpiredBatches(ctx context.Context) (count int64, err error) {
+	s.batchesMu.Lock()
+	defer s.batchesMu.Unlock()
+
+	expiredBefore := time.Now().Add(-batchRetention)
+	for id, batch := range s.batches {
+		if batch.FinishedAt.IsZero() || batch.FinishedAt.After(expiredBefore) {
+			continue
+		}
+		delete(s.batches, id)
+		count++
+	}
+	return count, nil
+}
+
+func toOcrBatchResponse(batch primitive.OcrBatch) primitive.OcrBatchResponse {
+	response := primitive.OcrBatchResponse{
+		ID:        batch.ID,
+		Status:    batch.Status,
+		Total:     len(batch.Files),
+		Files:     make([]primitive.OcrBatchFileResponse, 0, len(batch.Files)),
+		CreatedBy: batch.CreatedBy,
+		CreatedAt: batch.CreatedAt,
+	}
+	for idx, file := range batch.Files {
+		switch file.Status {
+		case primitive.BatchStatusSuccessful:
+			response.Completed++
+		case primitive.BatchStatusFailed:
+			response.Failed++
+		}
+		response.Files = append(response.Files, primitive.OcrBatchFileResponse{
+			Index:    idx,
+			Name:     file.Name,
+			Status:   file.Status,
+			RecordID: file.RecordID,
+			Error:    file.Error,
+		})
+	}
+	if response.Total > 0 {
+		response.Percent = (response.Completed + response.Failed) * 100 / response.Total
+	}
+	if !batch.FinishedAt.IsZero() {
+		finishedAt := batch.FinishedAt
+		response.FinishedAt = &finishedAt
+	}
+	return response
+}
@@ -0,0 +1,145 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"testing"
+	"time"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/events"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+)
+
+// batchFiles returns the file headers of a multipart form holding the named files
+func batchFiles(t *testing.T, names ...string) []*multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, name := range names {
+		part, err := writer.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = part.Write([]byte("not an image")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form.File["files"]
+}
+
+func TestCreateBatchOcr(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		draining bool
+		wantErr  bool
+	}{
+		{name: "without file", wantErr: true},
+		{name: "too many files", files: make([]string, MaxBatchFiles+1), wantErr: true},
+		{name: "files", files: []string{"a.png", "b.png"}},
+		{name: "shutting down", files: []string{"a.png"}, draining: true, wantErr: true},
+	}
+	previous := config.Conf.UploadDir
+	t.Cleanup(func() { config.Conf.UploadDir = previous })
+	config.Conf.UploadDir = t.TempDir()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tenant.WithTenant(context.Background(), "acme")
+			service := &Service{repository: NewInMemoryRepository(), broker: events.NewInMemoryBroker(0, 0), batches: make(map[string]*primitive.OcrBatch)}
+			var files []*multipart.FileHeader
+			if len(tt.files) > 0 {
+				files = batchFiles(t, tt.files...)
+			}
+
+			if tt.draining {
+				service.DrainJobs(context.Background())
+			}
+
+			batch, err := service.CreateBatchOcr(ctx, primitive.OcrRequest{HOCREnabled: "maybe"}, files)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateBatchOcr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(service.batches) != 0 {
+					t.Fatalf("CreateBatchOcr() kept %d batches after the error", len(service.batches))
+				}
+				return
+			}
+			if batch.Status != primitive.BatchStatusPending || batch.Total != len(tt.files) {
+				t.Fatalf("CreateBatchOcr() = %+v, want a pending batch of %d files", batch, len(tt.files))
+			}
+
+			streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			stream, err := service.SubscribeBatchEvents(streamCtx, batch.ID, 0)
+			if err != nil {
+				t.Fatalf("SubscribeBatchEvents() error = %v", err)
+			}
+
+			// every file fails before the recognition, the stream ends with the final status
+			var types []string
+			var lastID int64
+			var final primitive.OcrBatchResponse
+			for event := range stream {
+				types = append(types, event.Type)
+				lastID = event.ID
+				if event.Type == events.TypeStatus {
+					if err = json.Unmarshal(event.Data, &final); err != nil {
+						t.Fatal(err)
+					}
+					if isBatchFinished(final.Status) {
+						break
+					}
+				}
+			}
+			wantTypes := []string{events.TypeStatus, events.TypeResult, events.TypeProgress, events.TypeResult, events.TypeProgress, events.TypeStatus}
+			if len(types) != len(wantTypes) {
+				t.Fatalf("got events %v, want %v", types, wantTypes)
+			}
+			for idx := range types {
+				if types[idx] != wantTypes[idx] {
+					t.Fatalf("got events %v, want %v", types, wantTypes)
+				}
+			}
+			if final.Status != primitive.BatchStatusFailed || final.Failed != 2 || final.Percent != 100 || final.FinishedAt == nil {
+				t.Fatalf("got final state %+v, want a failed batch", final)
+			}
+
+			// the batch is finished once its last event is published
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				got, errGet := service.GetBatchOcr(ctx, batch.ID)
+				if errGet != nil {
+					t.Fatalf("GetBatchOcr() error = %v", errGet)
+				}
+				if got.FinishedAt != nil {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("GetBatchOcr() = %+v, the batch never finished", got)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if _, err = service.SubscribeBatchEvents(ctx, batch.ID, lastID); !errors.Is(err, primitive.ErrorNoMoreEvents) {
+				t.Fatalf("SubscribeBatchEvents() after the last event error = %v, want %v", err, primitive.ErrorNoMoreEvents)
+			}
+			otherTenant := tenant.WithTenant(context.Background(), "other")
+			if _, err = service.GetBatchOcr(otherTenant, batch.ID); !errors.Is(err, primitive.ErrorBatchNotFound) {
+				t.Fatalf("GetBatchOcr() of another tenant error = %v, want %v", err, primitive.ErrorBatchNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package ocr
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"go-ocr/modules/primitive"
+)
+
+// encodeCursor returns the opaque cursor of a keyset page starting after the row, or before it when backward.
+// The cursor only holds the id of the row, the sort value is read back from the row so a long text stays out.
+func encodeCursor(data primitive.Ocr, sortBy, sortOrder string, backward bool) string {
+	column, ascending := sortColumn(sortBy, sortOrder)
+	cursor := primitive.OcrCursor{
+		SortBy:    column,
+		SortOrder: "desc",
+		ID:        data.ID,
+		Backward:  backward,
+	}
+	if ascending {
+		cursor.SortOrder = "asc"
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor returned by a previous page, the sort of the list is the one of the cursor
+func DecodeCursor(value string) (*primitive.OcrCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, primitive.ErrorCursorNotValid
+	}
+	var cursor primitive.OcrCursor
+	if err = json.Unmarshal(raw, &cursor); err != nil {
+		return nil, primitive.ErrorCursorNotValid
+	}
+	if cursor.SortOrder != "asc" && cursor.SortOrder != "desc" {
+		return nil, primitive.ErrorCursorNotValid
+	}
+	if column, ok := sortColumns[cursor.SortBy]; !ok || column != cursor.SortBy {
+		return nil, primitive.ErrorCursorNotValid
+	}
+	return &cursor, nil
+}
+
+// columnValue returns the sort column of the row as a query argument
+func columnValue(data primitive.Ocr, column string) interface{} {
+	switch column {
+	case "text":
+		return data.Text
+	case "status":
+		return data.Status
+	case "document_type":
+		return data.DocumentType
+	case "language":
+		return data.Language
+	case "confidence":
+		return data.Confidence
+	case "created_at":
+		return data.CreatedAt.UTC()
+	case "updated_at":
+		return data.UpdatedAt.UTC()
+	default:
+		return data.ID
+	}
+}
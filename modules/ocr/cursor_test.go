@@ -0,0 +1,93 @@
+package ocr
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go-ocr/infrastructure/httplib"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+
+	"github.com/gin-gonic/gin"
+)
+
+// nextCursor reads the cursor back like a next request does, the cursor must get through its length check
+func nextCursor(t *testing.T, cursor string) *primitive.OcrCursor {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/ocr?cursor="+url.QueryEscape(cursor), nil)
+	query, err := httplib.GetCursorFromCtx(c)
+	if err != nil {
+		t.Fatalf("GetCursorFromCtx() with a cursor of %d bytes error = %v", len(cursor), err)
+	}
+	decoded, err := DecodeCursor(query.Cursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	return decoded
+}
+
+func TestListOcrCursorLongText(t *testing.T) {
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	repo := NewInMemoryRepository()
+	// texts far longer than a cursor may be, their order is the one of the letter
+	letters := []string{"d", "a", "e", "c", "b"}
+	for _, letter := range letters {
+		if _, err := repo.CreateOcr(ctx, primitive.Ocr{TenantID: "acme", Text: strings.Repeat(letter, 10000)}); err != nil {
+			t.Fatalf("CreateOcr() error = %v", err)
+		}
+	}
+	service := &Service{repository: repo}
+
+	tests := []struct {
+		name      string
+		sortOrder string
+		want      string
+	}{
+		{name: "text asc", sortOrder: "asc", want: "abcde"},
+		{name: "text desc", sortOrder: "desc", want: "edcba"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := primitive.ParameterFindOcr{SortBy: "text", SortOrder: tt.sortOrder, PageSize: 2}
+			got, pages := "", 0
+			var last primitive.OcrCursorPage
+			for {
+				page, err := service.ListOcrCursor(ctx, param)
+				if err != nil {
+					t.Fatalf("ListOcrCursor() error = %v", err)
+				}
+				for _, data := range page.Data {
+					got += data.Text[:1]
+				}
+				pages++
+				last = page
+				if page.NextCursor == "" || pages > len(letters) {
+					break
+				}
+				param.Cursor = nextCursor(t, page.NextCursor)
+				param.SortBy, param.SortOrder = param.Cursor.SortBy, param.Cursor.SortOrder
+			}
+			if got != tt.want || pages != 3 {
+				t.Fatalf("got %q in %d pages, want %q in 3 pages", got, pages, tt.want)
+			}
+
+			// the previous page of the last one goes back to the middle
+			param.Cursor = nextCursor(t, last.PrevCursor)
+			page, err := service.ListOcrCursor(ctx, param)
+			if err != nil {
+				t.Fatalf("ListOcrCursor() backward error = %v", err)
+			}
+			back := ""
+			for _, data := range page.Data {
+				back += data.Text[:1]
+			}
+			if back != tt.want[2:4] {
+				t.Fatalf("got %q on the previous page, want %q", back, tt.want[2:4])
+			}
+		})
+	}
+}
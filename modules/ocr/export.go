@@ -0,0 +1,428 @@
+package ocr
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/config"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/infrastructure/tracing"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	exportDownloadPath = "/api/v1/ocr/exports/%s/download"
+	// exportRetention is how long a finished export and its artifact are kept
+	exportRetention = 24 * time.Hour
+)
+
+// exportExtensions are the extensions of the artifacts, they are the accepted formats too
+var exportExtensions = map[string]string{
+	primitive.ExportFormatCsv:    ".csv",
+	primitive.ExportFormatNdjson: ".ndjson",
+	primitive.ExportFormatZip:    ".zip",
+}
+
+// exportCsvHeader are the columns of the csv export
+var exportCsvHeader = []string{"id", "tenant_id", "image_url", "text", "status",
+	"document_type", "language", "confidence", "tags", "content_hash", "fields", "created_by", "created_at", "updated_at"}
+
+// exportManifestEntry is a record of the zip manifest, image is its path inside the archive
+type exportManifestEntry struct {
+	primitive.OCrResponse
+	Image string `json:"image,omitempty"`
+}
+
+// IsValidExportFormat reports whether the format is one of csv, ndjson or zip
+func IsValidExportFormat(format string) bool {
+	_, ok := exportExtensions[format]
+	return ok
+}
+
+// ExportOcr writes every record of the tenant matching the filters to w in the given format,
+// the records are read one at a time so the result set is never held in memory
+func (s *Service) ExportOcr(ctx context.Context, param primitive.ParameterFindOcr, format string, w io.Writer) (rows int64, err error) {
+	logCtx := fmt.Sprintf("service.ExportOcr")
+
+	ctx, span := tracing.Start(ctx, "Service.ExportOcr", attribute.String("export.format", format))
+	defer func() {
+		tracing.EndWithError(span, err)
+	}()
+
+	if !IsValidExportFormat(format) {
+		return 0, primitive.ErrorExportFormatNotValid
+	}
+	param.TenantID = tenant.FromContext(ctx)
+
+	switch format {
+	case primitive.ExportFormatCsv:
+		rows, err = s.exportCsv(ctx, param, w)
+	case primitive.ExportFormatNdjson:
+		rows, err = s.exportNdjson(ctx, param, w)
+	case primitive.ExportFormatZip:
+		rows, err = s.exportZip(ctx, param, w)
+	}
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.export")
+		return rows, err
+	}
+
+	span.SetAttributes(attribute.Int64("export.rows", rows))
+	return rows, nil
+}
+
+func (s *Service) exportCsv(ctx context.Context, param primitive.ParameterFindOcr, w io.Writer) (rows int64, err error) {
+	writer := csv.NewWriter(w)
+	if err = writer.Write(exportCsvHeader); err != nil {
+		return 0, err
+	}
+
+	err = s.repository.EachOcr(ctx, param, func(data primitive.Ocr) error {
+		rows++
+		return writer.Write([]string{
+			strconv.FormatInt(data.ID, 10),
+			data.TenantID,
+			csvCell(data.ImageUrl),
+			csvCell(data.Text),
+			data.Status,
+			csvCell(data.DocumentType),
+			data.Language,
+			strconv.FormatFloat(data.Confidence, 'f', 2, 64),
+			data.Tags,
+			data.ContentHash,
+			csvCell(data.Fields),
+			csvCell(data.CreatedBy),
+			data.CreatedAt.UTC().Format(time.RFC3339Nano),
+			data.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		})
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	writer.Flush()
+	return rows, writer.Error()
+}
+
+// csvCell keeps a spreadsheet from evaluating the recognized text as a formula
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (s *Service) exportNdjson(ctx context.Context, param primitive.ParameterFindOcr, w io.Writer) (rows int64, err error) {
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+
+	err = s.repository.EachOcr(ctx, param, func(data primitive.Ocr) error {
+		rows++
+		return encoder.Encode(toOcrResponse(data))
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	return rows, buffered.Flush()
+}
+
+// exportZip writes the images under images/ and a manifest.json of the records, the manifest
+// is built in a temporary file while the images are written
+func (s *Service) exportZip(ctx context.Context, param primitive.ParameterFindOcr, w io.Writer) (rows int64, err error) {
+	logCtx := fmt.Sprintf("service.exportZip")
+
+	manifest, err := os.CreateTemp("", "ocr-export-manifest-*.json")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		manifest.Close()
+		_ = os.Remove(manifest.Name())
+	}()
+
+	manifestWriter := bufio.NewWriter(manifest)
+	if _, err = manifestWriter.WriteString("["); err != nil {
+		return 0, err
+	}
+
+	archive := zip.NewWriter(w)
+	err = s.repository.EachOcr(ctx, param, func(data primitive.Ocr) error {
+		entry := exportManifestEntry{OCrResponse: toOcrResponse(data)}
+
+		image, errOpen := os.Open(data.ImageUrl)
+		if errOpen == nil {
+			entry.Image = fmt.Sprintf("images/%d%s", data.ID, strings.ToLower(filepath.Ext(data.ImageUrl)))
+			errCopy := copyToZip(archive, entry.Image, data.CreatedAt, image)
+			image.Close()
+			if errCopy != nil {
+				return errCopy
+			}
+		} else {
+			// the record is exported without its image, like after the upload dir was cleaned
+			logger.Debug(ctx, logCtx, "image of record %d is not readable: %v", data.ID, errOpen)
+		}
+
+		if rows > 0 {
+			if _, errWrite := manifestWriter.WriteString(","); errWrite != nil {
+				return errWrite
+			}
+		}
+		rows++
+		line, errMarshal := json.Marshal(entry)
+		if errMarshal != nil {
+			return errMarshal
+		}
+		_, errWrite := manifestWriter.Write(line)
+		return errWrite
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	if _, err = manifestWriter.WriteString("]\n"); err != nil {
+		return rows, err
+	}
+	if err = manifestWriter.Flush(); err != nil {
+		return rows, err
+	}
+	if _, err = manifest.Seek(0, io.SeekStart); err != nil {
+		return rows, err
+	}
+	if err = copyToZip(archive, "manifest.json", time.Now(), manifest); err != nil {
+		return rows, err
+	}
+
+	return rows, archive.Close()
+}
+
+func copyToZip(archive *zip.Writer, name string, modified time.Time, content io.Reader) error {
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, content)
+	return err
+}
+
+// CreateExportOcr starts an export in the background, the artifact is downloaded once it succeeded
+func (s *Service) CreateExportOcr(ctx context.Context, param primitive.ParameterFindOcr, format string) (primitive.OcrExportResponse, error) {
+	logCtx := fmt.Sprintf("service.CreateExportOcr")
+
+	if !IsValidExportFormat(format) {
+		return primitive.OcrExportResponse{}, primitive.ErrorExportFormatNotValid
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "newRandomID")
+		return primitive.OcrExportResponse{}, err
+	}
+
+	export := &primitive.OcrExport{
+		ID:        id,
+		TenantID:  tenant.FromContext(ctx),
+		Format:    format,
+		Status:    primitive.ExportStatusPending,
+		CreatedAt: time.Now(),
+	}
+	if identity, ok := auth.FromContext(ctx); ok {
+		export.CreatedBy = identity.String()
+	}
+
+	s.exportsMu.Lock()
+	s.exports[id] = export
+	response := toOcrExportResponse(*export)
+	s.exportsMu.Unlock()
+
+	err = s.startJob(ctx, id, func(ctx context.Context) {
+		s.runExport(ctx, id, param)
+	})
+	if err != nil {
+		s.exportsMu.Lock()
+		delete(s.exports, id)
+		s.exportsMu.Unlock()
+		return primitive.OcrExportResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (s *Service) runExport(ctx context.Context, id string, param primitive.ParameterFindOcr) {
+	logCtx := fmt.Sprintf("service.runExport")
+
+	s.exportsMu.Lock()
+	export := s.exports[id]
+	export.Status = primitive.ExportStatusRunning
+	format := export.Format
+	s.exportsMu.Unlock()
+
+	path, rows, size, err := s.writeExport(ctx, id, param, format)
+
+	s.exportsMu.Lock()
+	defer s.exportsMu.Unlock()
+	export.FinishedAt = time.Now()
+	export.Rows = rows
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.writeExport")
+		export.Status = primitive.ExportStatusFailed
+		export.Error = err.Error()
+		// canceled by the drain of the shutdown
+		if errors.Is(err, context.Canceled) {
+			export.Error = primitive.ServerIsShuttingDown
+		}
+		return
+	}
+	export.Status = primitive.ExportStatusSuccessful
+	export.Path = path
+	export.Size = size
+}
+
+// writeExport writes the artifact next to its final path and renames it once complete,
+// so a partial artifact is never downloaded
+func (s *Service) writeExport(ctx context.Context, id string, param primitive.ParameterFindOcr, format string) (path string, rows int64, size int64, err error) {
+	// the export dir is not served, an artifact is only downloaded by the tenant through its export
+	dir := filepath.Join(config.Conf.ExportDir, tenant.FromContext(ctx))
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", 0, 0, err
+	}
+	path = filepath.Join(dir, id+exportExtensions[format])
+
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		return "", 0, 0, err
+	}
+	rows, err = s.ExportOcr(ctx, param, format, file)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", rows, 0, err
+	}
+
+	if err = os.Rename(file.Name(), path); err != nil {
+		_ = os.Remove(file.Name())
+		return "", rows, 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", rows, 0, err
+	}
+	return path, rows, info.Size(), nil
+}
+
+// GetExportOcr returns the state of an export of the tenant
+func (s *Service) GetExportOcr(ctx context.Context, id string) (primitive.OcrExportResponse, error) {
+	export, err := s.findExport(ctx, id)
+	if err != nil {
+		return primitive.OcrExportResponse{}, err
+	}
+	return toOcrExportResponse(export), nil
+}
+
+// OpenExportOcr returns the export of the tenant with the path of its artifact, it fails
+// with ErrorExportNotReady until the export succeeded
+func (s *Service) OpenExportOcr(ctx context.Context, id string) (primitive.OcrExport, error) {
+	export, err := s.findExport(ctx, id)
+	if err != nil {
+		return primitive.OcrExport{}, err
+	}
+	if export.Status != primitive.ExportStatusSuccessful {
+		return primitive.OcrExport{}, primitive.ErrorExportNotReady
+	}
+	return export, nil
+}
+
+func (s *Service) findExport(ctx context.Context, id string) (primitive.OcrExport, error) {
+	s.exportsMu.RLock()
+	defer s.exportsMu.RUnlock()
+
+	export, ok := s.exports[id]
+	if !ok || export.TenantID != tenant.FromContext(ctx) {
+		return primitive.OcrExport{}, primitive.ErrorExportNotFound
+	}
+	return *export, nil
+}
+
+// PurgeExpiredExports forgets the exports finished for longer than the retention and removes their artifact
+func (s *Service) PurgeExpiredExports(ctx context.Context) (count int64, err error) {
+	logCtx := fmt.Sprintf("service.PurgeExpiredExports")
+
+	s.exportsMu.Lock()
+	defer s.exportsMu.Unlock()
+
+	expiredBefore := time.Now().Add(-exportRetention)
+	for id, export := range s.exports {
+		if export.FinishedAt.IsZero() || export.FinishedAt.After(expiredBefore) {
+			continue
+		}
+		if export.Path != "" {
+			if errRemove := os.Remove(export.Path); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+				logger.Error(ctx, utils.ErrorLogFormat, errRemove.Error(), logCtx, "os.Remove")
+				err = errRemove
+				continue
+			}
+		}
+		delete(s.exports, id)
+		count++
+	}
+	return count, err
+}
+
+func toOcrExportResponse(export primitive.OcrExport) primitive.OcrExportResponse {
+	response := primitive.OcrExportResponse{
+		ID:        export.ID,
+		Format:    export.Format,
+		Status:    export.Status,
+		Rows:      export.Rows,
+		Size:      export.Size,
+		Error:     export.Error,
+		CreatedBy: export.CreatedBy,
+		CreatedAt: export.CreatedAt,
+	}
+	if !export.FinishedAt.IsZero() {
+		finishedAt := export.FinishedAt
+		response.FinishedAt = &finishedAt
+	}
+	if export.Status == primitive.ExportStatusSuccessful {
+		response.DownloadUrl = fmt.Sprintf(exportDownloadPath, export.ID)
+	}
+	return response
+}
+
+func toOcrResponse(data primitive.Ocr) primitive.OCrResponse {
+	return primitive.OCrResponse{
+		ID:           data.ID,
+		TenantID:     data.TenantID,
+		ImageUrl:     data.ImageUrl,
+		Text:         data.Text,
+		Status:       data.Status,
+		DocumentType: data.DocumentType,
+		Language:     data.Language,
+		Confidence:   data.Confidence,
+		Tags:         splitTags(data.Tags),
+		ContentHash:  data.ContentHash,
+		Fields:       decodeFields(data.Fields),
+		Zones:        decodeZones(data.Zones),
+		CreatedBy:    data.CreatedBy,
+		CreatedAt:    data.CreatedAt,
+		UpdatedAt:    data.UpdatedAt,
+	}
+}
@@ -0,0 +1,174 @@
+package ocr
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"go-ocr/infrastructure/events"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+)
+
+func TestCsvCell(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "empty", value: "", want: ""},
+		{name: "text", value: "invoice 42", want: "invoice 42"},
+		{name: "formula", value: "=SUM(A1:A2)", want: "'=SUM(A1:A2)"},
+		{name: "plus", value: "+1", want: "'+1"},
+		{name: "minus", value: "-1", want: "'-1"},
+		{name: "at", value: "@cmd", want: "'@cmd"},
+		{name: "tab", value: "\tvalue", want: "'\tvalue"},
+		{name: "carriage return", value: "\rvalue", want: "'\rvalue"},
+		{name: "sign inside the text", value: "a=b", want: "a=b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvCell(tt.value); got != tt.want {
+				t.Fatalf("csvCell(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportOcr(t *testing.T) {
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	service := &Service{repository: NewInMemoryRepository(), broker: events.NewInMemoryBroker(0, 0)}
+
+	imagePath := filepath.Join(t.TempDir(), "a.PNG")
+	if err := os.WriteFile(imagePath, []byte("image"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	texts := make(map[int64]string)
+	for _, data := range []primitive.Ocr{
+		{ImageUrl: imagePath, Text: "=HYPERLINK(\"x\")", TenantID: "acme"},
+		{ImageUrl: filepath.Join(t.TempDir(), "missing.png"), Text: "invoice", TenantID: "acme"},
+		{ImageUrl: "uploads/globex/c.png", Text: "other tenant", TenantID: "globex"},
+	} {
+		record, err := service.saveRecord(tenant.WithTenant(context.Background(), data.TenantID), data)
+		if err != nil {
+			t.Fatalf("saveRecord() error = %v", err)
+		}
+		if data.TenantID == "acme" {
+			texts[record.ID] = data.Text
+		}
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		check   func(t *testing.T, content []byte)
+		wantErr error
+	}{
+		{name: "csv", format: primitive.ExportFormatCsv, check: func(t *testing.T, content []byte) {
+			lines, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+			if err != nil {
+				t.Fatalf("read csv error = %v", err)
+			}
+			if len(lines) != len(texts)+1 || len(lines[0]) != len(exportCsvHeader) || lines[0][0] != "id" {
+				t.Fatalf("csv = %v, want the header and %d rows", lines, len(texts))
+			}
+			for _, line := range lines[1:] {
+				id, _ := strconv.ParseInt(line[0], 10, 64)
+				if line[1] != "acme" || line[3] != csvCell(texts[id]) {
+					t.Fatalf("csv row = %v, want the escaped text %q of acme", line, csvCell(texts[id]))
+				}
+			}
+		}},
+		{name: "ndjson", format: primitive.ExportFormatNdjson, check: func(t *testing.T, content []byte) {
+			var rows int
+			scanner := bufio.NewScanner(bytes.NewReader(content))
+			for scanner.Scan() {
+				var response primitive.OCrResponse
+				if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+					t.Fatalf("line %q error = %v", scanner.Text(), err)
+				}
+				// the json is not a spreadsheet, the text is kept as recognized
+				if response.TenantID != "acme" || response.Text != texts[response.ID] {
+					t.Fatalf("line = %+v, want the text %q of acme", response, texts[response.ID])
+				}
+				rows++
+			}
+			if rows != len(texts) {
+				t.Fatalf("ndjson got %d lines, want %d", rows, len(texts))
+			}
+		}},
+		{name: "zip", format: primitive.ExportFormatZip, check: func(t *testing.T, content []byte) {
+			archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+			if err != nil {
+				t.Fatalf("read zip error = %v", err)
+			}
+			files := make(map[string]*zip.File)
+			for _, file := range archive.File {
+				files[file.Name] = file
+			}
+			manifestFile, ok := files["manifest.json"]
+			if !ok {
+				t.Fatalf("zip = %v, want a manifest.json", files)
+			}
+			manifestReader, err := manifestFile.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer manifestReader.Close()
+			var manifest []exportManifestEntry
+			if err = json.NewDecoder(manifestReader).Decode(&manifest); err != nil {
+				t.Fatalf("read manifest error = %v", err)
+			}
+			if len(manifest) != len(texts) || len(files) != len(texts) {
+				t.Fatalf("zip got %d entries and %d files, want %d entries and an image", len(manifest), len(files), len(texts))
+			}
+			for _, entry := range manifest {
+				if entry.ImageUrl != imagePath {
+					// the record whose image is gone is exported without it
+					if entry.Image != "" {
+						t.Fatalf("entry = %+v, want no image", entry)
+					}
+					continue
+				}
+				if entry.Image != "images/"+strconv.FormatInt(entry.ID, 10)+".png" || files[entry.Image] == nil {
+					t.Fatalf("entry image = %q, want the image of the record in the zip", entry.Image)
+				}
+				image, err := files[entry.Image].Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				stored, _ := io.ReadAll(image)
+				image.Close()
+				if string(stored) != "image" {
+					t.Fatalf("image = %q, want %q", stored, "image")
+				}
+			}
+		}},
+		{name: "unknown format", format: "xlsx", wantErr: primitive.ErrorExportFormatNotValid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var content bytes.Buffer
+			rows, err := service.ExportOcr(ctx, primitive.ParameterFindOcr{}, tt.format, &content)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExportOcr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if rows != int64(len(texts)) {
+				t.Fatalf("ExportOcr() rows = %d, want %d", rows, len(texts))
+			}
+			tt.check(t, content.Bytes())
+		})
+	}
+}
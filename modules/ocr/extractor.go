@@ -0,0 +1,91 @@
+package ocr
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"go-ocr/modules/primitive"
+)
+
+// extractor finds a field in the plain text, the value is the first group of the pattern
+// or the whole match, last takes the last match like the total at the bottom of a receipt
+type extractor struct {
+	pattern *regexp.Regexp
+	last    bool
+}
+
+var extractors = map[string]extractor{
+	"date": {pattern: regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2}|\d{1,2}[./-]\d{1,2}[./-]\d{2,4})\b`)},
+	"total": {
+		pattern: regexp.MustCompile(`(?i)\b(?:grand\s+)?total\b[^0-9\n]{0,20}(\d[\d.,]*\d|\d)`),
+		last:    true,
+	},
+	"invoice_number": {pattern: regexp.MustCompile(`(?i)\binvoice\s*(?:no\.?|number|#)?\s*[:#]?\s*([A-Z0-9][A-Z0-9/-]{2,})`)},
+	"email":          {pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	"phone":          {pattern: regexp.MustCompile(`\+?\d[\d ()-]{6,}\d`)},
+	"id_number": {
+		pattern: regexp.MustCompile(`(?i)\b(?:id|nik|document|card)\s*(?:no\.?|number|#)?\s*[:#]?\s*([A-Z0-9][A-Z0-9-]{4,})`),
+	},
+}
+
+// extractFields runs the extractors on the plain text, the fields not found are left out
+func extractFields(text string, names []string) []primitive.OcrField {
+	fields := make([]primitive.OcrField, 0, len(names))
+	for _, name := range names {
+		extractor, ok := extractors[name]
+		if !ok {
+			continue
+		}
+		matches := extractor.pattern.FindAllStringSubmatch(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		match := matches[0]
+		if extractor.last {
+			match = matches[len(matches)-1]
+		}
+		value := match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+		fields = append(fields, primitive.OcrField{Name: name, Value: strings.TrimSpace(value)})
+	}
+	return fields
+}
+
+// encodeFields returns the fields as they are stored
+func encodeFields(fields []primitive.OcrField) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	raw, _ := json.Marshal(fields)
+	return string(raw)
+}
+
+// decodeFields returns the stored fields, never nil
+func decodeFields(raw string) []primitive.OcrField {
+	fields := make([]primitive.OcrField, 0)
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &fields)
+	}
+	return fields
+}
+
+// encodeWords returns the words as they are stored
+func encodeWords(words []primitive.OcrWord) string {
+	if len(words) == 0 {
+		return ""
+	}
+	raw, _ := json.Marshal(words)
+	return string(raw)
+}
+
+// decodeWords returns the stored words, never nil
+func decodeWords(raw string) []primitive.OcrWord {
+	words := make([]primitive.OcrWord, 0)
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &words)
+	}
+	return words
+}
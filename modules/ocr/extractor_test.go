@@ -0,0 +1,38 @@
+package ocr
+
+import (
+	"reflect"
+	"testing"
+
+	"go-ocr/modules/primitive"
+)
+
+func TestExtractFields(t *testing.T) {
+	receipt := "ACME Store\nInvoice No: INV-2024/001\nDate 12/03/2024\nSubtotal 9.00\nTotal: 10.50\n" +
+		"Grand Total 1,234.50\nbilling@acme.test\nCall +62 (21) 555-0100\nID No: AB12345"
+	tests := []struct {
+		name  string
+		text  string
+		names []string
+		want  []primitive.OcrField
+	}{
+		{name: "date", text: receipt, names: []string{"date"}, want: []primitive.OcrField{{Name: "date", Value: "12/03/2024"}}},
+		{name: "iso date", text: "issued 2024-03-12", names: []string{"date"}, want: []primitive.OcrField{{Name: "date", Value: "2024-03-12"}}},
+		{name: "last total", text: receipt, names: []string{"total"}, want: []primitive.OcrField{{Name: "total", Value: "1,234.50"}}},
+		{name: "invoice number", text: receipt, names: []string{"invoice_number"}, want: []primitive.OcrField{{Name: "invoice_number", Value: "INV-2024/001"}}},
+		{name: "email", text: receipt, names: []string{"email"}, want: []primitive.OcrField{{Name: "email", Value: "billing@acme.test"}}},
+		{name: "phone", text: receipt, names: []string{"phone"}, want: []primitive.OcrField{{Name: "phone", Value: "+62 (21) 555-0100"}}},
+		{name: "id number", text: receipt, names: []string{"id_number"}, want: []primitive.OcrField{{Name: "id_number", Value: "AB12345"}}},
+		{name: "order of the profile", text: receipt, names: []string{"email", "date"},
+			want: []primitive.OcrField{{Name: "email", Value: "billing@acme.test"}, {Name: "date", Value: "12/03/2024"}}},
+		{name: "not found left out", text: "no fields here", names: []string{"date", "total"}, want: []primitive.OcrField{}},
+		{name: "unknown skipped", text: receipt, names: []string{"unknown"}, want: []primitive.OcrField{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractFields(tt.text, tt.names); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("extractFields(%v) = %+v, want %+v", tt.names, got, tt.want)
+			}
+		})
+	}
+}
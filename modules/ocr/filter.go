@@ -0,0 +1,158 @@
+package ocr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+)
+
+const maxTags = 20
+
+var (
+	validTag         = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,63}$`)
+	validLanguage    = regexp.MustCompile(`^[A-Za-z0-9_]{1,32}$`)
+	validContentHash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	validFilterValue = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+)
+
+// SortableFields returns the accepted orderBy values without their aliases
+func SortableFields() []string {
+	fields := make([]string, 0, len(sortColumns))
+	for field, column := range sortColumns {
+		if field == column {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ValidateSortBy rejects an orderBy outside of the whitelist, empty is the default sort
+func ValidateSortBy(sortBy string) error {
+	if sortBy == "" {
+		return nil
+	}
+	if _, ok := sortColumns[strings.ToLower(sortBy)]; !ok {
+		return fmt.Errorf("orderBy %q is not sortable, use one of %s", sortBy, strings.Join(SortableFields(), ", "))
+	}
+	return nil
+}
+
+// ParseTags splits the comma separated tags, they are lower cased and deduplicated
+func ParseTags(value string) ([]string, error) {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utils.Contains(tags, tag) {
+			continue
+		}
+		if !validTag.MatchString(tag) {
+			return nil, fmt.Errorf("tag %q is not valid, use up to 64 letters, digits and _ . : -", tag)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	return tags, nil
+}
+
+// joinTags returns the tags as they are stored
+func joinTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+// splitTags returns the stored tags, never nil
+func splitTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+	return strings.Split(tags, ",")
+}
+
+// ParseStatuses splits the comma separated statuses of the given values
+func ParseStatuses(values []string) ([]string, error) {
+	var statuses []string
+	for _, value := range values {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				continue
+			}
+			if !validFilterValue.MatchString(status) {
+				return nil, fmt.Errorf("status %q is not valid", status)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// ParseDocumentType checks the document type filter, empty matches every type
+func ParseDocumentType(value string) (string, error) {
+	if value != "" && !validFilterValue.MatchString(value) {
+		return "", fmt.Errorf("documentType %q is not valid", value)
+	}
+	return value, nil
+}
+
+// ParseLanguage checks the language filter, a tesseract language code like eng
+func ParseLanguage(value string) (string, error) {
+	if value != "" && !validLanguage.MatchString(value) {
+		return "", fmt.Errorf("language %q is not a tesseract language like eng", value)
+	}
+	return value, nil
+}
+
+// ParseContentHash checks the content hash filter, the hex sha256 of the image
+func ParseContentHash(value string) (string, error) {
+	value = strings.ToLower(value)
+	if value != "" && !validContentHash.MatchString(value) {
+		return "", fmt.Errorf("contentHash %q is not a hex sha256", value)
+	}
+	return value, nil
+}
+
+// ParseTime parses a bound of a time range, empty is an open bound
+func ParseTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s %q is not a RFC 3339 time like 2024-01-31T15:04:05Z", name, value)
+	}
+	return parsed, nil
+}
+
+// ParseConfidence parses a bound of the confidence, empty is an open bound
+func ParseConfidence(name, value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	confidence, err := strconv.ParseFloat(value, 64)
+	if err != nil || confidence < 0 || confidence > 100 {
+		return nil, fmt.Errorf("%s %q must be a number between 0 and 100", name, value)
+	}
+	return &confidence, nil
+}
+
+// validateRanges rejects the ranges ending before they start
+func validateRanges(param primitive.ParameterFindOcr) error {
+	if !param.CreatedFrom.IsZero() && !param.CreatedTo.IsZero() && !param.CreatedFrom.Before(param.CreatedTo) {
+		return fmt.Errorf("createdFrom must be before createdTo")
+	}
+	if !param.UpdatedFrom.IsZero() && !param.UpdatedTo.IsZero() && !param.UpdatedFrom.Before(param.UpdatedTo) {
+		return fmt.Errorf("updatedFrom must be before updatedTo")
+	}
+	if param.MinConfidence != nil && param.MaxConfidence != nil && *param.MinConfidence > *param.MaxConfidence {
+		return fmt.Errorf("minConfidence must not be above maxConfidence")
+	}
+	return nil
+}
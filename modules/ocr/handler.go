@@ -1,21 +1,39 @@
 package ocr
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
+	"go-ocr/infrastructure/events"
 	"go-ocr/infrastructure/httplib"
 	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/infrastructure/tracing"
 	"go-ocr/infrastructure/validator"
 	"go-ocr/modules/primitive"
 	"go-ocr/utils"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// exportContentTypes are the media types of the export formats
+var exportContentTypes = map[string]string{
+	primitive.ExportFormatCsv:    "text/csv; charset=utf-8",
+	primitive.ExportFormatNdjson: "application/x-ndjson",
+	primitive.ExportFormatZip:    "application/zip",
+}
+
+// eventsHeartbeatInterval keeps the idle event streams open through the proxies
+const eventsHeartbeatInterval = 15 * time.Second
+
 type Http struct {
 	serviceOcr ServiceInterface
 }
@@ -28,17 +46,48 @@ func NewHttp(serviceOcr ServiceInterface) InterfaceHttp {
 
 type InterfaceHttp interface {
 	GroupOcr(group *gin.RouterGroup)
+	GroupUpload(group *gin.RouterGroup)
+	SaveToFile() (err error)
+	LoadFromFile() (err error)
 }
 
 func (h *Http) GroupOcr(g *gin.RouterGroup) {
 	g.POST("", h.ProcessOCR)
 	g.GET("", h.GetListOcr)
 	g.GET("/:id", h.DetailOCR)
+	g.GET("/:id/events", h.StreamOcrEvents)
+	g.GET("/export", h.ExportOcr)
+	g.POST("/exports", h.CreateExportOcr)
+	g.GET("/exports/:id", h.DetailExportOcr)
+	g.GET("/exports/:id/download", h.DownloadExportOcr)
+	g.POST("/batches", h.CreateBatchOcr)
+	g.GET("/batches/:id", h.DetailBatchOcr)
+	g.GET("/batches/:id/events", h.StreamBatchEvents)
+}
+
+// GroupUpload serves the uploaded images, the tenant of the path must be the one of the caller
+func (h *Http) GroupUpload(g *gin.RouterGroup) {
+	g.GET("/:tenant/:file", h.DownloadUpload)
+	g.HEAD("/:tenant/:file", h.DownloadUpload)
+}
+
+// SaveToFile is called by the listener on shutdown.
+func (h *Http) SaveToFile() (err error) {
+	return h.serviceOcr.SaveToFile(context.Background())
+}
+
+// LoadFromFile is called by the listener on start up.
+func (h *Http) LoadFromFile() (err error) {
+	return h.serviceOcr.LoadFromFile(context.Background())
 }
 
 func (h *Http) ProcessOCR(ctx *gin.Context) {
 	logCtx := fmt.Sprintf("handler.ProcessOCR")
 
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.ProcessOCR")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
 	var requestBody primitive.OcrRequest
 	if err := ctx.ShouldBind(&requestBody); err != nil {
 		logger.Error(ctx, logCtx, "ctx.ShouldBind got err : %v", err)
@@ -46,24 +95,32 @@ func (h *Http) ProcessOCR(ctx *gin.Context) {
 		return
 	}
 
-	errValidateStruct := validator.ValidateStructResponseSliceString(requestBody)
-	if errValidateStruct != nil {
-		logger.Error(ctx, logCtx, "validator.ValidateStructResponseSliceString got err : %v", errValidateStruct)
-		httplib.SetCustomResponse(ctx, http.StatusBadRequest, http.StatusText(http.StatusBadRequest), nil, errValidateStruct)
+	if !checkOcrRequest(ctx, logCtx, requestBody) {
 		return
 	}
 
 	// Get uploaded file
 	file, fileHeader, err := ctx.Request.FormFile("file")
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "ctx.Request.FormFile")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
 	defer file.Close()
 
+	maxUploadSize := tenant.SettingsFor(tenant.FromContext(ctx)).MaxUploadSize
+	if maxUploadSize > 0 && fileHeader.Size > maxUploadSize {
+		httplib.SetErrorResponse(ctx, http.StatusRequestEntityTooLarge, primitive.FileIsTooLarge)
+		return
+	}
+
 	response, err := h.serviceOcr.ProcessOcr(ctx, requestBody, file, fileHeader)
 	if err != nil {
 		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.ProcessOcr")
+		if errors.Is(err, primitive.ErrorImageNotPreprocessed) {
+			httplib.SetErrorResponse(ctx, http.StatusUnsupportedMediaType, err.Error())
+			return
+		}
 		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -72,55 +129,73 @@ func (h *Http) ProcessOCR(ctx *gin.Context) {
 	return
 }
 
+// checkOcrRequest rejects the form of a recognition that can't be processed, it writes the
+// response and returns false then
+func checkOcrRequest(ctx *gin.Context, logCtx string, requestBody primitive.OcrRequest) bool {
+	errValidateStruct := validator.ValidateStructResponseSliceString(requestBody)
+	if errValidateStruct != nil {
+		logger.Error(ctx, logCtx, "validator.ValidateStructResponseSliceString got err : %v", errValidateStruct)
+		httplib.SetCustomResponse(ctx, http.StatusBadRequest, http.StatusText(http.StatusBadRequest), nil, errValidateStruct)
+		return false
+	}
+
+	if _, err := ParseTags(requestBody.Tags); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "ParseTags")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if _, err := ProfileOptions(tenant.FromContext(ctx), requestBody.Type); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "ProfileOptions")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if _, err := ParseZones(requestBody.Zones); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "ParseZones")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if _, err := ParseFullText(requestBody.FullText); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "ParseFullText")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
 func (h *Http) GetListOcr(ctx *gin.Context) {
 	logCtx := fmt.Sprintf("handler.GetListOcr")
 
-	paginationQuery, err := httplib.GetPaginationFromCtx(ctx)
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.GetListOcr")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	filter, err := filterFromQuery(ctx)
 	if err != nil {
-		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "httplib.GetPaginationFromCtx")
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "filterFromQuery")
 		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	status := ctx.Request.URL.Query().Get("status")
-	if status != "" {
-		if !utils.IsValidSanitizeSQL(status) {
-			err = errors.New(primitive.QueryIsSuspicious)
-			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "utils.IsValidSanitizeSQL")
-			httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.QueryIsSuspicious)
-			return
-		}
+	disablePaginationHeader := utils.IsDisablePagination(ctx)
+	if !disablePaginationHeader && httplib.IsCursorPagination(ctx) {
+		h.getListOcrCursor(ctx, filter)
+		return
 	}
 
-	text := ctx.Request.URL.Query().Get("text")
-	if text != "" {
-		if !utils.IsValidSanitizeSQL(text) {
-			err = errors.New(primitive.QueryIsSuspicious)
-			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "utils.IsValidSanitizeSQL")
-			httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.QueryIsSuspicious)
-			return
-		}
+	paginationQuery, err := httplib.GetPaginationFromCtx(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "httplib.GetPaginationFromCtx")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	disablePaginationHeader := utils.IsDisablePagination(ctx)
-	var param primitive.ParameterFindOcr
-	if disablePaginationHeader {
-		param = primitive.ParameterFindOcr{
-			Text:   text,
-			Status: status,
-		}
-	} else {
-		param = primitive.ParameterFindOcr{
-			Text:      text,
-			Status:    status,
-			PageSize:  paginationQuery.GetSize(),
-			Offset:    paginationQuery.GetOffset(),
-			SortBy:    paginationQuery.GetOrderBy(),
-			SortOrder: paginationQuery.GetSortOrder(),
-		}
+	param := filter
+	if !disablePaginationHeader {
+		param.PageSize = paginationQuery.GetSize()
+		param.Offset = paginationQuery.GetOffset()
 	}
 
-	data, _, err := h.serviceOcr.ListOcr(ctx, disablePaginationHeader, param)
+	data, count, err := h.serviceOcr.ListOcr(ctx, disablePaginationHeader, param)
 	if err != nil {
 		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceArticle.GetListArticle")
 		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, err.Error())
@@ -131,15 +206,93 @@ func (h *Http) GetListOcr(ctx *gin.Context) {
 		httplib.SetSuccessResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), data)
 		return
 	} else {
-		httplib.SetPaginationResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), data, uint64(len(data)), paginationQuery)
+		httplib.SetLinkHeader(ctx, pageLinks(ctx, paginationQuery, count)...)
+		httplib.SetPaginationResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), data, uint64(count), paginationQuery)
+		return
+
+	}
+}
+
+// getListOcrCursor returns a keyset page of the list, the sort comes from the cursor after the first page
+func (h *Http) getListOcrCursor(ctx *gin.Context, param primitive.ParameterFindOcr) {
+	logCtx := fmt.Sprintf("handler.getListOcrCursor")
+
+	cursorQuery, err := httplib.GetCursorFromCtx(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "httplib.GetCursorFromCtx")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if cursorQuery.Cursor != "" {
+		param.Cursor, err = DecodeCursor(cursorQuery.Cursor)
+		if err != nil {
+			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "DecodeCursor")
+			httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		param.SortBy = param.Cursor.SortBy
+		param.SortOrder = param.Cursor.SortOrder
+	}
+	param.PageSize = cursorQuery.Limit
+
+	page, err := h.serviceOcr.ListOcrCursor(ctx.Request.Context(), param)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.ListOcrCursor")
+		if errors.Is(err, primitive.ErrorCursorNotValid) {
+			httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, err.Error())
 		return
+	}
 
+	// the cursor holds the sort, the links only need the filters
+	link := func(rel, cursor string) httplib.Link {
+		return httplib.LinkWithQuery(ctx, rel, map[string]string{
+			"cursor": cursor, "limit": strconv.Itoa(cursorQuery.Limit), "orderBy": "", "sortOrder": "",
+		})
 	}
+	var links []httplib.Link
+	if page.NextCursor != "" {
+		links = append(links, link("next", page.NextCursor))
+	}
+	if page.PrevCursor != "" {
+		links = append(links, link("prev", page.PrevCursor))
+	}
+	httplib.SetLinkHeader(ctx, links...)
+	httplib.SetCursorResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), page.Data, uint64(page.Total),
+		cursorQuery.Limit, page.NextCursor, page.PrevCursor)
+}
+
+// pageLinks returns the first, prev, next and last links of a page of the list
+func pageLinks(ctx *gin.Context, paginationQuery *httplib.Query, count int64) []httplib.Link {
+	lastPage := httplib.GetTotalPages(int(count), paginationQuery.GetSize())
+	if lastPage < 1 {
+		lastPage = 1
+	}
+	link := func(rel string, page int) httplib.Link {
+		return httplib.LinkWithQuery(ctx, rel, map[string]string{
+			"page": strconv.Itoa(page), "size": strconv.Itoa(paginationQuery.GetSize()),
+		})
+	}
+
+	links := []httplib.Link{link("first", 1)}
+	if paginationQuery.GetPage() > 1 {
+		links = append(links, link("prev", min(paginationQuery.GetPage()-1, lastPage)))
+	}
+	if paginationQuery.GetPage() < lastPage {
+		links = append(links, link("next", paginationQuery.GetPage()+1))
+	}
+	return append(links, link("last", lastPage))
 }
 
 func (h *Http) DetailOCR(ctx *gin.Context) {
 	logCtx := fmt.Sprintf("handler.DetailOCR")
 
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.DetailOCR")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
 	idParam := ctx.Param("id")
 	if idParam == "" {
 		err := errors.New(primitive.ParamIdIsZeroOrNullString)
@@ -158,7 +311,7 @@ func (h *Http) DetailOCR(ctx *gin.Context) {
 
 	data, err := h.serviceOcr.GetRecordOcrById(ctx, idInt)
 	if err != nil {
-		errNotFound := []error{gorm.ErrRecordNotFound}
+		errNotFound := []error{gorm.ErrRecordNotFound, primitive.ErrorArticleNotFound}
 		if utils.ContainsError(err, errNotFound) {
 			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceServices.GetRecordServicesById")
 			httplib.SetErrorResponse(ctx, http.StatusNotFound, err.Error())
@@ -173,3 +326,399 @@ func (h *Http) DetailOCR(ctx *gin.Context) {
 	return
 
 }
+
+// StreamOcrEvents replay the status and result events of the record as server sent
+// events, the Last-Event-ID header resumes after the given event
+func (h *Http) StreamOcrEvents(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.StreamOcrEvents")
+
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.StreamOcrEvents")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	idInt, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || idInt == 0 {
+		err := errors.New(primitive.ParamIdIsZeroOrNullString)
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "strconv.ParseInt")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.ParamIdIsZeroOrNullString)
+		return
+	}
+
+	lastEventID, err := lastEventIDFromHeader(ctx)
+	if err != nil {
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stream, err := h.serviceOcr.SubscribeOcrEvents(ctx.Request.Context(), idInt, lastEventID)
+	if err != nil {
+		// no content tells the event source to stop reconnecting
+		if errors.Is(err, primitive.ErrorNoMoreEvents) {
+			ctx.Status(http.StatusNoContent)
+			return
+		}
+		errNotFound := []error{gorm.ErrRecordNotFound, primitive.ErrorArticleNotFound}
+		if utils.ContainsError(err, errNotFound) {
+			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.SubscribeOcrEvents")
+			httplib.SetErrorResponse(ctx, http.StatusNotFound, err.Error())
+			return
+		}
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.SubscribeOcrEvents")
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	streamEvents(ctx, stream, func(event events.Event) bool {
+		return event.Type == events.TypeResult
+	})
+}
+
+// streamEvents writes the events as Server-Sent Events with a heartbeat while idle, the stream
+// ends after the last event of the job or when the broker closes it
+func streamEvents(ctx *gin.Context, stream <-chan events.Event, last func(event events.Event) bool) {
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			_, errWrite := io.WriteString(w, ": heartbeat\n\n")
+			return errWrite == nil
+		case event, ok := <-stream:
+			// the broker closed the stream, the client reconnects with its last event id
+			if !ok {
+				return false
+			}
+			errRender := sse.Encode(w, sse.Event{
+				Id:    strconv.FormatInt(event.ID, 10),
+				Event: event.Type,
+				Data:  event.Data,
+			})
+			if errRender != nil {
+				return false
+			}
+			return !last(event)
+		}
+	})
+}
+
+// lastEventIDFromHeader returns the id of the last event the client received, zero on the first connection
+func lastEventIDFromHeader(ctx *gin.Context) (int64, error) {
+	header := ctx.GetHeader("Last-Event-ID")
+	if header == "" {
+		return 0, nil
+	}
+	lastEventID, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || lastEventID < 0 {
+		return 0, errors.New("Last-Event-ID must be a positive number")
+	}
+	return lastEventID, nil
+}
+
+// CreateBatchOcr starts the recognition of the files in the background with the options of the form,
+// every file is a record of its own once it is recognized
+func (h *Http) CreateBatchOcr(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.CreateBatchOcr")
+
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.CreateBatchOcr")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	var requestBody primitive.OcrRequest
+	if err := ctx.ShouldBind(&requestBody); err != nil {
+		logger.Error(ctx, logCtx, "ctx.ShouldBind got err : %v", err)
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.SomethingWrongWithTheBodyRequest)
+		return
+	}
+
+	if !checkOcrRequest(ctx, logCtx, requestBody) {
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "ctx.MultipartForm")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	files := form.File["files"]
+	if len(files) == 0 {
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.BatchHasNoFile)
+		return
+	}
+	if len(files) > MaxBatchFiles {
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("%s, at most %d", primitive.BatchHasTooManyFiles, MaxBatchFiles))
+		return
+	}
+
+	maxUploadSize := tenant.SettingsFor(tenant.FromContext(ctx)).MaxUploadSize
+	for _, fileHeader := range files {
+		if maxUploadSize > 0 && fileHeader.Size > maxUploadSize {
+			httplib.SetErrorResponse(ctx, http.StatusRequestEntityTooLarge, primitive.FileIsTooLarge)
+			return
+		}
+	}
+
+	data, err := h.serviceOcr.CreateBatchOcr(ctx.Request.Context(), requestBody, files)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.CreateBatchOcr")
+		if errors.Is(err, primitive.ErrorShuttingDown) {
+			httplib.SetErrorResponse(ctx, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.Header("Location", "/api/v1/ocr/batches/"+data.ID)
+	httplib.SetSuccessResponse(ctx, http.StatusAccepted, primitive.CreateBatchSuccess, data)
+}
+
+func (h *Http) DetailBatchOcr(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.DetailBatchOcr")
+
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.DetailBatchOcr")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	data, err := h.serviceOcr.GetBatchOcr(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.GetBatchOcr")
+		httplib.SetErrorResponse(ctx, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), data)
+}
+
+// StreamBatchEvents stream the status, progress and per file result events of the batch as
+// Server-Sent Events, the stream ends with the final status of the batch
+func (h *Http) StreamBatchEvents(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.StreamBatchEvents")
+
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.StreamBatchEvents")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	lastEventID, err := lastEventIDFromHeader(ctx)
+	if err != nil {
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stream, err := h.serviceOcr.SubscribeBatchEvents(ctx.Request.Context(), ctx.Param("id"), lastEventID)
+	if err != nil {
+		// no content tells the event source to stop reconnecting
+		if errors.Is(err, primitive.ErrorNoMoreEvents) {
+			ctx.Status(http.StatusNoContent)
+			return
+		}
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.SubscribeBatchEvents")
+		if errors.Is(err, primitive.ErrorBatchNotFound) {
+			httplib.SetErrorResponse(ctx, http.StatusNotFound, err.Error())
+			return
+		}
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	streamEvents(ctx, stream, func(event events.Event) bool {
+		if event.Type != events.TypeStatus {
+			return false
+		}
+		var state primitive.OcrBatchResponse
+		return json.Unmarshal(event.Data, &state) == nil && isBatchFinished(state.Status)
+	})
+}
+
+// ExportOcr streams every record matching the filters of the list as csv, ndjson or zip
+func (h *Http) ExportOcr(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.ExportOcr")
+
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.ExportOcr")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	format := ctx.Query("format")
+	if !IsValidExportFormat(format) {
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.ExportFormatIsNotValid)
+		return
+	}
+	param, err := filterFromQuery(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "filterFromQuery")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// the status is sent with the first row, an error after it can only cut the stream
+	ctx.Header("Content-Type", exportContentTypes[format])
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ocr-%s%s"`,
+		time.Now().UTC().Format("20060102T150405Z"), exportExtensions[format]))
+	ctx.Status(http.StatusOK)
+
+	if _, err = h.serviceOcr.ExportOcr(ctx.Request.Context(), param, format, ctx.Writer); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.ExportOcr")
+		_ = ctx.Error(err)
+		ctx.Abort()
+	}
+}
+
+// CreateExportOcr starts an export of the records matching the filters in the background
+func (h *Http) CreateExportOcr(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.CreateExportOcr")
+
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.CreateExportOcr")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	format := ctx.Query("format")
+	if !IsValidExportFormat(format) {
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.ExportFormatIsNotValid)
+		return
+	}
+	param, err := filterFromQuery(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "filterFromQuery")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.serviceOcr.CreateExportOcr(ctx.Request.Context(), param, format)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.CreateExportOcr")
+		if errors.Is(err, primitive.ErrorShuttingDown) {
+			httplib.SetErrorResponse(ctx, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.Header("Location", "/api/v1/ocr/exports/"+data.ID)
+	httplib.SetSuccessResponse(ctx, http.StatusAccepted, primitive.CreateExportSuccess, data)
+}
+
+func (h *Http) DetailExportOcr(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.DetailExportOcr")
+
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.DetailExportOcr")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	data, err := h.serviceOcr.GetExportOcr(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.GetExportOcr")
+		httplib.SetErrorResponse(ctx, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), data)
+}
+
+func (h *Http) DownloadExportOcr(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.DownloadExportOcr")
+
+	spanCtx, span := tracing.Start(ctx.Request.Context(), "Http.DownloadExportOcr")
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	export, err := h.serviceOcr.OpenExportOcr(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceOcr.OpenExportOcr")
+		if errors.Is(err, primitive.ErrorExportNotReady) {
+			httplib.SetErrorResponse(ctx, http.StatusConflict, err.Error())
+			return
+		}
+		httplib.SetErrorResponse(ctx, http.StatusNotFound, err.Error())
+		return
+	}
+
+	ctx.Header("Content-Type", exportContentTypes[export.Format])
+	ctx.FileAttachment(export.Path, "ocr-"+export.ID+exportExtensions[export.Format])
+}
+
+// DownloadUpload serves an uploaded image of the tenant, the image of another tenant is not found
+func (h *Http) DownloadUpload(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.DownloadUpload")
+
+	if ctx.Param("tenant") != tenant.FromContext(ctx) {
+		httplib.SetErrorResponse(ctx, http.StatusNotFound, primitive.ErrUploadNotFound)
+		return
+	}
+
+	filePath, err := h.serviceOcr.UploadPath(ctx.Request.Context(), ctx.Param("file"))
+	if err != nil {
+		logger.Debug(ctx, logCtx, "h.serviceOcr.UploadPath got err : %v", err)
+		httplib.SetErrorResponse(ctx, http.StatusNotFound, primitive.ErrUploadNotFound)
+		return
+	}
+
+	ctx.File(filePath)
+}
+
+// filterFromQuery returns the filters and the sort shared by the list and the exports, the
+// unknown sort fields and the malformed filters are rejected with the reason
+func filterFromQuery(ctx *gin.Context) (param primitive.ParameterFindOcr, err error) {
+	param.Text = ctx.Query("text")
+	if param.Text != "" && !utils.IsValidSanitizeSQL(param.Text) {
+		return param, errors.New(primitive.QueryIsSuspicious)
+	}
+	if param.Statuses, err = ParseStatuses(ctx.QueryArray("status")); err != nil {
+		return param, err
+	}
+	if param.DocumentType, err = ParseDocumentType(ctx.Query("documentType")); err != nil {
+		return param, err
+	}
+	if param.Language, err = ParseLanguage(ctx.Query("language")); err != nil {
+		return param, err
+	}
+	if param.Tags, err = ParseTags(ctx.Query("tags")); err != nil {
+		return param, err
+	}
+	if param.ContentHash, err = ParseContentHash(ctx.Query("contentHash")); err != nil {
+		return param, err
+	}
+
+	bounds := []struct {
+		name  string
+		bound *time.Time
+	}{
+		{"createdFrom", &param.CreatedFrom},
+		{"createdTo", &param.CreatedTo},
+		{"updatedFrom", &param.UpdatedFrom},
+		{"updatedTo", &param.UpdatedTo},
+	}
+	for _, query := range bounds {
+		if *query.bound, err = ParseTime(query.name, ctx.Query(query.name)); err != nil {
+			return param, err
+		}
+	}
+	if param.MinConfidence, err = ParseConfidence("minConfidence", ctx.Query("minConfidence")); err != nil {
+		return param, err
+	}
+	if param.MaxConfidence, err = ParseConfidence("maxConfidence", ctx.Query("maxConfidence")); err != nil {
+		return param, err
+	}
+	if err = validateRanges(param); err != nil {
+		return param, err
+	}
+
+	if err = ValidateSortBy(ctx.Query("orderBy")); err != nil {
+		return param, err
+	}
+	paginationQuery := &httplib.Query{}
+	paginationQuery.SetOrderBy(ctx.Query("orderBy"))
+	paginationQuery.SetSortOrder(ctx.Query("sortOrder"))
+	param.SortBy = paginationQuery.GetOrderBy()
+	param.SortOrder = paginationQuery.GetSortOrder()
+	return param, nil
+}
@@ -0,0 +1,316 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	ocrv1 "go-ocr/api/ocr/v1"
+	"go-ocr/infrastructure/httplib"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/infrastructure/tracing"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// errUploadTooLarge stops the stream of a file larger than the tenant max upload size
+var errUploadTooLarge = errors.New(primitive.FileIsTooLarge)
+
+type Grpc struct {
+	ocrv1.UnimplementedOcrServiceServer
+	serviceOcr ServiceInterface
+}
+
+func NewGrpc(serviceOcr ServiceInterface) InterfaceGrpc {
+	return &Grpc{
+		serviceOcr: serviceOcr,
+	}
+}
+
+type InterfaceGrpc interface {
+	ocrv1.OcrServiceServer
+	RegisterOcr(server grpc.ServiceRegistrar)
+}
+
+func (g *Grpc) RegisterOcr(server grpc.ServiceRegistrar) {
+	ocrv1.RegisterOcrServiceServer(server, g)
+}
+
+func (g *Grpc) Recognize(ctx context.Context, request *ocrv1.RecognizeRequest) (*ocrv1.OcrResult, error) {
+	logCtx := fmt.Sprintf("handler.grpc.Recognize")
+
+	ctx, span := tracing.Start(ctx, "Grpc.Recognize")
+	defer span.End()
+
+	fileName, err := imageFileName(request.GetFileName())
+	if err != nil {
+		return nil, err
+	}
+	if len(request.GetImage()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image is required")
+	}
+	maxUploadSize := tenant.SettingsFor(tenant.FromContext(ctx)).MaxUploadSize
+	if maxUploadSize > 0 && int64(len(request.GetImage())) > maxUploadSize {
+		return nil, status.Error(codes.ResourceExhausted, primitive.FileIsTooLarge)
+	}
+
+	payload, err := recognizePayload(ctx, request.GetHocrEnabled(), request.GetType(), request.GetZones(), request.GetFullText())
+	if err != nil {
+		return nil, err
+	}
+	response, err := g.serviceOcr.ProcessImage(ctx, payload, fileName, bytes.NewReader(request.GetImage()))
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "g.serviceOcr.ProcessImage")
+		return nil, recognizeError(err)
+	}
+
+	return toOcrResult(response), nil
+}
+
+func (g *Grpc) RecognizeStream(stream grpc.ClientStreamingServer[ocrv1.RecognizeChunk, ocrv1.OcrResult]) error {
+	logCtx := fmt.Sprintf("handler.grpc.RecognizeStream")
+
+	ctx, span := tracing.Start(stream.Context(), "Grpc.RecognizeStream")
+	defer span.End()
+
+	first, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return status.Error(codes.InvalidArgument, "image is required")
+		}
+		return err
+	}
+	fileName, err := imageFileName(first.GetFileName())
+	if err != nil {
+		return err
+	}
+	payload, err := recognizePayload(ctx, first.GetHocrEnabled(), first.GetType(), first.GetZones(), first.GetFullText())
+	if err != nil {
+		return err
+	}
+
+	// the chunks are written to the service while they are received, the whole
+	// file is never kept in memory
+	maxUploadSize := tenant.SettingsFor(tenant.FromContext(ctx)).MaxUploadSize
+	reader, writer := io.Pipe()
+	go func() {
+		var size int64
+		chunk := first
+		for {
+			size += int64(len(chunk.GetData()))
+			if maxUploadSize > 0 && size > maxUploadSize {
+				writer.CloseWithError(errUploadTooLarge)
+				return
+			}
+			if _, errWrite := writer.Write(chunk.GetData()); errWrite != nil {
+				return
+			}
+
+			var errRecv error
+			chunk, errRecv = stream.Recv()
+			if errors.Is(errRecv, io.EOF) {
+				writer.Close()
+				return
+			}
+			if errRecv != nil {
+				writer.CloseWithError(errRecv)
+				return
+			}
+		}
+	}()
+
+	response, err := g.serviceOcr.ProcessImage(ctx, payload, fileName, reader)
+	// unblock the writer when the service stopped before reading everything
+	reader.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		if errors.Is(err, errUploadTooLarge) {
+			return status.Error(codes.ResourceExhausted, primitive.FileIsTooLarge)
+		}
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "g.serviceOcr.ProcessImage")
+		return recognizeError(err)
+	}
+
+	return stream.SendAndClose(toOcrResult(response))
+}
+
+func (g *Grpc) GetResult(ctx context.Context, request *ocrv1.GetResultRequest) (*ocrv1.OcrResult, error) {
+	logCtx := fmt.Sprintf("handler.grpc.GetResult")
+
+	ctx, span := tracing.Start(ctx, "Grpc.GetResult")
+	defer span.End()
+
+	if request.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, primitive.ParamIdIsZeroOrNullString)
+	}
+
+	data, err := g.serviceOcr.GetRecordOcrById(ctx, request.GetId())
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "g.serviceOcr.GetRecordOcrById")
+		errNotFound := []error{gorm.ErrRecordNotFound, primitive.ErrorArticleNotFound}
+		if utils.ContainsError(err, errNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return toOcrResult(data), nil
+}
+
+func (g *Grpc) ListResults(ctx context.Context, request *ocrv1.ListResultsRequest) (*ocrv1.ListResultsResponse, error) {
+	logCtx := fmt.Sprintf("handler.grpc.ListResults")
+
+	ctx, span := tracing.Start(ctx, "Grpc.ListResults")
+	defer span.End()
+
+	if request.GetText() != "" && !utils.IsValidSanitizeSQL(request.GetText()) {
+		return nil, status.Error(codes.InvalidArgument, primitive.QueryIsSuspicious)
+	}
+	statuses, err := ParseStatuses([]string{request.GetStatus()})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	documentType, err := ParseDocumentType(request.GetDocumentType())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = ValidateSortBy(request.GetSortBy()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if request.GetPage() < 0 || request.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page and page size can't be negative")
+	}
+
+	paginationQuery := &httplib.Query{Page: int(request.GetPage()), Size: int(request.GetPageSize())}
+	if paginationQuery.Size == 0 {
+		_ = paginationQuery.SetSize("")
+	}
+	paginationQuery.SetOrderBy(request.GetSortBy())
+	paginationQuery.SetSortOrder(request.GetSortOrder())
+
+	param := primitive.ParameterFindOcr{
+		Text:         request.GetText(),
+		Statuses:     statuses,
+		DocumentType: documentType,
+		PageSize:     paginationQuery.GetSize(),
+		Offset:       paginationQuery.GetOffset(),
+		SortBy:       paginationQuery.GetOrderBy(),
+		SortOrder:    paginationQuery.GetSortOrder(),
+	}
+
+	data, count, err := g.serviceOcr.ListOcr(ctx, false, param)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "g.serviceOcr.ListOcr")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &ocrv1.ListResultsResponse{
+		Results: make([]*ocrv1.OcrResult, 0, len(data)),
+		Total:   count,
+	}
+	for _, item := range data {
+		response.Results = append(response.Results, toOcrResult(item))
+	}
+	return response, nil
+}
+
+// imageFileName keeps only the base name, the file is saved under the upload dir of the tenant
+func imageFileName(fileName string) (string, error) {
+	name := filepath.Base(fileName)
+	if fileName == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", status.Error(codes.InvalidArgument, "file name is required")
+	}
+	return name, nil
+}
+
+func toOcrResult(data primitive.OCrResponse) *ocrv1.OcrResult {
+	result := &ocrv1.OcrResult{
+		Id:           data.ID,
+		TenantId:     data.TenantID,
+		ImageUrl:     data.ImageUrl,
+		Text:         data.Text,
+		Status:       data.Status,
+		CreatedBy:    data.CreatedBy,
+		CreatedAt:    timestamppb.New(data.CreatedAt),
+		UpdatedAt:    timestamppb.New(data.UpdatedAt),
+		DocumentType: data.DocumentType,
+	}
+	for _, field := range data.Fields {
+		resultField := &ocrv1.OcrField{Name: field.Name, Value: field.Value, Confidence: field.Confidence}
+		if field.BBox != nil {
+			resultField.Bbox = &ocrv1.OcrBox{
+				X: int32(field.BBox.X), Y: int32(field.BBox.Y), Width: int32(field.BBox.Width), Height: int32(field.BBox.Height),
+			}
+		}
+		result.Fields = append(result.Fields, resultField)
+	}
+	if len(data.Zones) > 0 {
+		result.Zones = make(map[string]*ocrv1.OcrZoneResult, len(data.Zones))
+		for name, zone := range data.Zones {
+			result.Zones[name] = &ocrv1.OcrZoneResult{
+				Text:       zone.Text,
+				Confidence: zone.Confidence,
+				Bbox: &ocrv1.OcrBox{
+					X: int32(zone.BBox.X), Y: int32(zone.BBox.Y), Width: int32(zone.BBox.Width), Height: int32(zone.BBox.Height),
+				},
+			}
+		}
+	}
+	return result
+}
+
+// recognizePayload returns the payload of a recognition, the requests without type use the generic profile
+func recognizePayload(ctx context.Context, hocrEnabled bool, documentType string, zones []*ocrv1.OcrZone, fullText bool) (primitive.OcrRequest, error) {
+	if documentType == "" {
+		documentType = defaultDocumentType
+	}
+	if _, err := ProfileOptions(tenant.FromContext(ctx), documentType); err != nil {
+		return primitive.OcrRequest{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	payload := primitive.OcrRequest{HOCREnabled: strconv.FormatBool(hocrEnabled), Type: documentType}
+	if fullText {
+		payload.FullText = strconv.FormatBool(fullText)
+	}
+	if len(zones) == 0 {
+		return payload, nil
+	}
+
+	// the zones go through the same parsing as the ones of the uploads
+	requestZones := make([]primitive.OcrZone, 0, len(zones))
+	for _, zone := range zones {
+		requestZone := primitive.OcrZone{
+			Name: zone.GetName(), X: zone.GetX(), Y: zone.GetY(), Width: zone.GetWidth(), Height: zone.GetHeight(),
+			Unit: zone.GetUnit(), Whitelist: zone.GetWhitelist(),
+		}
+		if zone.GetPsm() != 0 {
+			psm := int(zone.GetPsm())
+			requestZone.Psm = &psm
+		}
+		requestZones = append(requestZones, requestZone)
+	}
+	raw, _ := json.Marshal(requestZones)
+	payload.Zones = string(raw)
+	if _, err := ParseZones(payload.Zones); err != nil {
+		return primitive.OcrRequest{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return payload, nil
+}
+
+// recognizeError returns the status of a failed recognition
+func recognizeError(err error) error {
+	if utils.ContainsError(err, []error{primitive.ErrorImageNotPreprocessed, primitive.ErrorZoneNotValid}) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
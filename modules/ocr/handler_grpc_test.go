@@ -0,0 +1,193 @@
+package ocr
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	ocrv1 "go-ocr/api/ocr/v1"
+	"go-ocr/infrastructure/events"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/middleware"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGrpcTestClient serves the grpc api of the service in memory, with the steps resolving the correlation id and the tenant
+func newGrpcTestClient(t *testing.T, service ServiceInterface) ocrv1.OcrServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	steps := []middleware.GrpcStep{middleware.GrpcCorrelationID(), middleware.GrpcTenant()}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middleware.UnaryInterceptor(steps...)),
+		grpc.ChainStreamInterceptor(middleware.StreamInterceptor(steps...)),
+	)
+	NewGrpc(service).RegisterOcr(server)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return ocrv1.NewOcrServiceClient(conn)
+}
+
+// tenantContext is the outgoing context of a call of the tenant
+func tenantContext(tenantID string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), tenant.HeaderTenantID, tenantID)
+}
+
+func TestGrpcGetResult(t *testing.T) {
+	service := &Service{repository: NewInMemoryRepository(), broker: events.NewInMemoryBroker(0, 0)}
+	record, err := service.saveRecord(tenant.WithTenant(context.Background(), "acme"), primitive.Ocr{ImageUrl: "uploads/acme/a.png", Text: "invoice", TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("saveRecord() error = %v", err)
+	}
+	client := newGrpcTestClient(t, service)
+
+	tests := []struct {
+		name     string
+		tenantID string
+		id       int64
+		wantCode codes.Code
+	}{
+		{name: "record of the tenant", tenantID: "acme", id: record.ID, wantCode: codes.OK},
+		{name: "record of another tenant", tenantID: "globex", id: record.ID, wantCode: codes.NotFound},
+		{name: "unknown record", tenantID: "acme", id: record.ID + 1, wantCode: codes.NotFound},
+		{name: "zero id", tenantID: "acme", wantCode: codes.InvalidArgument},
+		{name: "malformed tenant", tenantID: "../acme", id: record.ID, wantCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header metadata.MD
+			got, err := client.GetResult(tenantContext(tt.tenantID), &ocrv1.GetResultRequest{Id: tt.id}, grpc.Header(&header))
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("GetResult() error = %v, want code %s", err, tt.wantCode)
+			}
+			if err == nil && (got.GetId() != record.ID || got.GetText() != record.Text || got.GetTenantId() != "acme") {
+				t.Fatalf("GetResult() = %+v, want the record %d", got, record.ID)
+			}
+			// the correlation id is echoed even when the call fails
+			if len(header.Get(logger.CorrelationID)) != 1 {
+				t.Fatalf("GetResult() header = %v, want a correlation id", header)
+			}
+		})
+	}
+}
+
+func TestGrpcListResults(t *testing.T) {
+	service := &Service{repository: NewInMemoryRepository(), broker: events.NewInMemoryBroker(0, 0)}
+	for _, data := range []primitive.Ocr{
+		{ImageUrl: "uploads/acme/a.png", Text: "invoice one", TenantID: "acme"},
+		{ImageUrl: "uploads/acme/b.png", Text: "invoice two", TenantID: "acme"},
+		{ImageUrl: "uploads/globex/c.png", Text: "invoice three", TenantID: "globex"},
+	} {
+		if _, err := service.saveRecord(tenant.WithTenant(context.Background(), data.TenantID), data); err != nil {
+			t.Fatalf("saveRecord() error = %v", err)
+		}
+	}
+	client := newGrpcTestClient(t, service)
+
+	tests := []struct {
+		name      string
+		tenantID  string
+		request   *ocrv1.ListResultsRequest
+		wantCode  codes.Code
+		wantTotal int64
+	}{
+		{name: "results of the tenant", tenantID: "acme", request: &ocrv1.ListResultsRequest{}, wantTotal: 2},
+		{name: "results of another tenant", tenantID: "globex", request: &ocrv1.ListResultsRequest{}, wantTotal: 1},
+		{name: "text", tenantID: "acme", request: &ocrv1.ListResultsRequest{Text: "two"}, wantTotal: 1},
+		{name: "page size", tenantID: "acme", request: &ocrv1.ListResultsRequest{Page: 1, PageSize: 1}, wantTotal: 2},
+		{name: "negative page", tenantID: "acme", request: &ocrv1.ListResultsRequest{Page: -1}, wantCode: codes.InvalidArgument},
+		{name: "unknown status", tenantID: "acme", request: &ocrv1.ListResultsRequest{Status: "LOST"}},
+		{name: "malformed status", tenantID: "acme", request: &ocrv1.ListResultsRequest{Status: "DONE;"}, wantCode: codes.InvalidArgument},
+		{name: "unknown sort", tenantID: "acme", request: &ocrv1.ListResultsRequest{SortBy: "secret"}, wantCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.ListResults(tenantContext(tt.tenantID), tt.request)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("ListResults() error = %v, want code %s", err, tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if got.GetTotal() != tt.wantTotal {
+				t.Fatalf("ListResults() total = %d, want %d", got.GetTotal(), tt.wantTotal)
+			}
+			wantResults := tt.wantTotal
+			if tt.request.GetPageSize() > 0 && int64(tt.request.GetPageSize()) < wantResults {
+				wantResults = int64(tt.request.GetPageSize())
+			}
+			if int64(len(got.GetResults())) != wantResults {
+				t.Fatalf("ListResults() got %d results, want %d", len(got.GetResults()), wantResults)
+			}
+			for _, result := range got.GetResults() {
+				if result.GetTenantId() != tt.tenantID {
+					t.Fatalf("ListResults() got a result of %s, want only %s", result.GetTenantId(), tt.tenantID)
+				}
+			}
+		})
+	}
+}
+
+// TestGrpcRecognizeInvalid covers the requests refused before the recognition
+func TestGrpcRecognizeInvalid(t *testing.T) {
+	client := newGrpcTestClient(t, &Service{repository: NewInMemoryRepository()})
+
+	tests := []struct {
+		name     string
+		request  *ocrv1.RecognizeRequest
+		wantCode codes.Code
+	}{
+		{name: "without file name", request: &ocrv1.RecognizeRequest{Image: []byte("image")}, wantCode: codes.InvalidArgument},
+		{name: "file name of a dir", request: &ocrv1.RecognizeRequest{FileName: "..", Image: []byte("image")}, wantCode: codes.InvalidArgument},
+		{name: "without image", request: &ocrv1.RecognizeRequest{FileName: "a.png"}, wantCode: codes.InvalidArgument},
+		{name: "unknown type", request: &ocrv1.RecognizeRequest{FileName: "a.png", Image: []byte("image"), Type: "unknown"}, wantCode: codes.InvalidArgument},
+		{name: "zone out of the page", request: &ocrv1.RecognizeRequest{FileName: "a.png", Image: []byte("image"),
+			Zones: []*ocrv1.OcrZone{{Name: "total", X: 0.5, Y: 0.5, Width: 1, Height: 1}}}, wantCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Recognize(tenantContext("acme"), tt.request)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Recognize() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+
+	t.Run("stream without chunk", func(t *testing.T) {
+		stream, err := client.RecognizeStream(tenantContext("acme"))
+		if err != nil {
+			t.Fatalf("RecognizeStream() error = %v", err)
+		}
+		if _, err = stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("RecognizeStream() error = %v, want code %s", err, codes.InvalidArgument)
+		}
+	})
+	t.Run("stream without file name", func(t *testing.T) {
+		stream, err := client.RecognizeStream(tenantContext("acme"))
+		if err != nil {
+			t.Fatalf("RecognizeStream() error = %v", err)
+		}
+		if err = stream.Send(&ocrv1.RecognizeChunk{Data: []byte("image")}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		if _, err = stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("RecognizeStream() error = %v, want code %s", err, codes.InvalidArgument)
+		}
+	})
+}
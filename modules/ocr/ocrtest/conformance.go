@@ -0,0 +1,587 @@
+// Package ocrtest holds the conformance suite that every ocr.RepositoryInterface
+// implementation must pass, so the backends can be swapped without changing behaviour.
+package ocrtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-ocr/modules/ocr"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+
+	"gorm.io/gorm"
+)
+
+// NewRepository returns the repository under test, it may be shared between the
+// subtests because every subtest works on its own tenant.
+type NewRepository func(t *testing.T) ocr.RepositoryInterface
+
+var tenantSequence atomic.Int64
+
+// RunRepositoryConformance runs the whole suite against the repository returned by newRepository.
+func RunRepositoryConformance(t *testing.T, newRepository NewRepository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo ocr.RepositoryInterface)
+	}{
+		{"CreateAndFindByID", testCreateAndFindByID},
+		{"FindByIDIsScopedToTenant", testFindByIDIsScopedToTenant},
+		{"FindByText", testFindByText},
+		{"ListFilters", testListFilters},
+		{"MetadataFilters", testMetadataFilters},
+		{"SortWhitelist", testSortWhitelist},
+		{"PaginationEdges", testPaginationEdges},
+		{"Keyset", testKeyset},
+		{"NonPagination", testNonPagination},
+		{"Each", testEach},
+		{"SoftDelete", testSoftDelete},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, newRepository(t))
+		})
+	}
+}
+
+// newTenant returns a tenant id nobody else used, so a shared database starts empty for the subtest
+func newTenant() string {
+	return fmt.Sprintf("conformance-%d-%d", time.Now().UnixNano(), tenantSequence.Add(1))
+}
+
+func create(t *testing.T, repo ocr.RepositoryInterface, request primitive.Ocr) primitive.Ocr {
+	t.Helper()
+	result, err := repo.CreateOcr(context.Background(), request)
+	if err != nil {
+		t.Fatalf("CreateOcr() error = %v", err)
+	}
+	return result
+}
+
+func isNotFound(err error) bool {
+	return utils.ContainsError(err, []error{gorm.ErrRecordNotFound, primitive.ErrorArticleNotFound})
+}
+
+func ids(ocrs []primitive.Ocr) []int64 {
+	result := make([]int64, 0, len(ocrs))
+	for _, ocr := range ocrs {
+		result = append(result, ocr.ID)
+	}
+	return result
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func testCreateAndFindByID(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+
+	fields := `[{"name":"total","value":"12.50","confidence":90,"bbox":{"x":1,"y":2,"width":3,"height":4}}]`
+	zones := `{"total":{"text":"12.50","confidence":90,"bbox":{"x":1,"y":2,"width":3,"height":4}}}`
+	words := `[{"text":"first","confidence":90,"line":1,"bbox":{"x":1,"y":2,"width":3,"height":4}}]`
+	first := create(t, repo, primitive.Ocr{
+		TenantID: tenantID, ImageUrl: "uploads/a.png", Text: "first", Status: "done", CreatedBy: "api_key:1",
+		DocumentType: "receipt", Language: "eng", Confidence: 87.25, Tags: "a,b", ContentHash: "abc", Fields: fields,
+		Words: words, ImageWidth: 640, ImageHeight: 480, Zones: zones,
+	})
+	second := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "second", Status: "done"})
+
+	if first.ID <= 0 {
+		t.Fatalf("CreateOcr() id = %d, want a positive id", first.ID)
+	}
+	if second.ID <= first.ID {
+		t.Fatalf("CreateOcr() ids = %d then %d, want increasing ids", first.ID, second.ID)
+	}
+	if first.CreatedAt.IsZero() {
+		t.Errorf("CreateOcr() created at is zero")
+	}
+	if !first.DeletedAt.IsZero() {
+		t.Errorf("CreateOcr() deleted at = %v, want zero", first.DeletedAt)
+	}
+
+	found, err := repo.FindOcrByID(ctx, tenantID, first.ID)
+	if err != nil {
+		t.Fatalf("FindOcrByID() error = %v", err)
+	}
+	if found.ID != first.ID || found.TenantID != tenantID || found.Text != "first" || found.Status != "done" ||
+		found.ImageUrl != "uploads/a.png" || found.CreatedBy != "api_key:1" || found.DocumentType != "receipt" ||
+		found.Language != "eng" || found.Confidence != 87.25 || found.Tags != "a,b" || found.ContentHash != "abc" ||
+		found.Fields != fields || found.Words != words || found.ImageWidth != 640 || found.ImageHeight != 480 ||
+		found.Zones != zones {
+		t.Errorf("FindOcrByID() = %+v, want %+v", found, first)
+	}
+
+	_, err = repo.FindOcrByID(ctx, tenantID, second.ID+1_000_000)
+	if !isNotFound(err) {
+		t.Errorf("FindOcrByID() of a missing id error = %v, want not found", err)
+	}
+}
+
+func testFindByIDIsScopedToTenant(t *testing.T, repo ocr.RepositoryInterface) {
+	created := create(t, repo, primitive.Ocr{TenantID: newTenant(), Text: "private"})
+
+	_, err := repo.FindOcrByID(context.Background(), newTenant(), created.ID)
+	if !isNotFound(err) {
+		t.Errorf("FindOcrByID() from another tenant error = %v, want not found", err)
+	}
+}
+
+func testFindByText(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+
+	invoice := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "Invoice number 42"})
+	create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "invoice copy"})
+	create(t, repo, primitive.Ocr{TenantID: newTenant(), Text: "receipt"})
+
+	// the text is matched as a substring by every backend, within a word and across words
+	for _, text := range []string{"invoice", "INVOICE", "Invo", "voice", "nv", "ce number 4"} {
+		found, err := repo.FindOcrByText(ctx, tenantID, text)
+		if err != nil {
+			t.Fatalf("FindOcrByText(%q) error = %v", text, err)
+		}
+		if found.ID != invoice.ID {
+			t.Errorf("FindOcrByText(%q) id = %d, want the first match %d", text, found.ID, invoice.ID)
+		}
+	}
+
+	if _, err := repo.FindOcrByText(ctx, tenantID, "receipt"); !isNotFound(err) {
+		t.Errorf("FindOcrByText() from another tenant error = %v, want not found", err)
+	}
+	if _, err := repo.FindOcrByText(ctx, tenantID, "100%"); !isNotFound(err) {
+		t.Errorf("FindOcrByText() of a wildcard error = %v, want not found", err)
+	}
+	if _, err := repo.FindOcrByText(ctx, tenantID, "number invoice"); !isNotFound(err) {
+		t.Errorf("FindOcrByText() of the words out of order error = %v, want not found", err)
+	}
+}
+
+func testListFilters(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+
+	doneInvoice := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "invoice march", Status: "done"})
+	failedInvoice := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "invoice april", Status: "failed"})
+	doneReceipt := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "receipt march", Status: "done"})
+	create(t, repo, primitive.Ocr{TenantID: newTenant(), Text: "invoice march", Status: "done"})
+
+	tests := []struct {
+		name  string
+		param primitive.ParameterFindOcr
+		want  []int64
+	}{
+		{"tenant", primitive.ParameterFindOcr{}, []int64{doneReceipt.ID, failedInvoice.ID, doneInvoice.ID}},
+		{"status", primitive.ParameterFindOcr{Statuses: []string{"done"}}, []int64{doneReceipt.ID, doneInvoice.ID}},
+		{"text", primitive.ParameterFindOcr{Text: "invoice"}, []int64{failedInvoice.ID, doneInvoice.ID}},
+		{"text ignores case", primitive.ParameterFindOcr{Text: "MARCH"}, []int64{doneReceipt.ID, doneInvoice.ID}},
+		{"text within a word", primitive.ParameterFindOcr{Text: "arch"}, []int64{doneReceipt.ID, doneInvoice.ID}},
+		{"short text within a word", primitive.ParameterFindOcr{Text: "pr"}, []int64{failedInvoice.ID}},
+		{"text across words", primitive.ParameterFindOcr{Text: "ce mar"}, []int64{doneInvoice.ID}},
+		{"status and text", primitive.ParameterFindOcr{Statuses: []string{"done"}, Text: "invoice"}, []int64{doneInvoice.ID}},
+		{"no match", primitive.ParameterFindOcr{Statuses: []string{"pending"}}, []int64{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			param := test.param
+			param.TenantID = tenantID
+			param.PageSize = 10
+
+			result, err := repo.FindAllListOcrPagination(ctx, param)
+			if err != nil {
+				t.Fatalf("FindAllListOcrPagination() error = %v", err)
+			}
+			if !equalIDs(ids(result), test.want) {
+				t.Errorf("FindAllListOcrPagination() ids = %v, want %v", ids(result), test.want)
+			}
+
+			count, err := repo.CountAllListOcr(ctx, param)
+			if err != nil {
+				t.Fatalf("CountAllListOcr() error = %v", err)
+			}
+			if count != int64(len(test.want)) {
+				t.Errorf("CountAllListOcr() = %d, want %d", count, len(test.want))
+			}
+		})
+	}
+}
+
+func testMetadataFilters(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	hash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	invoice := create(t, repo, primitive.Ocr{
+		TenantID: tenantID, Text: "invoice", Status: "done", DocumentType: "invoice", Language: "eng",
+		Confidence: 91.5, Tags: "finance,q1", ContentHash: hash, CreatedAt: base, UpdatedAt: base.Add(3 * time.Minute),
+	})
+	receipt := create(t, repo, primitive.Ocr{
+		TenantID: tenantID, Text: "receipt", Status: "failed", DocumentType: "receipt", Language: "eng+fra",
+		Confidence: 42, Tags: "finance", CreatedAt: base.Add(time.Minute), UpdatedAt: base.Add(time.Minute),
+	})
+	letter := create(t, repo, primitive.Ocr{
+		TenantID: tenantID, Text: "letter", Status: "pending", Language: "fra",
+		Confidence: 75.25, Tags: "q1-archive", CreatedAt: base.Add(2 * time.Minute), UpdatedAt: base.Add(2 * time.Minute),
+	})
+	create(t, repo, primitive.Ocr{TenantID: newTenant(), Text: "invoice", Status: "done", DocumentType: "invoice", Language: "eng", ContentHash: hash})
+
+	minConfidence, maxConfidence := 50.0, 91.5
+	tests := []struct {
+		name  string
+		param primitive.ParameterFindOcr
+		want  []int64
+	}{
+		{"statuses", primitive.ParameterFindOcr{Statuses: []string{"done", "pending"}}, []int64{letter.ID, invoice.ID}},
+		{"document type", primitive.ParameterFindOcr{DocumentType: "receipt"}, []int64{receipt.ID}},
+		{"language", primitive.ParameterFindOcr{Language: "fra"}, []int64{letter.ID, receipt.ID}},
+		{"language is a whole code", primitive.ParameterFindOcr{Language: "en"}, []int64{}},
+		{"tag", primitive.ParameterFindOcr{Tags: []string{"q1"}}, []int64{invoice.ID}},
+		{"every tag", primitive.ParameterFindOcr{Tags: []string{"finance", "q1"}}, []int64{invoice.ID}},
+		{"tag is matched literally", primitive.ParameterFindOcr{Tags: []string{"q1_archive"}}, []int64{}},
+		{"content hash", primitive.ParameterFindOcr{ContentHash: hash}, []int64{invoice.ID}},
+		{"created range", primitive.ParameterFindOcr{CreatedFrom: base.Add(time.Minute), CreatedTo: base.Add(2 * time.Minute)}, []int64{receipt.ID}},
+		{"updated from", primitive.ParameterFindOcr{UpdatedFrom: base.Add(2 * time.Minute)}, []int64{letter.ID, invoice.ID}},
+		{"confidence bounds", primitive.ParameterFindOcr{MinConfidence: &minConfidence, MaxConfidence: &maxConfidence}, []int64{letter.ID, invoice.ID}},
+		{"combined", primitive.ParameterFindOcr{Statuses: []string{"done", "failed"}, Tags: []string{"finance"}, MaxConfidence: &minConfidence}, []int64{receipt.ID}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			param := test.param
+			param.TenantID = tenantID
+			param.PageSize = 10
+
+			result, err := repo.FindAllListOcrPagination(ctx, param)
+			if err != nil {
+				t.Fatalf("FindAllListOcrPagination() error = %v", err)
+			}
+			if !equalIDs(ids(result), test.want) {
+				t.Errorf("FindAllListOcrPagination() ids = %v, want %v", ids(result), test.want)
+			}
+
+			count, err := repo.CountAllListOcr(ctx, param)
+			if err != nil {
+				t.Fatalf("CountAllListOcr() error = %v", err)
+			}
+			if count != int64(len(test.want)) {
+				t.Errorf("CountAllListOcr() = %d, want %d", count, len(test.want))
+			}
+		})
+	}
+
+	sorts := []struct {
+		sortBy string
+		want   []int64
+	}{
+		{"confidence", []int64{receipt.ID, letter.ID, invoice.ID}},
+		{"updated_at", []int64{receipt.ID, letter.ID, invoice.ID}},
+		{"document_type", []int64{letter.ID, invoice.ID, receipt.ID}},
+		{"language", []int64{invoice.ID, receipt.ID, letter.ID}},
+	}
+	for _, test := range sorts {
+		t.Run("sort by "+test.sortBy, func(t *testing.T) {
+			result, err := repo.FindAllListOcrPagination(ctx, primitive.ParameterFindOcr{
+				TenantID: tenantID, PageSize: 10, SortBy: test.sortBy, SortOrder: "asc",
+			})
+			if err != nil {
+				t.Fatalf("FindAllListOcrPagination() error = %v", err)
+			}
+			if !equalIDs(ids(result), test.want) {
+				t.Errorf("FindAllListOcrPagination() ids = %v, want %v", ids(result), test.want)
+			}
+		})
+	}
+}
+
+func testSortWhitelist(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	charlie := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "charlie", Status: "done", CreatedAt: base.Add(2 * time.Minute)})
+	alpha := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "alpha", Status: "pending", CreatedAt: base})
+	bravo := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "bravo", Status: "failed", CreatedAt: base.Add(time.Minute)})
+
+	tests := []struct {
+		sortBy    string
+		sortOrder string
+		want      []int64
+	}{
+		{"id", "asc", []int64{charlie.ID, alpha.ID, bravo.ID}},
+		{"id", "desc", []int64{bravo.ID, alpha.ID, charlie.ID}},
+		{"ID", "asc", []int64{charlie.ID, alpha.ID, bravo.ID}},
+		{"text", "asc", []int64{alpha.ID, bravo.ID, charlie.ID}},
+		{"Text", "desc", []int64{charlie.ID, bravo.ID, alpha.ID}},
+		{"status", "asc", []int64{charlie.ID, bravo.ID, alpha.ID}},
+		{"created_at", "asc", []int64{alpha.ID, bravo.ID, charlie.ID}},
+		{"createdAt", "desc", []int64{charlie.ID, bravo.ID, alpha.ID}},
+		{"", "", []int64{bravo.ID, alpha.ID, charlie.ID}},
+		{"image_url; drop table ocr", "asc", []int64{charlie.ID, alpha.ID, bravo.ID}},
+		{"text", "sideways", []int64{charlie.ID, bravo.ID, alpha.ID}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s", test.sortBy, test.sortOrder), func(t *testing.T) {
+			result, err := repo.FindAllListOcrPagination(ctx, primitive.ParameterFindOcr{
+				TenantID:  tenantID,
+				PageSize:  10,
+				SortBy:    test.sortBy,
+				SortOrder: test.sortOrder,
+			})
+			if err != nil {
+				t.Fatalf("FindAllListOcrPagination() error = %v", err)
+			}
+			if !equalIDs(ids(result), test.want) {
+				t.Errorf("FindAllListOcrPagination() ids = %v, want %v", ids(result), test.want)
+			}
+		})
+	}
+}
+
+func testPaginationEdges(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+
+	created := make([]int64, 0, 5)
+	for idx := 0; idx < 5; idx++ {
+		created = append(created, create(t, repo, primitive.Ocr{TenantID: tenantID, Text: fmt.Sprintf("page %d", idx)}).ID)
+	}
+
+	tests := []struct {
+		name     string
+		offset   int
+		pageSize int
+		want     []int64
+	}{
+		{"first page", 0, 2, []int64{created[0], created[1]}},
+		{"middle page", 2, 2, []int64{created[2], created[3]}},
+		{"last partial page", 4, 2, []int64{created[4]}},
+		{"offset at the end", 5, 2, []int64{}},
+		{"offset past the end", 50, 2, []int64{}},
+		{"page larger than the data", 0, 50, created},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := repo.FindAllListOcrPagination(ctx, primitive.ParameterFindOcr{
+				TenantID:  tenantID,
+				Offset:    test.offset,
+				PageSize:  test.pageSize,
+				SortBy:    "id",
+				SortOrder: "asc",
+			})
+			if err != nil {
+				t.Fatalf("FindAllListOcrPagination() error = %v", err)
+			}
+			if !equalIDs(ids(result), test.want) {
+				t.Errorf("FindAllListOcrPagination() ids = %v, want %v", ids(result), test.want)
+			}
+		})
+	}
+
+	count, err := repo.CountAllListOcr(ctx, primitive.ParameterFindOcr{TenantID: tenantID, Offset: 4, PageSize: 2})
+	if err != nil {
+		t.Fatalf("CountAllListOcr() error = %v", err)
+	}
+	if count != 5 {
+		t.Errorf("CountAllListOcr() = %d, want the total 5 whatever the page", count)
+	}
+}
+
+func testKeyset(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	alpha := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "alpha", CreatedAt: base})
+	firstBravo := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "bravo", CreatedAt: base.Add(time.Minute)})
+	secondBravo := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "bravo", CreatedAt: base.Add(2 * time.Minute)})
+	charlie := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "charlie", CreatedAt: base.Add(3 * time.Minute)})
+	delta := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "delta", CreatedAt: base.Add(4 * time.Minute)})
+
+	textAfter := func(row primitive.Ocr, backward bool) *primitive.OcrCursor {
+		return &primitive.OcrCursor{SortBy: "text", SortOrder: "asc", ID: row.ID, Backward: backward}
+	}
+
+	tests := []struct {
+		name   string
+		param  primitive.ParameterFindOcr
+		insert *primitive.Ocr
+		want   []int64
+	}{
+		{"first page", primitive.ParameterFindOcr{SortBy: "text", SortOrder: "asc"}, nil, []int64{alpha.ID, firstBravo.ID}},
+		{"ties are broken by id", primitive.ParameterFindOcr{SortBy: "text", SortOrder: "asc", Cursor: textAfter(firstBravo, false)}, nil, []int64{secondBravo.ID, charlie.ID}},
+		{"inserts before the cursor don't shift the page", primitive.ParameterFindOcr{SortBy: "text", SortOrder: "asc", Cursor: textAfter(charlie, false)},
+			&primitive.Ocr{TenantID: tenantID, Text: "aardvark"}, []int64{delta.ID}},
+		{"backward from the nearest", primitive.ParameterFindOcr{SortBy: "text", SortOrder: "asc", Cursor: textAfter(charlie, true)}, nil, []int64{secondBravo.ID, firstBravo.ID}},
+		{"id desc", primitive.ParameterFindOcr{SortBy: "id", SortOrder: "desc", Cursor: &primitive.OcrCursor{SortBy: "id", SortOrder: "desc", ID: charlie.ID}},
+			nil, []int64{secondBravo.ID, firstBravo.ID}},
+		{"created at desc", primitive.ParameterFindOcr{SortBy: "created_at", SortOrder: "desc", Cursor: &primitive.OcrCursor{SortBy: "created_at", SortOrder: "desc",
+			ID: charlie.ID}}, nil, []int64{secondBravo.ID, firstBravo.ID}},
+		{"past the end", primitive.ParameterFindOcr{SortBy: "text", SortOrder: "asc", Cursor: textAfter(delta, false)}, nil, []int64{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.insert != nil {
+				create(t, repo, *test.insert)
+			}
+			param := test.param
+			param.TenantID = tenantID
+			param.PageSize = 2
+
+			result, err := repo.FindAllListOcrKeyset(ctx, param)
+			if err != nil {
+				t.Fatalf("FindAllListOcrKeyset() error = %v", err)
+			}
+			if !equalIDs(ids(result), test.want) {
+				t.Errorf("FindAllListOcrKeyset() ids = %v, want %v", ids(result), test.want)
+			}
+		})
+	}
+
+	// the cursor row is read in the tenant of the list, the row of another tenant is not a cursor
+	other := create(t, repo, primitive.Ocr{TenantID: newTenant(), Text: "bravo"})
+	for _, id := range []int64{other.ID, delta.ID + 1000} {
+		_, err := repo.FindAllListOcrKeyset(ctx, primitive.ParameterFindOcr{TenantID: tenantID, PageSize: 2, SortBy: "text", SortOrder: "asc", Cursor: textAfter(primitive.Ocr{ID: id}, false)})
+		if !errors.Is(err, primitive.ErrorCursorNotValid) {
+			t.Errorf("FindAllListOcrKeyset() with the cursor row %d error = %v, want %v", id, err, primitive.ErrorCursorNotValid)
+		}
+	}
+}
+
+func testNonPagination(t *testing.T, repo ocr.RepositoryInterface) {
+	tenantID := newTenant()
+
+	want := make([]int64, 0, 3)
+	for idx := 0; idx < 3; idx++ {
+		want = append(want, create(t, repo, primitive.Ocr{TenantID: tenantID, Text: fmt.Sprintf("all %d", idx)}).ID)
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i] > want[j] })
+
+	result, err := repo.FindAllListOcrNonPagination(context.Background(), primitive.ParameterFindOcr{TenantID: tenantID})
+	if err != nil {
+		t.Fatalf("FindAllListOcrNonPagination() error = %v", err)
+	}
+	if !equalIDs(ids(result), want) {
+		t.Errorf("FindAllListOcrNonPagination() ids = %v, want the newest first %v", ids(result), want)
+	}
+}
+
+func testEach(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+
+	bravo := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "bravo", Status: "done"})
+	alpha := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "alpha", Status: "done"})
+	create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "charlie", Status: "failed"})
+	create(t, repo, primitive.Ocr{TenantID: newTenant(), Text: "delta", Status: "done"})
+
+	param := primitive.ParameterFindOcr{TenantID: tenantID, Statuses: []string{"done"}, SortBy: "text", SortOrder: "asc"}
+	var visited []primitive.Ocr
+	err := repo.EachOcr(ctx, param, func(ocr primitive.Ocr) error {
+		visited = append(visited, ocr)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachOcr() error = %v", err)
+	}
+	if !equalIDs(ids(visited), []int64{alpha.ID, bravo.ID}) {
+		t.Errorf("EachOcr() ids = %v, want the filtered and sorted %v", ids(visited), []int64{alpha.ID, bravo.ID})
+	}
+	if len(visited) > 0 && (visited[0].Text != "alpha" || visited[0].TenantID != tenantID) {
+		t.Errorf("EachOcr() first = %+v, want the whole entry of %d", visited[0], alpha.ID)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.EachOcr(ctx, param, func(ocr primitive.Ocr) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("EachOcr() returning an error = %v after %d call(s), want the error after 1 call", err, calls)
+	}
+}
+
+func testSoftDelete(t *testing.T, repo ocr.RepositoryInterface) {
+	ctx := context.Background()
+	tenantID := newTenant()
+	otherTenantID := newTenant()
+	now := time.Now()
+
+	expired := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "expired scan", CreatedAt: now.Add(-48 * time.Hour)})
+	recent := create(t, repo, primitive.Ocr{TenantID: tenantID, Text: "recent scan", CreatedAt: now})
+	otherExpired := create(t, repo, primitive.Ocr{TenantID: otherTenantID, Text: "expired scan", CreatedAt: now.Add(-48 * time.Hour)})
+
+	count, err := repo.DeleteOcrCreatedBefore(ctx, tenantID, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteOcrCreatedBefore() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("DeleteOcrCreatedBefore() = %d, want 1", count)
+	}
+
+	count, err = repo.DeleteOcrCreatedBefore(ctx, tenantID, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteOcrCreatedBefore() again error = %v", err)
+	}
+	if count != 0 {
+		t.Errorf("DeleteOcrCreatedBefore() again = %d, want 0 because the entry is already deleted", count)
+	}
+
+	if _, err = repo.FindOcrByID(ctx, tenantID, expired.ID); !isNotFound(err) {
+		t.Errorf("FindOcrByID() of a deleted entry error = %v, want not found", err)
+	}
+	if _, err = repo.FindOcrByText(ctx, tenantID, "expired"); !isNotFound(err) {
+		t.Errorf("FindOcrByText() of a deleted entry error = %v, want not found", err)
+	}
+	if _, err = repo.FindOcrByID(ctx, tenantID, recent.ID); err != nil {
+		t.Errorf("FindOcrByID() of a recent entry error = %v", err)
+	}
+	if _, err = repo.FindOcrByID(ctx, otherTenantID, otherExpired.ID); err != nil {
+		t.Errorf("FindOcrByID() of another tenant error = %v, want it untouched", err)
+	}
+
+	param := primitive.ParameterFindOcr{TenantID: tenantID, PageSize: 10}
+	listed, err := repo.FindAllListOcrPagination(ctx, param)
+	if err != nil {
+		t.Fatalf("FindAllListOcrPagination() error = %v", err)
+	}
+	if !equalIDs(ids(listed), []int64{recent.ID}) {
+		t.Errorf("FindAllListOcrPagination() ids = %v, want only %d", ids(listed), recent.ID)
+	}
+
+	all, err := repo.FindAllListOcrNonPagination(ctx, param)
+	if err != nil {
+		t.Fatalf("FindAllListOcrNonPagination() error = %v", err)
+	}
+	if !equalIDs(ids(all), []int64{recent.ID}) {
+		t.Errorf("FindAllListOcrNonPagination() ids = %v, want only %d", ids(all), recent.ID)
+	}
+
+	total, err := repo.CountAllListOcr(ctx, param)
+	if err != nil {
+		t.Fatalf("CountAllListOcr() error = %v", err)
+	}
+	if total != 1 {
+		t.Errorf("CountAllListOcr() = %d, want 1", total)
+	}
+}
@@ -0,0 +1,91 @@
+package ocr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+)
+
+// defaultDocumentType is the profile of the grpc requests without type
+const defaultDocumentType = "generic"
+
+var spaces = regexp.MustCompile(`[ \t]+`)
+
+// postprocessors clean the plain text, they run in the order of the profile
+var postprocessors = map[string]func(string) string{
+	"trim_lines": func(text string) string {
+		lines := strings.Split(text, "\n")
+		for idx, line := range lines {
+			lines[idx] = strings.TrimSpace(line)
+		}
+		return strings.Join(lines, "\n")
+	},
+	"collapse_spaces": func(text string) string {
+		return spaces.ReplaceAllString(text, " ")
+	},
+	"drop_empty_lines": func(text string) string {
+		lines := strings.Split(text, "\n")
+		kept := lines[:0]
+		for _, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				kept = append(kept, line)
+			}
+		}
+		return strings.Join(kept, "\n")
+	},
+	"uppercase": strings.ToUpper,
+}
+
+// ProfileNames returns the configured document types
+func ProfileNames() []string {
+	profiles := config.Current().Profiles
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+// ProfileOptions returns the recognition options of the document type, the languages of
+// the tenant are used when the profile has none
+func ProfileOptions(tenantID, documentType string) (primitive.RecognizeOptions, error) {
+	for _, profile := range config.Current().Profiles {
+		if profile.Name != documentType {
+			continue
+		}
+		options := primitive.RecognizeOptions{
+			Languages:    profile.Languages,
+			Psm:          primitive.PsmDefault,
+			Format:       primitive.FormatText,
+			DocumentType: profile.Name,
+			Preprocess:   profile.Preprocess,
+			Postprocess:  profile.Postprocess,
+			Extractors:   profile.Extractors,
+			Zones:        profileZones(profile.Zones),
+			FullText:     profile.FullText,
+		}
+		if len(options.Languages) == 0 {
+			options.Languages = tenant.Languages(tenantID)
+		}
+		if profile.Psm != nil {
+			options.Psm = *profile.Psm
+		}
+		return options, nil
+	}
+	return primitive.RecognizeOptions{}, fmt.Errorf("%w: %q, use one of %s",
+		primitive.ErrorDocumentTypeNotValid, documentType, strings.Join(ProfileNames(), ", "))
+}
+
+// postprocess runs the postprocessors on the plain text, the unknown ones are skipped
+func postprocess(text string, names []string) string {
+	for _, name := range names {
+		if postprocessor, ok := postprocessors[name]; ok {
+			text = postprocessor(text)
+		}
+	}
+	return strings.Trim(text, "\n")
+}
@@ -0,0 +1,79 @@
+package ocr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/modules/primitive"
+)
+
+func TestPostprocess(t *testing.T) {
+	text := "  Invoice   No\t42  \n\n\n  total:  10.00 \n"
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{name: "none", want: "  Invoice   No\t42  \n\n\n  total:  10.00 "},
+		{name: "trim lines", names: []string{"trim_lines"}, want: "Invoice   No\t42\n\n\ntotal:  10.00"},
+		{name: "collapse spaces", names: []string{"collapse_spaces"}, want: " Invoice No 42 \n\n\n total: 10.00 "},
+		{name: "drop empty lines", names: []string{"drop_empty_lines"}, want: "  Invoice   No\t42  \n  total:  10.00 "},
+		{name: "uppercase", names: []string{"uppercase"}, want: "  INVOICE   NO\t42  \n\n\n  TOTAL:  10.00 "},
+		{name: "chained", names: []string{"trim_lines", "collapse_spaces", "drop_empty_lines"}, want: "Invoice No 42\ntotal: 10.00"},
+		{name: "unknown skipped", names: []string{"unknown", "trim_lines"}, want: "Invoice   No\t42\n\n\ntotal:  10.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postprocess(text, tt.names); got != tt.want {
+				t.Fatalf("postprocess(%v) = %q, want %q", tt.names, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileOptions(t *testing.T) {
+	previousProfiles, previousTenants, previousLanguages := config.Conf.Profiles, config.Conf.Tenants, config.Conf.TesseractsConfig.Languages
+	t.Cleanup(func() {
+		config.Conf.Profiles, config.Conf.Tenants, config.Conf.TesseractsConfig.Languages = previousProfiles, previousTenants, previousLanguages
+	})
+	psm := 6
+	config.Conf.Profiles = []config.ProfileConfig{
+		{Name: "generic"},
+		{Name: "receipt", Languages: []string{"eng"}, Psm: &psm, Postprocess: []string{"trim_lines"}, Extractors: []string{"total"}},
+	}
+	config.Conf.Tenants = []config.TenantConfig{{ID: "acme", Languages: []string{"ind"}}}
+	config.Conf.TesseractsConfig.Languages = []string{"eng", "deu"}
+
+	tests := []struct {
+		name          string
+		tenantID      string
+		documentType  string
+		wantLanguages []string
+		wantPsm       int
+		wantErr       error
+	}{
+		{name: "languages of the profile", tenantID: "acme", documentType: "receipt", wantLanguages: []string{"eng"}, wantPsm: psm},
+		{name: "languages of the tenant", tenantID: "acme", documentType: "generic", wantLanguages: []string{"ind"}, wantPsm: primitive.PsmDefault},
+		{name: "languages of the engine", tenantID: "globex", documentType: "generic", wantLanguages: []string{"eng", "deu"}, wantPsm: primitive.PsmDefault},
+		{name: "unknown type", tenantID: "acme", documentType: "passport", wantErr: primitive.ErrorDocumentTypeNotValid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProfileOptions(tt.tenantID, tt.documentType)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProfileOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.DocumentType != tt.documentType || got.Format != primitive.FormatText {
+				t.Fatalf("ProfileOptions() = %+v, want the plain text of %s", got, tt.documentType)
+			}
+			if !reflect.DeepEqual(got.Languages, tt.wantLanguages) || got.Psm != tt.wantPsm {
+				t.Fatalf("ProfileOptions() languages = %v, psm = %d, want %v, %d", got.Languages, got.Psm, tt.wantLanguages, tt.wantPsm)
+			}
+		})
+	}
+}
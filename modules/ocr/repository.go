@@ -2,7 +2,9 @@ package ocr
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"time"
 
 	"go-ocr/modules/primitive"
 
@@ -11,11 +13,30 @@ import (
 
 type RepositoryInterface interface {
 	CreateOcr(ctx context.Context, request primitive.Ocr) (result primitive.Ocr, err error)
-	FindOcrByID(ctx context.Context, id int64) (result primitive.Ocr, err error)
-	FindOcrByText(ctx context.Context, text string) (result primitive.Ocr, err error)
+	FindOcrByID(ctx context.Context, tenantID string, id int64) (result primitive.Ocr, err error)
+	FindOcrByText(ctx context.Context, tenantID string, text string) (result primitive.Ocr, err error)
 	FindAllListOcrPagination(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error)
+	FindAllListOcrKeyset(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error)
 	CountAllListOcr(ctx context.Context, param primitive.ParameterFindOcr) (count int64, err error)
 	FindAllListOcrNonPagination(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error)
+	EachOcr(ctx context.Context, param primitive.ParameterFindOcr, fn func(primitive.Ocr) error) (err error)
+	DeleteOcrCreatedBefore(ctx context.Context, tenantID string, before time.Time) (count int64, err error)
+}
+
+// sortColumns maps the accepted orderBy values to their column, it is the whitelist of every
+// repository. The handlers reject the other values, the repositories sort them by id.
+var sortColumns = map[string]string{
+	"id":            "id",
+	"text":          "text",
+	"status":        "status",
+	"document_type": "document_type",
+	"documenttype":  "document_type",
+	"language":      "language",
+	"confidence":    "confidence",
+	"created_at":    "created_at",
+	"createdat":     "created_at",
+	"updated_at":    "updated_at",
+	"updatedat":     "updated_at",
 }
 
 type Repository struct {
@@ -29,15 +50,22 @@ func NewRepository(db *gorm.DB) *Repository {
 }
 
 func (repo *Repository) CreateOcr(ctx context.Context, request primitive.Ocr) (result primitive.Ocr, err error) {
-	err = repo.db.WithContext(ctx).Table("ocr").Create(&request).Scan(&result).Error
+	if request.CreatedAt.IsZero() {
+		request.CreatedAt = time.Now()
+		request.UpdatedAt = request.CreatedAt
+	}
+
+	// deleted_at is left null, a zero time would never match "deleted_at is null"
+	err = repo.db.WithContext(ctx).Table("ocr").Omit("deleted_at").Create(&request).Error
 	if err != nil {
 		return result, err
 	}
-	return result, nil
+	return request, nil
 }
 
-func (repo *Repository) FindOcrByID(ctx context.Context, id int64) (result primitive.Ocr, err error) {
+func (repo *Repository) FindOcrByID(ctx context.Context, tenantID string, id int64) (result primitive.Ocr, err error) {
 	err = repo.db.WithContext(ctx).Table("ocr").
+		Where("tenant_id = ?", tenantID).
 		Where("id = ?", id).
 		Where("deleted_at is null").
 		First(&result).
@@ -48,9 +76,10 @@ func (repo *Repository) FindOcrByID(ctx context.Context, id int64) (result primi
 	return result, nil
 }
 
-func (repo *Repository) FindOcrByText(ctx context.Context, text string) (result primitive.Ocr, err error) {
+func (repo *Repository) FindOcrByText(ctx context.Context, tenantID string, text string) (result primitive.Ocr, err error) {
 	err = repo.db.WithContext(ctx).Table("ocr").
-		Where("text ilike '%?%'", text).
+		Where("tenant_id = ?", tenantID).
+		Where("text ilike ?", likePattern(text)).
 		Where("deleted_at is null").
 		First(&result).
 		Error
@@ -61,19 +90,11 @@ func (repo *Repository) FindOcrByText(ctx context.Context, text string) (result
 }
 
 func (repo *Repository) FindAllListOcrPagination(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error) {
-	query := repo.db.WithContext(ctx).Table("ocr")
-
-	if param.Status != "" {
-		query = query.Where("status = ?", param.Status)
-	}
-
-	if param.Text != "" {
-		query = query.Where("text ilike '%?%'", param.Text)
-	}
+	query := repo.filter(ctx, param)
 
 	err = query.Offset(param.Offset).
 		Limit(param.PageSize).
-		Order(strings.Join([]string{param.SortBy, param.SortOrder}, " ")).
+		Order(orderClause(param.SortBy, param.SortOrder)).
 		Find(&result).
 		Error
 	if err != nil {
@@ -83,17 +104,25 @@ func (repo *Repository) FindAllListOcrPagination(ctx context.Context, param prim
 	return result, nil
 }
 
-func (repo *Repository) CountAllListOcr(ctx context.Context, param primitive.ParameterFindOcr) (count int64, err error) {
-	query := repo.db.WithContext(ctx).Table("ocr")
-
-	if param.Status != "" {
-		query = query.Where("status = ?", param.Status)
+// FindAllListOcrKeyset returns the page size entries following the cursor in the sort order,
+// or preceding it when the cursor goes backward, the entries nearest to the cursor first
+func (repo *Repository) FindAllListOcrKeyset(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error) {
+	query, err := keysetQuery(repo.filter(ctx, param), repo.db.WithContext(ctx), param)
+	if err != nil {
+		return nil, err
 	}
 
-	if param.Text != "" {
-		query = query.Where("text ilike '%?%'", param.Text)
+	err = query.Limit(param.PageSize).Find(&result).Error
+	if err != nil {
+		return nil, err
 	}
 
+	return result, nil
+}
+
+func (repo *Repository) CountAllListOcr(ctx context.Context, param primitive.ParameterFindOcr) (count int64, err error) {
+	query := repo.filter(ctx, param)
+
 	err = query.Count(&count).Error
 	if err != nil {
 		return 0, err
@@ -103,22 +132,182 @@ func (repo *Repository) CountAllListOcr(ctx context.Context, param primitive.Par
 }
 
 func (repo *Repository) FindAllListOcrNonPagination(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error) {
-	query := repo.db.WithContext(ctx).Table("ocr")
+	query := repo.filter(ctx, param)
 
-	if param.Status != "" {
-		query = query.Where("status = ?", param.Status)
+	err = query.Order("id desc").
+		Find(&result).
+		Error
+	if err != nil {
+		return nil, err
 	}
 
+	return result, nil
+}
+
+// EachOcr calls fn for every sorted entry matching the filters, stopping at the first error,
+// the rows are read one at a time so a large export never holds the result set
+func (repo *Repository) EachOcr(ctx context.Context, param primitive.ParameterFindOcr, fn func(primitive.Ocr) error) (err error) {
+	return eachRow(repo.filter(ctx, param).Order(orderClause(param.SortBy, param.SortOrder)), fn)
+}
+
+func (repo *Repository) DeleteOcrCreatedBefore(ctx context.Context, tenantID string, before time.Time) (count int64, err error) {
+	query := repo.db.WithContext(ctx).Table("ocr").
+		Where("tenant_id = ?", tenantID).
+		Where("created_at < ?", before).
+		Where("deleted_at is null").
+		Update("deleted_at", time.Now())
+	if query.Error != nil {
+		return 0, query.Error
+	}
+	return query.RowsAffected, nil
+}
+
+// filter builds the tenant, text and metadata conditions shared by the list queries
+func (repo *Repository) filter(ctx context.Context, param primitive.ParameterFindOcr) *gorm.DB {
+	query := repo.db.WithContext(ctx).Table("ocr").
+		Where("tenant_id = ?", param.TenantID).
+		Where("deleted_at is null")
+
+	query = filterMetadata(query, param, func(t time.Time) time.Time { return t })
+
 	if param.Text != "" {
-		query = query.Where("text ilike '%?%'", param.Text)
+		query = query.Where("text ilike ?", likePattern(param.Text))
 	}
 
-	err = query.Order("id desc").
-		Find(&result).
-		Error
+	return query
+}
+
+// filterMetadata adds the conditions of every filter but the text, shared by the sql repositories,
+// dbTime converts the bounds of the ranges like the times are stored
+func filterMetadata(query *gorm.DB, param primitive.ParameterFindOcr, dbTime func(time.Time) time.Time) *gorm.DB {
+	if len(param.Statuses) > 0 {
+		query = query.Where("status in ?", param.Statuses)
+	}
+	if param.DocumentType != "" {
+		query = query.Where("document_type = ?", param.DocumentType)
+	}
+	if param.Language != "" {
+		query = query.Where(`('+' || language || '+') like ? escape '\'`, likePattern("+"+param.Language+"+"))
+	}
+	for _, tag := range param.Tags {
+		query = query.Where(`(',' || tags || ',') like ? escape '\'`, likePattern(","+tag+","))
+	}
+	if param.ContentHash != "" {
+		query = query.Where("content_hash = ?", param.ContentHash)
+	}
+	if !param.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", dbTime(param.CreatedFrom))
+	}
+	if !param.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", dbTime(param.CreatedTo))
+	}
+	if !param.UpdatedFrom.IsZero() {
+		query = query.Where("updated_at >= ?", dbTime(param.UpdatedFrom))
+	}
+	if !param.UpdatedTo.IsZero() {
+		query = query.Where("updated_at < ?", dbTime(param.UpdatedTo))
+	}
+	if param.MinConfidence != nil {
+		query = query.Where("confidence >= ?", *param.MinConfidence)
+	}
+	if param.MaxConfidence != nil {
+		query = query.Where("confidence <= ?", *param.MaxConfidence)
+	}
+	return query
+}
+
+// eachRow scans the rows of the query one at a time into fn
+func eachRow(query *gorm.DB, fn func(primitive.Ocr) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ocr primitive.Ocr
+		if err = query.ScanRows(rows, &ocr); err != nil {
+			return err
+		}
+		if err = fn(ocr); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// likePattern matches the text anywhere, the wildcards typed by the caller are matched literally
+func likePattern(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + replacer.Replace(text) + "%"
+}
+
+// sortColumn returns the whitelisted column of orderBy and whether the order is ascending,
+// the default is id descending
+func sortColumn(sortBy, sortOrder string) (column string, ascending bool) {
+	column, ok := sortColumns[strings.ToLower(sortBy)]
+	if !ok {
+		column = "id"
+	}
+	return column, strings.ToLower(sortOrder) == "asc"
+}
+
+// orderClause sorts by the whitelisted column, ties are broken by id so the pages are stable
+func orderClause(sortBy, sortOrder string) string {
+	column, ascending := sortColumn(sortBy, sortOrder)
+	return orderClauseOf(column, ascending)
+}
+
+func orderClauseOf(column string, ascending bool) string {
+	direction := " desc"
+	if ascending {
+		direction = " asc"
+	}
+	if column == "id" {
+		return column + direction
+	}
+	return column + direction + ", id" + direction
+}
+
+// keysetQuery continues the sorted query after the row of the cursor, the order is
+// reversed when the cursor goes backward. The column comes from the whitelist.
+func keysetQuery(query, db *gorm.DB, param primitive.ParameterFindOcr) (*gorm.DB, error) {
+	column, ascending := sortColumn(param.SortBy, param.SortOrder)
+	if param.Cursor == nil {
+		return query.Order(orderClauseOf(column, ascending)), nil
+	}
+
+	row, err := cursorRow(db, param.TenantID, param.Cursor.ID)
 	if err != nil {
 		return nil, err
 	}
+	if param.Cursor.Backward {
+		ascending = !ascending
+	}
+	operator := "<"
+	if ascending {
+		operator = ">"
+	}
 
-	return result, nil
+	if column == "id" {
+		query = query.Where("id "+operator+" ?", row.ID)
+	} else {
+		value := columnValue(row, column)
+		query = query.Where("("+column+" "+operator+" ? or ("+column+" = ? and id "+operator+" ?))", value, value, row.ID)
+	}
+	return query.Order(orderClauseOf(column, ascending)), nil
+}
+
+// cursorRow reads the row of the cursor in the tenant to compare the rows with its sort value,
+// a deleted row still holds its place
+func cursorRow(db *gorm.DB, tenantID string, id int64) (row primitive.Ocr, err error) {
+	err = db.Table("ocr").
+		Where("tenant_id = ?", tenantID).
+		Where("id = ?", id).
+		First(&row).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, primitive.ErrorCursorNotValid
+	}
+	return row, err
 }
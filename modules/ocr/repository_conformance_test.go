@@ -0,0 +1,89 @@
+package ocr_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/database"
+	"go-ocr/infrastructure/migration"
+	"go-ocr/migrations"
+	"go-ocr/modules/ocr"
+	"go-ocr/modules/ocr/ocrtest"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// postgresDsnEnv enables the postgres run, the database is migrated and every subtest uses its own tenant
+const postgresDsnEnv = "GO_OCR_TEST_POSTGRES_DSN"
+
+func TestInMemoryRepositoryConformance(t *testing.T) {
+	ocrtest.RunRepositoryConformance(t, func(t *testing.T) ocr.RepositoryInterface {
+		return ocr.NewInMemoryRepository()
+	})
+}
+
+func TestPersistentInMemoryRepositoryConformance(t *testing.T) {
+	ocrtest.RunRepositoryConformance(t, func(t *testing.T) ocr.RepositoryInterface {
+		repo, err := ocr.NewPersistentInMemoryRepository(t.TempDir(), 0)
+		if err != nil {
+			t.Fatalf("NewPersistentInMemoryRepository() error = %v", err)
+		}
+		if err = repo.LoadFromFile(context.Background()); err != nil {
+			t.Fatalf("LoadFromFile() error = %v", err)
+		}
+		return repo
+	})
+}
+
+func TestSqliteRepositoryConformance(t *testing.T) {
+	conf := &config.Config{Sqlite: config.SqliteConfig{Path: filepath.Join(t.TempDir(), "go-ocr.db")}}
+	db, err := database.NewSqliteClient(conf)
+	if err != nil {
+		t.Fatalf("NewSqliteClient() error = %v", err)
+	}
+	files, err := fs.Sub(migrations.Sqlite, "sqlite")
+	if err != nil {
+		t.Fatalf("fs.Sub() error = %v", err)
+	}
+	migrate(t, db.DbConn, files, migration.DialectSqlite)
+
+	ocrtest.RunRepositoryConformance(t, func(t *testing.T) ocr.RepositoryInterface {
+		return ocr.NewSqliteRepository(db.DbConn)
+	})
+}
+
+func TestPostgresRepositoryConformance(t *testing.T) {
+	dsn := os.Getenv(postgresDsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDsnEnv)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	migrate(t, db, migrations.FS, migration.DialectPostgres)
+
+	ocrtest.RunRepositoryConformance(t, func(t *testing.T) ocr.RepositoryInterface {
+		return ocr.NewRepository(db)
+	})
+}
+
+func migrate(t *testing.T, db *gorm.DB, files fs.FS, dialect string) {
+	t.Helper()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("db.DB() error = %v", err)
+	}
+	runner, err := migration.NewRunner(sqlDB, files, dialect)
+	if err != nil {
+		t.Fatalf("migration.NewRunner() error = %v", err)
+	}
+	if _, err = runner.Up(context.Background()); err != nil {
+		t.Fatalf("runner.Up() error = %v", err)
+	}
+}
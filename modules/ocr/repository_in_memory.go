@@ -2,12 +2,13 @@ package ocr
 
 import (
 	"context"
-	"errors"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"go-ocr/modules/primitive"
+	"go-ocr/utils"
 )
 
 // InMemoryRepository stores OCR data in memory.
@@ -15,6 +16,8 @@ type InMemoryRepository struct {
 	ocrs       []primitive.Ocr
 	idSequence int64
 	mu         sync.RWMutex
+	// persistence is nil unless the data is kept on disk across restarts
+	persistence *persistence
 }
 
 // CreateOcr adds a new OCR entry to the in-memory repository.
@@ -24,45 +27,55 @@ func (i *InMemoryRepository) CreateOcr(ctx context.Context, request primitive.Oc
 
 	// Assign a new ID from the sequence and increment it.
 	request.ID = i.idSequence
+	if request.CreatedAt.IsZero() {
+		request.CreatedAt = time.Now()
+		request.UpdatedAt = request.CreatedAt
+	}
+
+	// Write it to the journal first so nothing is acknowledged without being journaled.
+	if err = i.appendJournal(request); err != nil {
+		return primitive.Ocr{}, err
+	}
 	i.idSequence++
 
 	// Add the OCR entry to the repository.
 	i.ocrs = append(i.ocrs, request)
+	i.compactJournal()
 
 	// Return the newly created OCR entry.
 	return request, nil
 }
 
 // FindOcrByID retrieves an OCR entry by its ID.
-func (i *InMemoryRepository) FindOcrByID(ctx context.Context, id int64) (result primitive.Ocr, err error) {
+func (i *InMemoryRepository) FindOcrByID(ctx context.Context, tenantID string, id int64) (result primitive.Ocr, err error) {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 
 	// Search for the OCR entry with the matching ID.
 	for _, ocr := range i.ocrs {
-		if ocr.ID == id {
+		if ocr.ID == id && ocr.TenantID == tenantID && ocr.DeletedAt.IsZero() {
 			return ocr, nil
 		}
 	}
 
 	// Return an error if not found.
-	return primitive.Ocr{}, errors.New("OCR entry not found")
+	return primitive.Ocr{}, primitive.ErrorArticleNotFound
 }
 
 // FindOcrByText retrieves an OCR entry by matching its text.
-func (i *InMemoryRepository) FindOcrByText(ctx context.Context, text string) (result primitive.Ocr, err error) {
+func (i *InMemoryRepository) FindOcrByText(ctx context.Context, tenantID string, text string) (result primitive.Ocr, err error) {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 
-	// Search for the OCR entry with the matching text.
+	// Search for the first OCR entry containing the text.
 	for _, ocr := range i.ocrs {
-		if ocr.Text == text {
+		if ocr.TenantID == tenantID && ocr.DeletedAt.IsZero() && containsFold(ocr.Text, text) {
 			return ocr, nil
 		}
 	}
 
 	// Return an error if not found.
-	return primitive.Ocr{}, errors.New("OCR entry not found")
+	return primitive.Ocr{}, primitive.ErrorArticleNotFound
 }
 
 // FindAllListOcrPagination returns a paginated and filtered list of OCR entries.
@@ -70,40 +83,11 @@ func (i *InMemoryRepository) FindAllListOcrPagination(ctx context.Context, param
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 
-	// Filter based on Text and Status
-	filtered := make([]primitive.Ocr, 0)
-	for _, ocr := range i.ocrs {
-		if (param.Text == "" || strings.Contains(ocr.Text, param.Text)) &&
-			(param.Status == "" || ocr.Status == param.Status) && ocr.DeletedAt.IsZero() {
-			filtered = append(filtered, ocr)
-		}
-	}
+	// Filter based on Tenant, Text and Status
+	filtered := i.filter(param)
 
-	// Sort by the requested field and order
-	if param.SortBy != "" {
-		switch param.SortBy {
-		case "Text":
-			if param.SortOrder == "asc" {
-				sort.Slice(filtered, func(i, j int) bool {
-					return filtered[i].Text < filtered[j].Text
-				})
-			} else {
-				sort.Slice(filtered, func(i, j int) bool {
-					return filtered[i].Text > filtered[j].Text
-				})
-			}
-		case "ID":
-			if param.SortOrder == "asc" {
-				sort.Slice(filtered, func(i, j int) bool {
-					return filtered[i].ID < filtered[j].ID
-				})
-			} else {
-				sort.Slice(filtered, func(i, j int) bool {
-					return filtered[i].ID > filtered[j].ID
-				})
-			}
-		}
-	}
+	// Sort by the whitelisted field and order, ties are broken by id
+	sortOcrs(filtered, param.SortBy, param.SortOrder)
 
 	// Apply pagination
 	start := param.Offset
@@ -120,18 +104,74 @@ func (i *InMemoryRepository) FindAllListOcrPagination(ctx context.Context, param
 	return filtered[start:end], nil
 }
 
-// CountAllListOcr counts the total number of OCR entries in the repository.
-func (i *InMemoryRepository) CountAllListOcr(ctx context.Context, param primitive.ParameterFindOcr) (count int64, err error) {
+// FindAllListOcrKeyset returns the page size entries following the cursor in the sort order,
+// or preceding it when the cursor goes backward, the entries nearest to the cursor first.
+func (i *InMemoryRepository) FindAllListOcrKeyset(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error) {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 
-	// Filter based on Text and Status
-	filtered := make([]primitive.Ocr, 0)
+	// Filter based on Tenant, Text and Status
+	filtered := i.filter(param)
+	sortOcrs(filtered, param.SortBy, param.SortOrder)
+	if param.Cursor == nil {
+		if len(filtered) > param.PageSize {
+			filtered = filtered[:param.PageSize]
+		}
+		return filtered, nil
+	}
+
+	// the sort value is read from the row of the cursor, a deleted row still holds its place
+	row, found := primitive.Ocr{}, false
 	for _, ocr := range i.ocrs {
-		if (param.Text == "" || strings.Contains(ocr.Text, param.Text)) && (param.Status == "" || ocr.Status == param.Status) && ocr.DeletedAt.IsZero() {
-			filtered = append(filtered, ocr)
+		if ocr.ID == param.Cursor.ID && ocr.TenantID == param.TenantID {
+			row, found = ocr, true
+			break
 		}
 	}
+	if !found {
+		return nil, primitive.ErrorCursorNotValid
+	}
+	column, ascending := sortColumn(param.SortBy, param.SortOrder)
+	// after reports whether the entry comes after the cursor row in the sort order
+	after := func(ocr primitive.Ocr) bool {
+		first, second := row, ocr
+		if !ascending {
+			first, second = second, first
+		}
+		less, equal := compareOcr(first, second, column)
+		if equal {
+			return first.ID < second.ID
+		}
+		return less
+	}
+
+	result = make([]primitive.Ocr, 0, param.PageSize)
+	if param.Cursor.Backward {
+		for idx := len(filtered) - 1; idx >= 0 && len(result) < param.PageSize; idx-- {
+			if filtered[idx].ID != row.ID && !after(filtered[idx]) {
+				result = append(result, filtered[idx])
+			}
+		}
+		return result, nil
+	}
+	for _, ocr := range filtered {
+		if len(result) == param.PageSize {
+			break
+		}
+		if after(ocr) {
+			result = append(result, ocr)
+		}
+	}
+	return result, nil
+}
+
+// CountAllListOcr counts the total number of OCR entries in the repository.
+func (i *InMemoryRepository) CountAllListOcr(ctx context.Context, param primitive.ParameterFindOcr) (count int64, err error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	// Filter based on Tenant, Text and Status
+	filtered := i.filter(param)
 
 	// Return the count of filtered results.
 	return int64(len(filtered)), nil
@@ -142,17 +182,150 @@ func (i *InMemoryRepository) FindAllListOcrNonPagination(ctx context.Context, pa
 	i.mu.RLock()
 	defer i.mu.RUnlock()
 
-	// Filter based on Text and Status
+	// Filter based on Tenant, Text and Status
+	filtered := i.filter(param)
+
+	// Return the filtered list without pagination, the newest first.
+	sort.SliceStable(filtered, func(a, b int) bool {
+		return filtered[a].ID > filtered[b].ID
+	})
+	return filtered, nil
+}
+
+// EachOcr calls fn for every sorted entry matching the filters, stopping at the first error.
+func (i *InMemoryRepository) EachOcr(ctx context.Context, param primitive.ParameterFindOcr, fn func(primitive.Ocr) error) (err error) {
+	// Take a snapshot so fn runs without holding the lock.
+	i.mu.RLock()
+	filtered := i.filter(param)
+	i.mu.RUnlock()
+
+	sortOcrs(filtered, param.SortBy, param.SortOrder)
+	for _, ocr := range filtered {
+		// stop like the database does when the context is done
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = fn(ocr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteOcrCreatedBefore soft deletes the OCR entries of the tenant created before the given time.
+func (i *InMemoryRepository) DeleteOcrCreatedBefore(ctx context.Context, tenantID string, before time.Time) (count int64, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	deleted := make([]primitive.Ocr, 0)
+	indexes := make([]int, 0)
+	for idx, ocr := range i.ocrs {
+		if ocr.TenantID == tenantID && ocr.CreatedAt.Before(before) && ocr.DeletedAt.IsZero() {
+			ocr.DeletedAt = now
+			deleted = append(deleted, ocr)
+			indexes = append(indexes, idx)
+		}
+	}
+
+	// Write them to the journal first like CreateOcr, a failed write deletes nothing.
+	if err = i.appendJournal(deleted...); err != nil {
+		return 0, err
+	}
+	for n, idx := range indexes {
+		i.ocrs[idx] = deleted[n]
+	}
+	i.compactJournal()
+
+	return int64(len(deleted)), nil
+}
+
+// filter returns the live OCR entries of the tenant matching the filters, the caller must hold the lock.
+func (i *InMemoryRepository) filter(param primitive.ParameterFindOcr) []primitive.Ocr {
 	filtered := make([]primitive.Ocr, 0)
 	for _, ocr := range i.ocrs {
-		if (param.Text == "" || strings.Contains(ocr.Text, param.Text)) &&
-			(param.Status == "" || ocr.Status == param.Status) && ocr.DeletedAt.IsZero() {
+		if ocr.TenantID == param.TenantID && ocr.DeletedAt.IsZero() &&
+			(param.Text == "" || containsFold(ocr.Text, param.Text)) &&
+			matchMetadata(ocr, param) {
 			filtered = append(filtered, ocr)
 		}
 	}
+	return filtered
+}
 
-	// Return the filtered list without pagination.
-	return filtered, nil
+// matchMetadata reports whether the OCR entry matches every filter but the text, like filterMetadata.
+func matchMetadata(ocr primitive.Ocr, param primitive.ParameterFindOcr) bool {
+	if len(param.Statuses) > 0 && !utils.Contains(param.Statuses, ocr.Status) {
+		return false
+	}
+	if param.DocumentType != "" && ocr.DocumentType != param.DocumentType {
+		return false
+	}
+	if param.Language != "" && !utils.Contains(strings.Split(ocr.Language, "+"), param.Language) {
+		return false
+	}
+	for _, tag := range param.Tags {
+		if !utils.Contains(splitTags(ocr.Tags), tag) {
+			return false
+		}
+	}
+	if param.ContentHash != "" && ocr.ContentHash != param.ContentHash {
+		return false
+	}
+	if (!param.CreatedFrom.IsZero() && ocr.CreatedAt.Before(param.CreatedFrom)) ||
+		(!param.CreatedTo.IsZero() && !ocr.CreatedAt.Before(param.CreatedTo)) ||
+		(!param.UpdatedFrom.IsZero() && ocr.UpdatedAt.Before(param.UpdatedFrom)) ||
+		(!param.UpdatedTo.IsZero() && !ocr.UpdatedAt.Before(param.UpdatedTo)) {
+		return false
+	}
+	if (param.MinConfidence != nil && ocr.Confidence < *param.MinConfidence) ||
+		(param.MaxConfidence != nil && ocr.Confidence > *param.MaxConfidence) {
+		return false
+	}
+	return true
+}
+
+// sortOcrs sorts by the whitelisted field and order, ties are broken by id.
+func sortOcrs(ocrs []primitive.Ocr, sortBy, sortOrder string) {
+	column, ascending := sortColumn(sortBy, sortOrder)
+	sort.SliceStable(ocrs, func(a, b int) bool {
+		first, second := ocrs[a], ocrs[b]
+		if !ascending {
+			first, second = second, first
+		}
+		less, equal := compareOcr(first, second, column)
+		if equal {
+			return first.ID < second.ID
+		}
+		return less
+	})
+}
+
+// compareOcr compares two OCR entries on the given column.
+func compareOcr(a, b primitive.Ocr, column string) (less bool, equal bool) {
+	switch column {
+	case "text":
+		return a.Text < b.Text, a.Text == b.Text
+	case "status":
+		return a.Status < b.Status, a.Status == b.Status
+	case "document_type":
+		return a.DocumentType < b.DocumentType, a.DocumentType == b.DocumentType
+	case "language":
+		return a.Language < b.Language, a.Language == b.Language
+	case "confidence":
+		return a.Confidence < b.Confidence, a.Confidence == b.Confidence
+	case "created_at":
+		return a.CreatedAt.Before(b.CreatedAt), a.CreatedAt.Equal(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Before(b.UpdatedAt), a.UpdatedAt.Equal(b.UpdatedAt)
+	default:
+		return a.ID < b.ID, a.ID == b.ID
+	}
+}
+
+// containsFold reports whether substr is within s, ignoring the case like ilike.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
 
 // NewInMemoryRepository creates a new instance of InMemoryRepository.
@@ -0,0 +1,322 @@
+package ocr
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go-ocr/modules/primitive"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	snapshotFileName     = "ocr.snapshot.json"
+	journalFileName      = "ocr.journal"
+	defaultFsyncInterval = time.Second
+	// the journal is compacted into a new snapshot once it holds this many entries or bytes
+	snapshotEveryEntries = 10000
+	snapshotEveryBytes   = 64 << 20
+)
+
+// PersistentRepository is implemented by the repositories that keep their data
+// in memory and need to be saved and loaded around a restart.
+type PersistentRepository interface {
+	SaveToFile(ctx context.Context) (err error)
+	LoadFromFile(ctx context.Context) (err error)
+}
+
+// snapshot is the full state of the repository at the time it was saved.
+type snapshot struct {
+	IDSequence int64           `json:"idSequence"`
+	Ocrs       []primitive.Ocr `json:"ocrs"`
+}
+
+// journalEntry is one append only write, every entry is the latest state of the OCR entry.
+type journalEntry struct {
+	Ocr primitive.Ocr `json:"ocr"`
+}
+
+// persistence writes the snapshot and the journal of the in-memory repository,
+// the caller must hold the repository lock.
+type persistence struct {
+	dir           string
+	fsyncInterval time.Duration
+	journal       *os.File
+	writer        *bufio.Writer
+	dirty         bool
+	// entries and size are what the journal holds since the last snapshot, it is compacted
+	// once they reach snapshotEntries or snapshotSize
+	entries         int
+	size            int64
+	snapshotEntries int
+	snapshotSize    int64
+	// stop ends syncPeriodically, stopped is closed once it returned
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPersistentInMemoryRepository creates an InMemoryRepository that writes every change
+// to a journal on the given directory, call LoadFromFile before using it.
+func NewPersistentInMemoryRepository(dir string, fsyncInterval time.Duration) (*InMemoryRepository, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if fsyncInterval <= 0 {
+		fsyncInterval = defaultFsyncInterval
+	}
+
+	repo := NewInMemoryRepository()
+	repo.persistence = &persistence{
+		dir:             dir,
+		fsyncInterval:   fsyncInterval,
+		snapshotEntries: snapshotEveryEntries,
+		snapshotSize:    snapshotEveryBytes,
+	}
+	return repo, nil
+}
+
+// LoadFromFile restores the snapshot, replays the journal written after it and
+// opens the journal for the next writes.
+func (i *InMemoryRepository) LoadFromFile(ctx context.Context) (err error) {
+	if i.persistence == nil {
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var state snapshot
+	content, err := os.ReadFile(filepath.Join(i.persistence.dir, snapshotFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(content) > 0 {
+		if err = json.Unmarshal(content, &state); err != nil {
+			return err
+		}
+	}
+
+	byID := make(map[int64]int, len(state.Ocrs))
+	for idx, ocr := range state.Ocrs {
+		byID[ocr.ID] = idx
+	}
+
+	journalPath := filepath.Join(i.persistence.dir, journalFileName)
+	journal, err := os.OpenFile(journalPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+
+	// replay the journal. Only a write torn by a crash, the last line without its newline, is cut off
+	// so the next append starts clean. A corrupted entry before it stops the start up with the
+	// journal untouched, dropping it would lose the entries written after it.
+	var validSize int64
+	lineNumber := 0
+	reader := bufio.NewReader(journal)
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if errRead != nil {
+			if !errors.Is(errRead, io.EOF) {
+				_ = journal.Close()
+				return errRead
+			}
+			if len(line) > 0 {
+				log.Warnf("discard incomplete entry at the end of %s", journalPath)
+			}
+			break
+		}
+		lineNumber++
+
+		var entry journalEntry
+		if err = json.Unmarshal(line, &entry); err != nil {
+			_ = journal.Close()
+			return fmt.Errorf("%w: %s line %d: %v", primitive.ErrorJournalCorrupted, journalPath, lineNumber, err)
+		}
+		validSize += int64(len(line))
+
+		if idx, ok := byID[entry.Ocr.ID]; ok {
+			state.Ocrs[idx] = entry.Ocr
+		} else {
+			byID[entry.Ocr.ID] = len(state.Ocrs)
+			state.Ocrs = append(state.Ocrs, entry.Ocr)
+		}
+	}
+	if err = journal.Truncate(validSize); err != nil {
+		_ = journal.Close()
+		return err
+	}
+	if _, err = journal.Seek(validSize, io.SeekStart); err != nil {
+		_ = journal.Close()
+		return err
+	}
+
+	// the next id must be after the highest one even when the snapshot is older than the journal
+	i.ocrs = state.Ocrs
+	if i.ocrs == nil {
+		i.ocrs = make([]primitive.Ocr, 0)
+	}
+	i.idSequence = state.IDSequence
+	for _, ocr := range i.ocrs {
+		if ocr.ID >= i.idSequence {
+			i.idSequence = ocr.ID + 1
+		}
+	}
+	if i.idSequence < 1 {
+		i.idSequence = 1
+	}
+
+	i.persistence.journal = journal
+	i.persistence.writer = bufio.NewWriter(journal)
+
+	// fold the replayed journal into a new snapshot so the next start doesn't replay it again
+	if lineNumber > 0 {
+		if err = i.writeSnapshot(); err != nil {
+			return err
+		}
+	}
+
+	i.persistence.stop = make(chan struct{})
+	i.persistence.stopped = make(chan struct{})
+	go i.syncPeriodically(i.persistence.stop, i.persistence.stopped)
+
+	log.Infof("loaded %d ocr entries from %s", len(i.ocrs), i.persistence.dir)
+	return nil
+}
+
+// SaveToFile is called on shutdown, it stops the periodic fsync and writes the whole state
+// to a new snapshot. The journal stays open so a late write is still journaled.
+func (i *InMemoryRepository) SaveToFile(ctx context.Context) (err error) {
+	if i.persistence == nil {
+		return nil
+	}
+
+	i.mu.Lock()
+	stop, stopped := i.persistence.stop, i.persistence.stopped
+	i.mu.Unlock()
+	if stop != nil {
+		i.persistence.stopOnce.Do(func() { close(stop) })
+		<-stopped
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.writeSnapshot()
+}
+
+// writeSnapshot writes the whole state to a new snapshot and empties the journal, the caller
+// must hold the write lock.
+func (i *InMemoryRepository) writeSnapshot() (err error) {
+	content, err := json.Marshal(snapshot{IDSequence: i.idSequence, Ocrs: i.ocrs})
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash never leaves a half written snapshot
+	snapshotPath := filepath.Join(i.persistence.dir, snapshotFileName)
+	tmp, err := os.CreateTemp(i.persistence.dir, snapshotFileName+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Sync()
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), snapshotPath)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	// everything in the journal is now part of the snapshot
+	if i.persistence.journal != nil {
+		i.persistence.writer.Reset(i.persistence.journal)
+		if err = i.persistence.journal.Truncate(0); err != nil {
+			return err
+		}
+		if _, err = i.persistence.journal.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		i.persistence.dirty = false
+		i.persistence.entries = 0
+		i.persistence.size = 0
+		return i.persistence.journal.Sync()
+	}
+	return nil
+}
+
+// appendJournal writes the latest state of the entries, the caller must hold the write lock.
+func (i *InMemoryRepository) appendJournal(ocrs ...primitive.Ocr) error {
+	if i.persistence == nil || i.persistence.writer == nil {
+		return nil
+	}
+
+	for _, ocr := range ocrs {
+		line, err := json.Marshal(journalEntry{Ocr: ocr})
+		if err != nil {
+			return err
+		}
+		written, err := i.persistence.writer.Write(append(line, '\n'))
+		if err != nil {
+			return err
+		}
+		i.persistence.entries++
+		i.persistence.size += int64(written)
+	}
+	i.persistence.dirty = true
+	return i.persistence.writer.Flush()
+}
+
+// compactJournal folds the journal into a new snapshot once it is long enough, the caller must
+// hold the write lock and call it after the journaled change is applied in memory.
+func (i *InMemoryRepository) compactJournal() {
+	if i.persistence == nil || i.persistence.writer == nil {
+		return
+	}
+	if i.persistence.entries < i.persistence.snapshotEntries && i.persistence.size < i.persistence.snapshotSize {
+		return
+	}
+
+	// the entries are journaled, a failed compaction only leaves the journal longer
+	if err := i.writeSnapshot(); err != nil {
+		log.Errorf("failed compact ocr journal: %v", err)
+	}
+}
+
+// syncPeriodically fsync the journal until stop is closed, a crash loses at most the writes of one interval.
+func (i *InMemoryRepository) syncPeriodically(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	ticker := time.NewTicker(i.persistence.fsyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			i.mu.Lock()
+			if i.persistence.dirty {
+				if err := i.persistence.journal.Sync(); err != nil {
+					log.Errorf("failed sync ocr journal: %v", err)
+				} else {
+					i.persistence.dirty = false
+				}
+			}
+			i.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-ocr/modules/primitive"
+)
+
+// journalLine returns the journal entry of the OCR entry as appendJournal writes it
+func journalLine(t *testing.T, ocr primitive.Ocr) []byte {
+	t.Helper()
+	line, err := json.Marshal(journalEntry{Ocr: ocr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(line, '\n')
+}
+
+func TestLoadFromFileJournalRecovery(t *testing.T) {
+	first := primitive.Ocr{ID: 1, TenantID: "acme", Text: "first"}
+	second := primitive.Ocr{ID: 2, TenantID: "acme", Text: "second"}
+	updatedFirst := primitive.Ocr{ID: 1, TenantID: "acme", Text: "first updated"}
+
+	tests := []struct {
+		name     string
+		snapshot *snapshot
+		journal  func(t *testing.T) []byte
+		// keptSize is the size of the journal after the load, -1 keeps the whole journal. A replayed
+		// journal is folded into the snapshot and emptied.
+		keptSize int
+		want     []string
+		wantErr  error
+	}{
+		{
+			name: "clean journal",
+			journal: func(t *testing.T) []byte {
+				return append(journalLine(t, first), journalLine(t, second)...)
+			},
+			want: []string{"first", "second"},
+		},
+		{
+			name:     "journal after the snapshot",
+			snapshot: &snapshot{IDSequence: 2, Ocrs: []primitive.Ocr{first}},
+			journal: func(t *testing.T) []byte {
+				return append(journalLine(t, updatedFirst), journalLine(t, second)...)
+			},
+			want: []string{"first updated", "second"},
+		},
+		{
+			name: "torn final line is cut off",
+			journal: func(t *testing.T) []byte {
+				torn := journalLine(t, second)
+				return append(journalLine(t, first), torn[:len(torn)/2]...)
+			},
+			want: []string{"first"},
+		},
+		{
+			name: "corrupted line in the middle keeps the journal",
+			journal: func(t *testing.T) []byte {
+				journal := append(journalLine(t, first), []byte("{\"ocr\":garbage}\n")...)
+				return append(journal, journalLine(t, second)...)
+			},
+			keptSize: -1,
+			wantErr:  primitive.ErrorJournalCorrupted,
+		},
+		{
+			name: "corrupted complete final line keeps the journal",
+			journal: func(t *testing.T) []byte {
+				return append(journalLine(t, first), []byte("garbage\n")...)
+			},
+			keptSize: -1,
+			wantErr:  primitive.ErrorJournalCorrupted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.snapshot != nil {
+				content, err := json.Marshal(tt.snapshot)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = os.WriteFile(filepath.Join(dir, snapshotFileName), content, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			journal := tt.journal(t)
+			journalPath := filepath.Join(dir, journalFileName)
+			if err := os.WriteFile(journalPath, journal, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			repo, err := NewPersistentInMemoryRepository(dir, 0)
+			if err != nil {
+				t.Fatalf("NewPersistentInMemoryRepository() error = %v", err)
+			}
+			err = repo.LoadFromFile(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromFile() error = %v, want %v", err, tt.wantErr)
+			}
+
+			kept, errRead := os.ReadFile(journalPath)
+			if errRead != nil {
+				t.Fatal(errRead)
+			}
+			wantKept := journal
+			if tt.keptSize >= 0 {
+				wantKept = journal[:tt.keptSize]
+			}
+			if !bytes.Equal(kept, wantKept) {
+				t.Fatalf("the journal is %q after the load, want %q", kept, wantKept)
+			}
+			if err != nil {
+				return
+			}
+
+			got := make([]string, 0, len(repo.ocrs))
+			for _, ocr := range repo.ocrs {
+				got = append(got, ocr.Text)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for idx := range got {
+				if got[idx] != tt.want[idx] {
+					t.Fatalf("got %q, want %q", got, tt.want)
+				}
+			}
+
+			// the next entry gets a new id and survives a restart
+			created, err := repo.CreateOcr(context.Background(), primitive.Ocr{TenantID: "acme", Text: "next"})
+			if err != nil {
+				t.Fatalf("CreateOcr() error = %v", err)
+			}
+			if created.ID != int64(len(tt.want)+1) {
+				t.Fatalf("CreateOcr() id = %d, want %d", created.ID, len(tt.want)+1)
+			}
+			reloaded, err := NewPersistentInMemoryRepository(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = reloaded.LoadFromFile(context.Background()); err != nil {
+				t.Fatalf("LoadFromFile() after the append error = %v", err)
+			}
+			if _, err = reloaded.FindOcrByID(context.Background(), "acme", created.ID); err != nil {
+				t.Fatalf("FindOcrByID() after the reload error = %v", err)
+			}
+			if len(reloaded.ocrs) != len(tt.want)+1 {
+				t.Fatalf("got %d entries after the reload, want %d", len(reloaded.ocrs), len(tt.want)+1)
+			}
+		})
+	}
+}
+
+func TestDeleteOcrCreatedBeforeJournalFirst(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewPersistentInMemoryRepository(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewPersistentInMemoryRepository() error = %v", err)
+	}
+	if err = repo.LoadFromFile(ctx); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	created, err := repo.CreateOcr(ctx, primitive.Ocr{TenantID: "acme", Text: "old"})
+	if err != nil {
+		t.Fatalf("CreateOcr() error = %v", err)
+	}
+
+	// a journal that can't be written must not delete anything
+	if err = repo.persistence.journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	count, err := repo.DeleteOcrCreatedBefore(ctx, "acme", created.CreatedAt.Add(time.Second))
+	if err == nil || count != 0 {
+		t.Fatalf("DeleteOcrCreatedBefore() = %d, %v, want an error", count, err)
+	}
+	if _, err = repo.FindOcrByID(ctx, "acme", created.ID); err != nil {
+		t.Fatalf("FindOcrByID() after the failed delete error = %v", err)
+	}
+}
+
+func TestJournalCompaction(t *testing.T) {
+	tests := []struct {
+		name            string
+		snapshotEntries int
+		snapshotSize    int64
+		writes          int
+		wantEntries     int
+	}{
+		{name: "below the limits", snapshotEntries: 10, snapshotSize: 1 << 20, writes: 3, wantEntries: 3},
+		{name: "entries limit", snapshotEntries: 2, snapshotSize: 1 << 20, writes: 3, wantEntries: 1},
+		{name: "size limit", snapshotEntries: 10, snapshotSize: 1, writes: 3, wantEntries: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			repo, err := NewPersistentInMemoryRepository(dir, 0)
+			if err != nil {
+				t.Fatalf("NewPersistentInMemoryRepository() error = %v", err)
+			}
+			if err = repo.LoadFromFile(ctx); err != nil {
+				t.Fatalf("LoadFromFile() error = %v", err)
+			}
+			repo.persistence.snapshotEntries = tt.snapshotEntries
+			repo.persistence.snapshotSize = tt.snapshotSize
+
+			for idx := 0; idx < tt.writes; idx++ {
+				if _, err = repo.CreateOcr(ctx, primitive.Ocr{TenantID: "acme", Text: "entry"}); err != nil {
+					t.Fatalf("CreateOcr() error = %v", err)
+				}
+			}
+
+			journal, err := os.ReadFile(filepath.Join(dir, journalFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := bytes.Count(journal, []byte("\n")); got != tt.wantEntries {
+				t.Fatalf("the journal holds %d entries, want %d", got, tt.wantEntries)
+			}
+
+			// the snapshot and the journal left hold every write
+			reloaded, err := NewPersistentInMemoryRepository(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = reloaded.LoadFromFile(ctx); err != nil {
+				t.Fatalf("LoadFromFile() error = %v", err)
+			}
+			if len(reloaded.ocrs) != tt.writes {
+				t.Fatalf("got %d entries after the reload, want %d", len(reloaded.ocrs), tt.writes)
+			}
+		})
+	}
+}
+
+func TestSaveToFileStopsSync(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewPersistentInMemoryRepository(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewPersistentInMemoryRepository() error = %v", err)
+	}
+	if err = repo.LoadFromFile(ctx); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		if err = repo.SaveToFile(ctx); err != nil {
+			t.Fatalf("SaveToFile() error = %v", err)
+		}
+	}
+	select {
+	case <-repo.persistence.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the periodic sync is still running after SaveToFile()")
+	}
+
+	// a late write is still journaled
+	if _, err = repo.CreateOcr(ctx, primitive.Ocr{TenantID: "acme", Text: "late"}); err != nil {
+		t.Fatalf("CreateOcr() after SaveToFile() error = %v", err)
+	}
+}
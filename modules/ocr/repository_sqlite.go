@@ -0,0 +1,161 @@
+package ocr
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-ocr/modules/primitive"
+
+	"gorm.io/gorm"
+)
+
+// ftsTrigramLength is the shortest text the trigram tokenizer of ocr_fts can match
+const ftsTrigramLength = 3
+
+type SqliteRepository struct {
+	db *gorm.DB
+}
+
+func NewSqliteRepository(db *gorm.DB) *SqliteRepository {
+	return &SqliteRepository{
+		db: db,
+	}
+}
+
+func (repo *SqliteRepository) CreateOcr(ctx context.Context, request primitive.Ocr) (result primitive.Ocr, err error) {
+	// the times are stored as text so they are kept in utc to compare them in order
+	if request.CreatedAt.IsZero() {
+		request.CreatedAt = time.Now()
+		request.UpdatedAt = request.CreatedAt
+	}
+	request.CreatedAt = request.CreatedAt.UTC()
+	request.UpdatedAt = request.UpdatedAt.UTC()
+
+	err = repo.db.WithContext(ctx).Table("ocr").Omit("deleted_at").Create(&request).Error
+	if err != nil {
+		return result, err
+	}
+	return request, nil
+}
+
+func (repo *SqliteRepository) FindOcrByID(ctx context.Context, tenantID string, id int64) (result primitive.Ocr, err error) {
+	err = repo.db.WithContext(ctx).Table("ocr").
+		Where("tenant_id = ?", tenantID).
+		Where("id = ?", id).
+		Where("deleted_at is null").
+		First(&result).
+		Error
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (repo *SqliteRepository) FindOcrByText(ctx context.Context, tenantID string, text string) (result primitive.Ocr, err error) {
+	condition, arg := textCondition(text)
+	err = repo.db.WithContext(ctx).Table("ocr").
+		Where("tenant_id = ?", tenantID).
+		Where(condition, arg).
+		Where("deleted_at is null").
+		Order("id asc").
+		First(&result).
+		Error
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (repo *SqliteRepository) FindAllListOcrPagination(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error) {
+	err = repo.filter(ctx, param).
+		Offset(param.Offset).
+		Limit(param.PageSize).
+		Order(orderClause(param.SortBy, param.SortOrder)).
+		Find(&result).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (repo *SqliteRepository) FindAllListOcrKeyset(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error) {
+	query, err := keysetQuery(repo.filter(ctx, param), repo.db.WithContext(ctx), param)
+	if err != nil {
+		return nil, err
+	}
+
+	err = query.Limit(param.PageSize).Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (repo *SqliteRepository) CountAllListOcr(ctx context.Context, param primitive.ParameterFindOcr) (count int64, err error) {
+	err = repo.filter(ctx, param).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *SqliteRepository) FindAllListOcrNonPagination(ctx context.Context, param primitive.ParameterFindOcr) (result []primitive.Ocr, err error) {
+	err = repo.filter(ctx, param).
+		Order("id desc").
+		Find(&result).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (repo *SqliteRepository) EachOcr(ctx context.Context, param primitive.ParameterFindOcr, fn func(primitive.Ocr) error) (err error) {
+	return eachRow(repo.filter(ctx, param).Order(orderClause(param.SortBy, param.SortOrder)), fn)
+}
+
+func (repo *SqliteRepository) DeleteOcrCreatedBefore(ctx context.Context, tenantID string, before time.Time) (count int64, err error) {
+	query := repo.db.WithContext(ctx).Table("ocr").
+		Where("tenant_id = ?", tenantID).
+		Where("created_at < ?", before.UTC()).
+		Where("deleted_at is null").
+		Update("deleted_at", time.Now().UTC())
+	if query.Error != nil {
+		return 0, query.Error
+	}
+	return query.RowsAffected, nil
+}
+
+// filter builds the tenant, full text and metadata conditions shared by the list queries
+func (repo *SqliteRepository) filter(ctx context.Context, param primitive.ParameterFindOcr) *gorm.DB {
+	query := repo.db.WithContext(ctx).Table("ocr").
+		Where("tenant_id = ?", param.TenantID).
+		Where("deleted_at is null")
+
+	// the times are stored as utc text
+	query = filterMetadata(query, param, time.Time.UTC)
+
+	if param.Text != "" {
+		condition, arg := textCondition(param.Text)
+		query = query.Where(condition, arg)
+	}
+
+	return query
+}
+
+// textCondition matches the text as a substring like ilike, the trigram fts index needs at least
+// three characters so a shorter text is matched with like, which only folds the ascii case
+func textCondition(text string) (condition string, arg string) {
+	if utf8.RuneCountInString(text) < ftsTrigramLength {
+		return `text like ? escape '\'`, likePattern(text)
+	}
+	// a quoted phrase is never parsed as fts5 syntax
+	return "id in (select rowid from ocr_fts where ocr_fts match ?)", `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
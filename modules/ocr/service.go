@@ -2,86 +2,215 @@ package ocr
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"image"
 	"io"
+	"math"
 	"mime/multipart"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"go-ocr/infrastructure/auth"
 	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/events"
+	"go-ocr/infrastructure/imaging"
 	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/metrics"
 	redisLocal "go-ocr/infrastructure/redis"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/infrastructure/tracing"
 	"go-ocr/modules/primitive"
+	"go-ocr/modules/template"
 	"go-ocr/utils"
 
 	"github.com/otiai10/gosseract/v2"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
-	redisFinaleKeyOcr     = "ocr:%d"
+	redisFinaleKeyOcr     = "ocr:%s:%d"
 	redisListFinaleKeyOcr = "ocr_list"
+	eventsTopicOcr        = "ocr:%s:%d:events"
+	metricsDefaultPsm     = "default"
+	defaultCacheTTL       = time.Minute
+	// maxUploadExtensionLength is the longest extension kept in the name of a stored upload, with its dot
+	maxUploadExtensionLength = 10
 )
 
 type ServiceInterface interface {
 	ProcessOcr(ctx context.Context, payload primitive.OcrRequest, file multipart.File, fileHeader *multipart.FileHeader) (primitive.OCrResponse, error)
+	ProcessImage(ctx context.Context, payload primitive.OcrRequest, fileName string, image io.Reader) (primitive.OCrResponse, error)
+	RecognizeFile(ctx context.Context, imagePath string, options primitive.RecognizeOptions, store bool) (primitive.RecognizeResponse, error)
 	ListOcr(ctx context.Context, isDisablePagination bool, param primitive.ParameterFindOcr) (res []primitive.OCrResponse, count int64, err error)
+	ListOcrCursor(ctx context.Context, param primitive.ParameterFindOcr) (primitive.OcrCursorPage, error)
 	GetRecordOcrById(ctx context.Context, id int64) (primitive.OCrResponse, error)
+	GetOcrDocument(ctx context.Context, id int64) (primitive.OcrDocument, error)
+	PurgeExpiredOcr(ctx context.Context) (count int64, err error)
+	SubscribeOcrEvents(ctx context.Context, id int64, lastEventID int64) (<-chan events.Event, error)
+	ExportOcr(ctx context.Context, param primitive.ParameterFindOcr, format string, w io.Writer) (rows int64, err error)
+	CreateExportOcr(ctx context.Context, param primitive.ParameterFindOcr, format string) (primitive.OcrExportResponse, error)
+	GetExportOcr(ctx context.Context, id string) (primitive.OcrExportResponse, error)
+	OpenExportOcr(ctx context.Context, id string) (primitive.OcrExport, error)
+	UploadPath(ctx context.Context, fileName string) (string, error)
+	PurgeExpiredExports(ctx context.Context) (count int64, err error)
+	CreateBatchOcr(ctx context.Context, payload primitive.OcrRequest, files []*multipart.FileHeader) (primitive.OcrBatchResponse, error)
+	GetBatchOcr(ctx context.Context, id string) (primitive.OcrBatchResponse, error)
+	SubscribeBatchEvents(ctx context.Context, id string, lastEventID int64) (<-chan events.Event, error)
+	PurgeExpiredBatches(ctx context.Context) (count int64, err error)
+	DrainJobs(ctx context.Context)
+	SaveToFile(ctx context.Context) (err error)
+	LoadFromFile(ctx context.Context) (err error)
 }
 
 type Service struct {
 	repository       RepositoryInterface
 	redisInterface   redisLocal.LibInterface
+	broker           events.Broker
+	templates        template.RepositoryInterface
 	tesseractsClient *gosseract.Client
+	// engineMu serialize the access to the tesseract client, it holds the
+	// image of the running recognition so it can't be shared concurrently
+	engineMu sync.Mutex
+	// exports are the background exports of this process, they are only known by the
+	// instance that runs them and are lost on restart
+	exports   map[string]*primitive.OcrExport
+	exportsMu sync.RWMutex
+	// batches are the batches recognized in the background by this process, like the
+	// exports they are only known by the instance that runs them and are lost on restart
+	batches   map[string]*primitive.OcrBatch
+	batchesMu sync.RWMutex
+	// jobs cancel the running background jobs, they are drained on shutdown
+	jobs        map[string]context.CancelFunc
+	jobsMu      sync.Mutex
+	jobsRunning sync.WaitGroup
+	jobsStopped bool
 }
 
-func NewService(repository RepositoryInterface, redisInterface redisLocal.LibInterface, tesseractsClient *gosseract.Client) ServiceInterface {
+func NewService(repository RepositoryInterface, redisInterface redisLocal.LibInterface, broker events.Broker,
+	templates template.RepositoryInterface, tesseractsClient *gosseract.Client) ServiceInterface {
+	metrics.EnginePoolSize.Set(1)
 	return &Service{
 		repository:       repository,
 		redisInterface:   redisInterface,
+		broker:           broker,
+		templates:        templates,
 		tesseractsClient: tesseractsClient,
+		exports:          make(map[string]*primitive.OcrExport),
+		batches:          make(map[string]*primitive.OcrBatch),
 	}
 }
 
-func (s *Service) ProcessOcr(ctx context.Context, payload primitive.OcrRequest, file multipart.File, fileHeader *multipart.FileHeader) (primitive.OCrResponse, error) {
-	logCtx := fmt.Sprintf("service.RecordOcr")
+// startJob runs the job in the background on a context detached from the request, the
+// request context is done once the response is sent. The context of the job is canceled
+// when the job is still running after the drain of the shutdown.
+func (s *Service) startJob(ctx context.Context, id string, job func(ctx context.Context)) error {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
 
-	// Define the file path where the image will be saved
-	uploadDir := "./uploads"
-	filePath := filepath.Join(uploadDir, fileHeader.Filename)
-	payload.Image = filePath
+	if s.jobsStopped {
+		return primitive.ErrorShuttingDown
+	}
+	if s.jobs == nil {
+		s.jobs = make(map[string]context.CancelFunc)
+	}
+	jobCtx, cancel := context.WithCancel(logger.Detach(ctx))
+	s.jobs[id] = cancel
+	s.jobsRunning.Add(1)
 
-	// Ensure the directory exists
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "os.MkdirAll")
-		return primitive.OCrResponse{}, fmt.Errorf("failed to create upload directory: %w", err)
+	go func() {
+		defer s.jobsRunning.Done()
+		defer func() {
+			s.jobsMu.Lock()
+			delete(s.jobs, id)
+			s.jobsMu.Unlock()
+			cancel()
+		}()
+		job(jobCtx)
+	}()
+	return nil
+}
+
+// DrainJobs refuses the new background jobs and waits for the running ones until ctx is
+// done, then cancels the remaining ones and waits for them to stop
+func (s *Service) DrainJobs(ctx context.Context) {
+	s.jobsMu.Lock()
+	s.jobsStopped = true
+	s.jobsMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.jobsRunning.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return
+	case <-ctx.Done():
 	}
 
-	// Create a file at the specified location
-	fileCreated, err := os.Create(filePath)
-	if err != nil {
-		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "os.Create")
-		return primitive.OCrResponse{}, fmt.Errorf("failed to create file: %w", err)
+	s.jobsMu.Lock()
+	for _, cancel := range s.jobs {
+		cancel()
 	}
+	s.jobsMu.Unlock()
+	<-drained
+}
+
+func (s *Service) ProcessOcr(ctx context.Context, payload primitive.OcrRequest, file multipart.File, fileHeader *multipart.FileHeader) (primitive.OCrResponse, error) {
+	return s.ProcessImage(ctx, payload, fileHeader.Filename, file)
+}
+
+// ProcessImage save the image read from the reader then run the ocr on it, it is
+// used by the uploads and by the grpc api that doesn't have a multipart file
+func (s *Service) ProcessImage(ctx context.Context, payload primitive.OcrRequest, fileName string, image io.Reader) (response primitive.OCrResponse, err error) {
+	logCtx := fmt.Sprintf("service.RecordOcr")
+
+	ctx, span := tracing.Start(ctx, "Service.ProcessOcr")
 	defer func() {
-		fileCreated.Close()
+		tracing.EndWithError(span, err)
 	}()
 
-	// Write the uploaded file content to the created file
-	if _, err := io.Copy(fileCreated, file); err != nil {
-		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "io.Copy")
-		return primitive.OCrResponse{}, fmt.Errorf("failed to save uploaded file: %w", err)
+	tenantID := tenant.FromContext(ctx)
+
+	tags, err := ParseTags(payload.Tags)
+	if err != nil {
+		return primitive.OCrResponse{}, err
+	}
+	options, err := ProfileOptions(tenantID, payload.Type)
+	if err != nil {
+		return primitive.OCrResponse{}, err
+	}
+	zones, err := ParseZones(payload.Zones)
+	if err != nil {
+		return primitive.OCrResponse{}, err
+	}
+	if len(zones) > 0 {
+		options.Zones = zones
+	}
+	fullText, err := ParseFullText(payload.FullText)
+	if err != nil {
+		return primitive.OCrResponse{}, err
+	}
+	if fullText != nil {
+		options.FullText = *fullText
 	}
 
-	err = s.tesseractsClient.SetImage(fileCreated.Name())
+	// Save the uploaded file, namespaced per tenant
+	filePath, size, contentHash, err := s.saveImage(ctx, tenantID, fileName, image)
 	if err != nil {
 		return primitive.OCrResponse{}, err
 	}
+	payload.Image = filePath
+	span.SetAttributes(attribute.Int64("upload.size", size))
+	metrics.UploadSizeBytes.Observe(float64(size))
 
-	var textResult string
 	var isEnabledHOCR bool
 	if payload.HOCREnabled != "" {
 		isEnabledHOCR, err = strconv.ParseBool(payload.HOCREnabled)
@@ -93,22 +222,177 @@ func (s *Service) ProcessOcr(ctx context.Context, payload primitive.OcrRequest,
 	}
 
 	if isEnabledHOCR {
-		textResult, err = s.tesseractsClient.HOCRText()
-		if err != nil {
-			return primitive.OCrResponse{}, err
-		}
-	} else {
-		textResult, err = s.tesseractsClient.Text()
-		if err != nil {
-			return primitive.OCrResponse{}, err
+		options.Format = primitive.FormatHocr
+	}
+
+	result, err := s.recognize(ctx, filePath, options)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.recognize")
+		return primitive.OCrResponse{}, err
+	}
+	result.fields = append(result.fields, s.templateFields(ctx, tenantID, options.DocumentType, result)...)
+
+	return s.saveRecord(ctx, primitive.Ocr{
+		TenantID:     tenantID,
+		ImageUrl:     payload.Image,
+		Text:         strings.Trim(result.text, "\n"),
+		DocumentType: options.DocumentType,
+		Language:     result.language,
+		Confidence:   result.confidence,
+		Tags:         joinTags(tags),
+		ContentHash:  contentHash,
+		Fields:       encodeFields(result.fields),
+		Words:        encodeWords(result.words),
+		ImageWidth:   result.width,
+		ImageHeight:  result.height,
+		Zones:        encodeZones(result.zones),
+	})
+}
+
+// RecognizeFile run the engine on a local file, used by the command line, the result
+// is stored like an uploaded file when store is true
+func (s *Service) RecognizeFile(ctx context.Context, imagePath string, options primitive.RecognizeOptions, store bool) (response primitive.RecognizeResponse, err error) {
+	logCtx := fmt.Sprintf("service.RecognizeFile")
+
+	ctx, span := tracing.Start(ctx, "Service.RecognizeFile", attribute.String("ocr.file", imagePath))
+	defer func() {
+		tracing.EndWithError(span, err)
+	}()
+
+	result, err := s.recognize(ctx, imagePath, options)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.recognize")
+		return primitive.RecognizeResponse{}, err
+	}
+	tenantID := tenant.FromContext(ctx)
+	result.fields = append(result.fields, s.templateFields(ctx, tenantID, options.DocumentType, result)...)
+
+	response = primitive.RecognizeResponse{
+		File:         imagePath,
+		Format:       options.Format,
+		DocumentType: options.DocumentType,
+		Text:         strings.Trim(result.text, "\n"),
+		Fields:       result.fields,
+		Zones:        result.zones,
+	}
+	if !store {
+		return response, nil
+	}
+
+	file, err := os.Open(imagePath)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "os.Open")
+		return primitive.RecognizeResponse{}, err
+	}
+	defer file.Close()
+
+	filePath, _, contentHash, err := s.saveImage(ctx, tenantID, filepath.Base(imagePath), file)
+	if err != nil {
+		return primitive.RecognizeResponse{}, err
+	}
+
+	record, err := s.saveRecord(ctx, primitive.Ocr{
+		TenantID:     tenantID,
+		ImageUrl:     filePath,
+		Text:         response.Text,
+		DocumentType: options.DocumentType,
+		Language:     result.language,
+		Confidence:   result.confidence,
+		ContentHash:  contentHash,
+		Fields:       encodeFields(result.fields),
+		Words:        encodeWords(result.words),
+		ImageWidth:   result.width,
+		ImageHeight:  result.height,
+		Zones:        encodeZones(result.zones),
+	})
+	if err != nil {
+		return primitive.RecognizeResponse{}, err
+	}
+	response.Record = &record
+
+	return response, nil
+}
+
+// UploadPath returns the path of an uploaded image of the tenant of the context, only the
+// name of a file right under the upload dir of the tenant is accepted
+func (s *Service) UploadPath(ctx context.Context, fileName string) (string, error) {
+	if fileName == "" || fileName == "." || fileName == ".." || fileName != filepath.Base(fileName) {
+		return "", primitive.ErrorUploadNotFound
+	}
+	filePath := filepath.Join(config.Conf.UploadDir, tenant.FromContext(ctx), fileName)
+	info, err := os.Stat(filePath)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", primitive.ErrorUploadNotFound
+	}
+	return filePath, nil
+}
+
+// saveImage copy the image under the upload dir of the tenant and returns its path, size and hex sha256,
+// the image is stored under a random name so two uploads of the same file name don't overwrite each other
+func (s *Service) saveImage(ctx context.Context, tenantID string, fileName string, file io.Reader) (string, int64, string, error) {
+	logCtx := fmt.Sprintf("service.saveImage")
+
+	id, err := newRandomID()
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "newRandomID")
+		return "", 0, "", err
+	}
+
+	// Define the file path where the image will be saved, namespaced per tenant
+	uploadDir := filepath.Join(config.Conf.UploadDir, tenantID)
+	filePath := filepath.Join(uploadDir, id+uploadExtension(fileName))
+
+	// Ensure the directory exists
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "os.MkdirAll")
+		return "", 0, "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	// Create a file at the specified location
+	fileCreated, err := os.Create(filePath)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "os.Create")
+		return "", 0, "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		fileCreated.Close()
+	}()
+
+	// Write the uploaded file content to the created file, hashing it on the way
+	hash := sha256.New()
+	size, err := io.Copy(fileCreated, io.TeeReader(file, hash))
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "io.Copy")
+		// don't keep the part of an interrupted stream
+		_ = os.Remove(filePath)
+		return "", 0, "", fmt.Errorf("failed to save uploaded file: %w", err)
+	}
+
+	return filePath, size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// uploadExtension returns the lower case extension of the file name of the client, only a
+// short alphanumeric one is kept in the stored name
+func uploadExtension(fileName string) string {
+	extension := strings.ToLower(filepath.Ext(fileName))
+	if len(extension) < 2 || len(extension) > maxUploadExtensionLength {
+		return ""
+	}
+	for _, r := range extension[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
 		}
 	}
-	textResult = strings.Trim(textResult, "\n")
+	return extension
+}
+
+// saveRecord store the recognized record and cache it
+func (s *Service) saveRecord(ctx context.Context, payloadDb primitive.Ocr) (primitive.OCrResponse, error) {
+	logCtx := fmt.Sprintf("service.saveRecord")
 
-	payloadDb := primitive.Ocr{
-		ImageUrl: payload.Image,
-		Text:     textResult,
-		Status:   "SUCCESSFUL",
+	payloadDb.Status = "SUCCESSFUL"
+	if identity, ok := auth.FromContext(ctx); ok {
+		payloadDb.CreatedBy = identity.String()
 	}
 
 	data, err := s.repository.CreateOcr(ctx, payloadDb)
@@ -119,37 +403,442 @@ func (s *Service) ProcessOcr(ctx context.Context, payload primitive.OcrRequest,
 
 	//set data to redis on goroutine
 	if config.Conf.Redis.EnableRedis && s.redisInterface != nil {
+		// the request context is done once the response is sent, keep only its values
+		ctx := logger.Detach(ctx)
 		go func() {
 			dataBytes, errMarshall := json.Marshal(data)
 			if errMarshall != nil {
 				logger.Error(ctx, utils.ErrorLogFormat, errMarshall.Error(), logCtx, "json.Marshal")
 			}
-			redisFinaleKey := fmt.Sprintf(redisFinaleKeyOcr, data.ID)
-			errSetToRedis := s.redisInterface.Set(redisFinaleKey, dataBytes, time.Minute)
+			redisFinaleKey := fmt.Sprintf(redisFinaleKeyOcr, data.TenantID, data.ID)
+			errSetToRedis := s.redisInterface.Set(ctx, redisFinaleKey, dataBytes, cacheTTL())
 			if errSetToRedis != nil {
 				logger.Error(ctx, utils.ErrorLogFormat, errSetToRedis.Error(), logCtx, "s.redis.Set")
 			}
-			fmt.Printf("success SET on redis by key: %s\n", redisFinaleKey)
+			logger.Debug(ctx, logCtx, "success SET on redis by key: %s", redisFinaleKey)
 		}()
 	}
 
-	payloadResp := primitive.OCrResponse{
-		ID:        data.ID,
-		ImageUrl:  data.ImageUrl,
-		Text:      data.Text,
-		Status:    data.Status,
-		CreatedAt: data.CreatedAt,
-		UpdatedAt: data.UpdatedAt,
-	}
+	payloadResp := toOcrResponse(data)
+
+	s.publishOcrEvents(ctx, payloadResp)
 
 	return payloadResp, nil
 
 }
 
+// publishOcrEvents tell the subscribers of the record how the recognition went, the
+// recognition is synchronous so the record is already final when it gets an id and its
+// stream only replays the status and the result, the batches report a real progress
+func (s *Service) publishOcrEvents(ctx context.Context, data primitive.OCrResponse) {
+	logCtx := fmt.Sprintf("service.publishOcrEvents")
+
+	if s.broker == nil {
+		return
+	}
+
+	topic := fmt.Sprintf(eventsTopicOcr, data.TenantID, data.ID)
+	published := []struct {
+		eventType string
+		data      interface{}
+	}{
+		{events.TypeStatus, primitive.OcrStatusEvent{ID: data.ID, Status: data.Status}},
+		{events.TypeResult, data},
+	}
+	for _, event := range published {
+		if err := s.broker.Publish(ctx, topic, event.eventType, event.data); err != nil {
+			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.broker.Publish")
+			return
+		}
+	}
+}
+
+// SubscribeOcrEvents stream the events of the record after lastEventID, the events are
+// replayed while the broker keeps them, then only the final result can be given
+func (s *Service) SubscribeOcrEvents(ctx context.Context, id int64, lastEventID int64) (<-chan events.Event, error) {
+	logCtx := fmt.Sprintf("service.SubscribeOcrEvents")
+
+	data, err := s.GetRecordOcrById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	topic := fmt.Sprintf(eventsTopicOcr, data.TenantID, data.ID)
+	var lastPublishedID int64
+	if s.broker != nil {
+		lastPublishedID, err = s.broker.LastEventID(ctx, topic)
+		if err != nil {
+			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.broker.LastEventID")
+			return nil, err
+		}
+	}
+
+	if lastPublishedID == 0 {
+		// the events expired or were published before a restart, give the result as the only event
+		if lastEventID >= 1 {
+			return nil, primitive.ErrorNoMoreEvents
+		}
+		raw, errMarshal := json.Marshal(data)
+		if errMarshal != nil {
+			return nil, errMarshal
+		}
+		stream := make(chan events.Event, 1)
+		stream <- events.Event{ID: 1, Type: events.TypeResult, Data: raw}
+		close(stream)
+		return stream, nil
+	}
+
+	// the record is final, a client that got every event must not reconnect
+	if lastEventID >= lastPublishedID {
+		return nil, primitive.ErrorNoMoreEvents
+	}
+	return s.broker.Subscribe(ctx, topic, lastEventID)
+}
+
+// recognition is the output of the engine for one image
+type recognition struct {
+	text     string
+	language string
+	// confidence is the mean of the word confidences, from 0 to 100
+	confidence float64
+	// fields are found by the extractors of the profile in the plain text
+	fields []primitive.OcrField
+	// words are in pixels of the original image, the preprocessing may have resized it
+	words         []primitive.OcrWord
+	width, height int
+	// zones are the texts of the zones of the recognition, nil without zone
+	zones primitive.OcrZones
+}
+
+// recognize run tesseract on the image, only one recognition at a time can use the client.
+// The preprocessing of the profile runs first, its postprocessing and extraction need the plain text.
+// With zones only the zones are read, the text is theirs unless the full text is asked for.
+func (s *Service) recognize(ctx context.Context, imagePath string, options primitive.RecognizeOptions) (result recognition, err error) {
+	psm := metricsDefaultPsm
+	if options.Psm != primitive.PsmDefault {
+		psm = strconv.Itoa(options.Psm)
+	}
+
+	_, span := tracing.Start(ctx, "Engine.Recognize",
+		attribute.StringSlice("ocr.languages", options.Languages),
+		attribute.String("ocr.psm", psm),
+		attribute.String("ocr.format", options.Format),
+		attribute.Int("ocr.zones", len(options.Zones)),
+	)
+	defer func() {
+		tracing.EndWithError(span, err)
+	}()
+
+	metrics.QueueDepth.Inc()
+	s.engineMu.Lock()
+	metrics.QueueDepth.Dec()
+	span.AddEvent("engine acquired")
+	metrics.EngineInUse.Inc()
+	defer func() {
+		metrics.EngineInUse.Dec()
+		s.engineMu.Unlock()
+	}()
+
+	if len(options.Languages) > 0 {
+		if err = s.tesseractsClient.SetLanguage(options.Languages...); err != nil {
+			return result, err
+		}
+	}
+
+	start := time.Now()
+	if len(options.Zones) == 0 || options.FullText {
+		err = s.recognizePage(imagePath, options, &result)
+	}
+	if err == nil && len(options.Zones) > 0 {
+		err = s.recognizeZones(imagePath, options, &result)
+	}
+	if err == nil && options.Format == primitive.FormatText {
+		result.fields = extractFields(result.text, options.Extractors)
+		for idx, field := range result.fields {
+			result.fields[idx] = template.Locate(result.words, field)
+		}
+	}
+	result.language = strings.Join(s.tesseractsClient.Languages, "+")
+	metrics.OcrDuration.WithLabelValues(result.language, psm).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return recognition{}, err
+	}
+
+	return result, nil
+}
+
+// recognizePage reads the whole page in the format of the options
+func (s *Service) recognizePage(imagePath string, options primitive.RecognizeOptions, result *recognition) (err error) {
+	if len(options.Preprocess) > 0 {
+		err = s.setPreprocessedImage(imagePath, options.Preprocess)
+	} else {
+		err = s.tesseractsClient.SetImage(imagePath)
+	}
+	if err != nil {
+		return err
+	}
+	result.width, result.height = imageSize(imagePath)
+
+	// the mode stays on the client, put back the default after a recognition that changed it
+	if options.Psm != primitive.PsmDefault {
+		_ = s.tesseractsClient.SetPageSegMode(gosseract.PageSegMode(options.Psm))
+		defer func() {
+			_ = s.tesseractsClient.SetPageSegMode(gosseract.PSM_SINGLE_BLOCK)
+		}()
+	}
+
+	switch options.Format {
+	case primitive.FormatHocr:
+		result.text, err = s.tesseractsClient.HOCRText()
+	case primitive.FormatTsv:
+		result.text, err = s.tsvText()
+	default:
+		result.text, err = s.tesseractsClient.Text()
+	}
+	if err != nil {
+		return err
+	}
+	result.words, err = s.recognizedWords(imaging.Scale(options.Preprocess))
+	if err != nil {
+		return err
+	}
+	result.confidence = meanConfidence(result.words)
+	if options.Format == primitive.FormatText {
+		result.text = postprocess(result.text, options.Postprocess)
+	}
+	return nil
+}
+
+// recognizeZones crops each zone out of the image and reads it with its own mode and whitelist.
+// Without the full text, the text and the words of the recognition are the ones of the zones.
+func (s *Service) recognizeZones(imagePath string, options primitive.RecognizeOptions, result *recognition) error {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("%w: %v", primitive.ErrorImageNotPreprocessed, err)
+	}
+	result.width, result.height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	// the mode and the whitelist stay on the client, put back the defaults after the zones
+	defer func() {
+		_ = s.tesseractsClient.SetPageSegMode(gosseract.PSM_SINGLE_BLOCK)
+		_ = s.tesseractsClient.SetWhitelist("")
+	}()
+
+	result.zones = make(primitive.OcrZones, len(options.Zones))
+	var words []primitive.OcrWord
+	texts := make([]string, 0, len(options.Zones))
+	for _, zone := range options.Zones {
+		rect := zoneRect(zone, result.width, result.height)
+		zoneResult := primitive.OcrZoneResult{
+			BBox: primitive.OcrBox{X: rect.Min.X, Y: rect.Min.Y, Width: rect.Dx(), Height: rect.Dy()},
+		}
+		if !rect.Empty() {
+			text, zoneWords, errZone := s.recognizeZone(img, rect, zone, options)
+			if errZone != nil {
+				return errZone
+			}
+			// the lines of a zone follow the ones of the previous zones
+			lines := 0
+			if len(words) > 0 {
+				lines = words[len(words)-1].Line
+			}
+			for idx := range zoneWords {
+				zoneWords[idx].Line += lines
+			}
+			words = append(words, zoneWords...)
+			zoneResult.Text = text
+			zoneResult.Confidence = meanConfidence(zoneWords)
+		}
+		result.zones[zone.Name] = zoneResult
+		if zoneResult.Text != "" {
+			texts = append(texts, zoneResult.Text)
+		}
+	}
+
+	if !options.FullText {
+		result.text = strings.Join(texts, "\n")
+		result.words = words
+		result.confidence = meanConfidence(words)
+	}
+	return nil
+}
+
+// recognizeZone reads the rectangle of the image, the words are returned in pixels of the image
+func (s *Service) recognizeZone(img image.Image, rect image.Rectangle, zone primitive.OcrZone, options primitive.RecognizeOptions) (string, []primitive.OcrWord, error) {
+	cropped, err := imaging.Apply(imaging.Crop(img, rect), options.Preprocess)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", primitive.ErrorImageNotPreprocessed, err)
+	}
+	data, err := imaging.Encode(cropped)
+	if err != nil {
+		return "", nil, err
+	}
+	if err = s.tesseractsClient.SetImageFromBytes(data); err != nil {
+		return "", nil, err
+	}
+
+	psm := gosseract.PSM_SINGLE_BLOCK
+	if zone.Psm != nil {
+		psm = gosseract.PageSegMode(*zone.Psm)
+	} else if options.Psm != primitive.PsmDefault {
+		psm = gosseract.PageSegMode(options.Psm)
+	}
+	if err = s.tesseractsClient.SetPageSegMode(psm); err != nil {
+		return "", nil, err
+	}
+	if err = s.tesseractsClient.SetWhitelist(zone.Whitelist); err != nil {
+		return "", nil, err
+	}
+
+	text, err := s.tesseractsClient.Text()
+	if err != nil {
+		return "", nil, err
+	}
+	words, err := s.recognizedWords(imaging.Scale(options.Preprocess))
+	if err != nil {
+		return "", nil, err
+	}
+	for idx := range words {
+		words[idx].BBox.X += rect.Min.X
+		words[idx].BBox.Y += rect.Min.Y
+	}
+	return postprocess(text, options.Postprocess), words, nil
+}
+
+// setPreprocessedImage gives the engine the image after the preprocessing steps
+func (s *Service) setPreprocessedImage(imagePath string, steps []string) error {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := imaging.Preprocess(file, steps)
+	if err != nil {
+		return fmt.Errorf("%w: %v", primitive.ErrorImageNotPreprocessed, err)
+	}
+	return s.tesseractsClient.SetImageFromBytes(data)
+}
+
+// recognizedWords returns the words of the last recognition, their boxes are divided by the
+// scale of the preprocessing to be in pixels of the original image
+func (s *Service) recognizedWords(scale int) ([]primitive.OcrWord, error) {
+	boxes, err := s.tesseractsClient.GetBoundingBoxesVerbose()
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]primitive.OcrWord, 0, len(boxes))
+	line := 0
+	var lastBlock, lastPar, lastLine int
+	for idx, box := range boxes {
+		if strings.TrimSpace(box.Word) == "" {
+			continue
+		}
+		if idx == 0 || box.BlockNum != lastBlock || box.ParNum != lastPar || box.LineNum != lastLine {
+			line++
+			lastBlock, lastPar, lastLine = box.BlockNum, box.ParNum, box.LineNum
+		}
+		words = append(words, primitive.OcrWord{
+			Text:       box.Word,
+			Confidence: box.Confidence,
+			Line:       line,
+			BBox: primitive.OcrBox{
+				X:      box.Box.Min.X / scale,
+				Y:      box.Box.Min.Y / scale,
+				Width:  box.Box.Dx() / scale,
+				Height: box.Box.Dy() / scale,
+			},
+		})
+	}
+	return words, nil
+}
+
+// meanConfidence returns the mean confidence of the words, 0 when there is no word
+func meanConfidence(words []primitive.OcrWord) float64 {
+	if len(words) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, word := range words {
+		total += word.Confidence
+	}
+	return math.Round(total/float64(len(words))*100) / 100
+}
+
+// imageSize returns the size of the image, 0 when its format can't be decoded
+func imageSize(imagePath string) (width, height int) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return 0, 0
+	}
+	defer file.Close()
+
+	imageConfig, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0
+	}
+	return imageConfig.Width, imageConfig.Height
+}
+
+// templateFields runs the templates of the tenant made for the document type, a template
+// that can't be read is logged and skipped so the recognition is still stored
+func (s *Service) templateFields(ctx context.Context, tenantID, documentType string, result recognition) []primitive.OcrField {
+	logCtx := fmt.Sprintf("service.templateFields")
+
+	if s.templates == nil || documentType == "" {
+		return nil
+	}
+	templates, err := s.templates.FindAllTemplate(ctx, tenantID, documentType)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.templates.FindAllTemplate")
+		return nil
+	}
+
+	document := primitive.OcrDocument{
+		TenantID:     tenantID,
+		DocumentType: documentType,
+		Text:         result.text,
+		Words:        result.words,
+		Width:        result.width,
+		Height:       result.height,
+	}
+	var fields []primitive.OcrField
+	for _, data := range templates {
+		fields = append(fields, template.Extract(document, template.DecodeRules(data.Rules))...)
+	}
+	return fields
+}
+
+// tsvText formats the word boxes like the tsv output of tesseract, only the word level rows are available
+func (s *Service) tsvText() (string, error) {
+	boxes, err := s.tesseractsClient.GetBoundingBoxesVerbose()
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	builder.WriteString("level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n")
+	for _, box := range boxes {
+		fmt.Fprintf(&builder, "5\t1\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t%s\n",
+			box.BlockNum, box.ParNum, box.LineNum, box.WordNum,
+			box.Box.Min.X, box.Box.Min.Y, box.Box.Dx(), box.Box.Dy(), box.Confidence, box.Word)
+	}
+	return builder.String(), nil
+}
+
 func (s *Service) ListOcr(ctx context.Context, isDisablePagination bool, param primitive.ParameterFindOcr) (res []primitive.OCrResponse, count int64, err error) {
 	logCtx := fmt.Sprintf("service.ListPaymentAll")
 
 	emptySliceDataOcr := make([]primitive.OCrResponse, 0)
+	param.TenantID = tenant.FromContext(ctx)
 	// Data not found in cache, query the database
 	count, err = s.repository.CountAllListOcr(ctx, param)
 	if err != nil {
@@ -186,14 +875,7 @@ func (s *Service) ListOcr(ctx context.Context, isDisablePagination bool, param p
 	if len(listData) > 0 {
 		for _, val := range listData {
 
-			list = append(list, primitive.OCrResponse{
-				ID:        val.ID,
-				ImageUrl:  val.ImageUrl,
-				Text:      val.Text,
-				Status:    val.Status,
-				CreatedAt: val.CreatedAt,
-				UpdatedAt: val.CreatedAt,
-			})
+			list = append(list, toOcrResponse(val))
 		}
 		res = list
 	}
@@ -201,24 +883,170 @@ func (s *Service) ListOcr(ctx context.Context, isDisablePagination bool, param p
 	return res, count, nil
 }
 
+// ListOcrCursor returns the page size records following the cursor of the param, the pages
+// are read by keyset so the records inserted meanwhile don't shift them
+func (s *Service) ListOcrCursor(ctx context.Context, param primitive.ParameterFindOcr) (page primitive.OcrCursorPage, err error) {
+	logCtx := fmt.Sprintf("service.ListOcrCursor")
+
+	param.TenantID = tenant.FromContext(ctx)
+	page.Data = make([]primitive.OCrResponse, 0)
+	page.Total, err = s.repository.CountAllListOcr(ctx, param)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.CountAllListOcr")
+		return page, err
+	}
+
+	// one more record tells whether there is a page after this one
+	limit := param.PageSize
+	param.PageSize = limit + 1
+	listData, err := s.repository.FindAllListOcrKeyset(ctx, param)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.FindAllListOcrKeyset")
+		return page, err
+	}
+	hasMore := len(listData) > limit
+	if hasMore {
+		listData = listData[:limit]
+	}
+	if len(listData) == 0 {
+		return page, nil
+	}
+
+	backward := param.Cursor != nil && param.Cursor.Backward
+	if backward {
+		// the records nearest to the cursor come first, put them back in the sort order
+		for left, right := 0, len(listData)-1; left < right; left, right = left+1, right-1 {
+			listData[left], listData[right] = listData[right], listData[left]
+		}
+	}
+
+	first, last := listData[0], listData[len(listData)-1]
+	if (!backward && hasMore) || backward {
+		page.NextCursor = encodeCursor(last, param.SortBy, param.SortOrder, false)
+	}
+	if (backward && hasMore) || (!backward && param.Cursor != nil) {
+		page.PrevCursor = encodeCursor(first, param.SortBy, param.SortOrder, true)
+	}
+
+	for _, val := range listData {
+		page.Data = append(page.Data, toOcrResponse(val))
+	}
+	return page, nil
+}
+
 func (s *Service) GetRecordOcrById(ctx context.Context, id int64) (primitive.OCrResponse, error) {
 	logCtx := fmt.Sprintf("service.GetRecordPaymentById")
 
-	data, err := s.repository.FindOcrByID(ctx, id)
+	tenantID := tenant.FromContext(ctx)
+
+	data, found := s.getOcrFromCache(ctx, tenantID, id)
+	if !found {
+		var err error
+		data, err = s.repository.FindOcrByID(ctx, tenantID, id)
+		if err != nil {
+			logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "u.repository.FindPaymentByIdAndByCustomerId")
+			return primitive.OCrResponse{}, err
+		}
+	}
+
+	return toOcrResponse(data), nil
+
+}
+
+// GetOcrDocument returns the text and the words of the record, to run a template on it
+func (s *Service) GetOcrDocument(ctx context.Context, id int64) (primitive.OcrDocument, error) {
+	logCtx := fmt.Sprintf("service.GetOcrDocument")
+
+	tenantID := tenant.FromContext(ctx)
+	data, err := s.repository.FindOcrByID(ctx, tenantID, id)
 	if err != nil {
-		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "u.repository.FindPaymentByIdAndByCustomerId")
-		return primitive.OCrResponse{}, err
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.FindOcrByID")
+		return primitive.OcrDocument{}, err
 	}
 
-	payloadResp := primitive.OCrResponse{
-		ID:        data.ID,
-		ImageUrl:  data.ImageUrl,
-		Text:      data.Text,
-		Status:    data.Status,
-		CreatedAt: data.CreatedAt,
-		UpdatedAt: data.CreatedAt,
+	return primitive.OcrDocument{
+		TenantID:     data.TenantID,
+		DocumentType: data.DocumentType,
+		Text:         data.Text,
+		Words:        decodeWords(data.Words),
+		Width:        data.ImageWidth,
+		Height:       data.ImageHeight,
+	}, nil
+}
+
+// cacheTTL is how long a record stays in redis, it is read per write because it can be reloaded
+func cacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(config.Current().Redis.CacheTTL)
+	if err != nil || ttl <= 0 {
+		return defaultCacheTTL
 	}
+	return ttl
+}
 
-	return payloadResp, nil
+// getOcrFromCache look up the record cached by ProcessOcr
+func (s *Service) getOcrFromCache(ctx context.Context, tenantID string, id int64) (data primitive.Ocr, found bool) {
+	logCtx := fmt.Sprintf("service.getOcrFromCache")
+
+	if !config.Conf.Redis.EnableRedis || s.redisInterface == nil {
+		return primitive.Ocr{}, false
+	}
 
+	value := s.redisInterface.Get(ctx, fmt.Sprintf(redisFinaleKeyOcr, tenantID, id))
+	if value == "" {
+		metrics.CacheRequestsTotal.WithLabelValues(metrics.CacheRedis, metrics.CacheResultMiss).Inc()
+		return primitive.Ocr{}, false
+	}
+
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "json.Unmarshal")
+		metrics.CacheRequestsTotal.WithLabelValues(metrics.CacheRedis, metrics.CacheResultMiss).Inc()
+		return primitive.Ocr{}, false
+	}
+
+	metrics.CacheRequestsTotal.WithLabelValues(metrics.CacheRedis, metrics.CacheResultHit).Inc()
+	return data, true
+}
+
+// PurgeExpiredOcr soft deletes the records older than the retention of their tenant,
+// only the tenants with a configured retention are purged.
+func (s *Service) PurgeExpiredOcr(ctx context.Context) (count int64, err error) {
+	logCtx := fmt.Sprintf("service.PurgeExpiredOcr")
+
+	for _, tenantConfig := range config.Conf.Tenants {
+		retention := tenant.SettingsFor(tenantConfig.ID).Retention
+		if retention <= 0 {
+			continue
+		}
+
+		deleted, errDelete := s.repository.DeleteOcrCreatedBefore(ctx, tenantConfig.ID, time.Now().Add(-retention))
+		if errDelete != nil {
+			logger.Error(ctx, utils.ErrorLogFormat, errDelete.Error(), logCtx, "s.repository.DeleteOcrCreatedBefore")
+			err = errDelete
+			continue
+		}
+		if deleted > 0 {
+			logger.Info(ctx, logCtx, "purged %d expired records of tenant %s", deleted, tenantConfig.ID)
+		}
+		count += deleted
+	}
+
+	return count, err
+}
+
+// SaveToFile snapshot the repository when it keeps its data in memory.
+func (s *Service) SaveToFile(ctx context.Context) (err error) {
+	repository, ok := s.repository.(PersistentRepository)
+	if !ok {
+		return nil
+	}
+	return repository.SaveToFile(ctx)
+}
+
+// LoadFromFile restores the repository when it keeps its data in memory.
+func (s *Service) LoadFromFile(ctx context.Context) (err error) {
+	repository, ok := s.repository.(PersistentRepository)
+	if !ok {
+		return nil
+	}
+	return repository.LoadFromFile(ctx)
 }
@@ -0,0 +1,172 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/events"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+)
+
+func TestSubscribeOcrEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		published   bool
+		lastEventID int64
+		wantTypes   []string
+		wantErr     error
+	}{
+		{name: "replay from the start", published: true, wantTypes: []string{events.TypeStatus, events.TypeResult}},
+		{name: "resume after the status", published: true, lastEventID: 1, wantTypes: []string{events.TypeResult}},
+		{name: "every event received", published: true, lastEventID: 2, wantErr: primitive.ErrorNoMoreEvents},
+		{name: "events expired", wantTypes: []string{events.TypeResult}},
+		{name: "events expired and the result received", lastEventID: 1, wantErr: primitive.ErrorNoMoreEvents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tenant.WithTenant(context.Background(), "acme")
+			service := &Service{repository: NewInMemoryRepository(), broker: events.NewInMemoryBroker(0, 0)}
+			record, err := service.saveRecord(ctx, primitive.Ocr{ImageUrl: "uploads/acme/a.png", Text: "invoice", TenantID: "acme"})
+			if err != nil {
+				t.Fatalf("saveRecord() error = %v", err)
+			}
+			if !tt.published {
+				// a new broker is what a restart or the retention leaves
+				service.broker = events.NewInMemoryBroker(0, 0)
+			}
+
+			streamCtx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			stream, err := service.SubscribeOcrEvents(streamCtx, record.ID, tt.lastEventID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SubscribeOcrEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var types []string
+			for event := range stream {
+				types = append(types, event.Type)
+				if event.Type == events.TypeResult {
+					break
+				}
+			}
+			if len(types) != len(tt.wantTypes) {
+				t.Fatalf("got events %v, want %v", types, tt.wantTypes)
+			}
+			for idx := range types {
+				if types[idx] != tt.wantTypes[idx] {
+					t.Fatalf("got events %v, want %v", types, tt.wantTypes)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveImage(t *testing.T) {
+	previous := config.Conf.UploadDir
+	t.Cleanup(func() { config.Conf.UploadDir = previous })
+	config.Conf.UploadDir = t.TempDir()
+
+	tests := []struct {
+		name          string
+		fileName      string
+		wantExtension string
+	}{
+		{name: "image", fileName: "a.png", wantExtension: ".png"},
+		{name: "same name as the previous upload", fileName: "a.png", wantExtension: ".png"},
+		{name: "upper case extension", fileName: "b.JPG", wantExtension: ".jpg"},
+		{name: "path of the client", fileName: "../../c.png", wantExtension: ".png"},
+		{name: "without extension", fileName: "scan"},
+		{name: "extension with symbols", fileName: "d.p$g"},
+	}
+	ctx := context.Background()
+	service := &Service{}
+	saved := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "image of " + tt.name
+			filePath, size, _, err := service.saveImage(ctx, "acme", tt.fileName, strings.NewReader(content))
+			if err != nil {
+				t.Fatalf("saveImage() error = %v", err)
+			}
+			if filepath.Dir(filePath) != filepath.Join(config.Conf.UploadDir, "acme") {
+				t.Fatalf("saveImage() = %s, want a file of the upload dir of the tenant", filePath)
+			}
+			if filepath.Ext(filePath) != tt.wantExtension || size != int64(len(content)) {
+				t.Fatalf("saveImage() = %s, %d, want extension %q and size %d", filePath, size, tt.wantExtension, len(content))
+			}
+			saved[filePath] = content
+		})
+	}
+
+	// every upload kept its own content
+	if len(saved) != len(tests) {
+		t.Fatalf("saveImage() stored %d files for %d uploads", len(saved), len(tests))
+	}
+	for filePath, content := range saved {
+		stored, err := os.ReadFile(filePath)
+		if err != nil || string(stored) != content {
+			t.Fatalf("stored %s = %q, error = %v, want %q", filePath, stored, err, content)
+		}
+	}
+}
+
+func TestDrainJobs(t *testing.T) {
+	tests := []struct {
+		name         string
+		blocked      bool
+		wantCanceled bool
+	}{
+		{name: "a job finishing in time is drained"},
+		{name: "a job still running is canceled", blocked: true, wantCanceled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{}
+			release := make(chan struct{})
+			canceled := make(chan bool, 1)
+			err := service.startJob(context.Background(), "job", func(ctx context.Context) {
+				if !tt.blocked {
+					close(release)
+				}
+				select {
+				case <-release:
+					canceled <- false
+				case <-ctx.Done():
+					canceled <- true
+				}
+			})
+			if err != nil {
+				t.Fatalf("startJob() error = %v", err)
+			}
+
+			drainCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			service.DrainJobs(drainCtx)
+
+			// the job returned before the drain did
+			select {
+			case got := <-canceled:
+				if got != tt.wantCanceled {
+					t.Fatalf("job canceled = %v, want %v", got, tt.wantCanceled)
+				}
+			default:
+				t.Fatalf("DrainJobs() returned before the job")
+			}
+
+			err = service.startJob(context.Background(), "late", func(ctx context.Context) {})
+			if !errors.Is(err, primitive.ErrorShuttingDown) {
+				t.Fatalf("startJob() after DrainJobs() error = %v, want %v", err, primitive.ErrorShuttingDown)
+			}
+		})
+	}
+}
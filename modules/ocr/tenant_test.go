@@ -0,0 +1,127 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/events"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+)
+
+// TestTenantIsolation stores a record, its image and an export for a tenant, then reads
+// them back as the tenant and as another one that must not see any of them
+func TestTenantIsolation(t *testing.T) {
+	previousUploadDir, previousExportDir := config.Conf.UploadDir, config.Conf.ExportDir
+	t.Cleanup(func() {
+		config.Conf.UploadDir, config.Conf.ExportDir = previousUploadDir, previousExportDir
+	})
+	config.Conf.UploadDir, config.Conf.ExportDir = t.TempDir(), t.TempDir()
+
+	const owner, other = "globex", "acme"
+	ownerCtx := tenant.WithTenant(context.Background(), owner)
+	service := &Service{repository: NewInMemoryRepository(), broker: events.NewInMemoryBroker(0, 0),
+		exports: make(map[string]*primitive.OcrExport), batches: make(map[string]*primitive.OcrBatch)}
+
+	imagePath, _, _, err := service.saveImage(ownerCtx, owner, "invoice.png", strings.NewReader("image of globex"))
+	if err != nil {
+		t.Fatalf("saveImage() error = %v", err)
+	}
+	record, err := service.saveRecord(ownerCtx, primitive.Ocr{ImageUrl: imagePath, Text: "secret of globex", TenantID: owner})
+	if err != nil {
+		t.Fatalf("saveRecord() error = %v", err)
+	}
+	export, err := service.CreateExportOcr(ownerCtx, primitive.ParameterFindOcr{}, primitive.ExportFormatCsv)
+	if err != nil {
+		t.Fatalf("CreateExportOcr() error = %v", err)
+	}
+	service.jobsRunning.Wait()
+
+	// visible runs an operation as the tenant of ctx and reports whether the data of the owner came back
+	operations := []struct {
+		name    string
+		visible func(ctx context.Context) (bool, error)
+	}{
+		{name: "read", visible: func(ctx context.Context) (bool, error) {
+			_, err := service.GetRecordOcrById(ctx, record.ID)
+			return err == nil, ignoreNotFound(err)
+		}},
+		{name: "read the document", visible: func(ctx context.Context) (bool, error) {
+			_, err := service.GetOcrDocument(ctx, record.ID)
+			return err == nil, ignoreNotFound(err)
+		}},
+		{name: "list", visible: func(ctx context.Context) (bool, error) {
+			list, count, err := service.ListOcr(ctx, false, primitive.ParameterFindOcr{PageSize: 10})
+			return count > 0 || len(list) > 0, err
+		}},
+		{name: "list by cursor", visible: func(ctx context.Context) (bool, error) {
+			page, err := service.ListOcrCursor(ctx, primitive.ParameterFindOcr{PageSize: 10, SortBy: "id", SortOrder: "desc"})
+			return page.Total > 0 || len(page.Data) > 0, err
+		}},
+		{name: "export csv", visible: exportVisible(service, primitive.ExportFormatCsv)},
+		{name: "export ndjson", visible: exportVisible(service, primitive.ExportFormatNdjson)},
+		{name: "export zip", visible: exportVisible(service, primitive.ExportFormatZip)},
+		{name: "read the background export", visible: func(ctx context.Context) (bool, error) {
+			_, err := service.GetExportOcr(ctx, export.ID)
+			return err == nil, ignoreNotFound(err)
+		}},
+		{name: "download the background export", visible: func(ctx context.Context) (bool, error) {
+			_, err := service.OpenExportOcr(ctx, export.ID)
+			return err == nil, ignoreNotFound(err)
+		}},
+		{name: "download the image", visible: func(ctx context.Context) (bool, error) {
+			_, err := service.UploadPath(ctx, filepath.Base(imagePath))
+			return err == nil, ignoreNotFound(err)
+		}},
+	}
+	tests := []struct {
+		name        string
+		tenantID    string
+		wantVisible bool
+	}{
+		{name: "owner", tenantID: owner, wantVisible: true},
+		{name: "other tenant", tenantID: other},
+		{name: "default tenant", tenantID: tenant.DefaultTenantID},
+	}
+	for _, tt := range tests {
+		for _, operation := range operations {
+			t.Run(tt.name+" "+operation.name, func(t *testing.T) {
+				visible, err := operation.visible(tenant.WithTenant(context.Background(), tt.tenantID))
+				if err != nil {
+					t.Fatalf("%s error = %v", operation.name, err)
+				}
+				if visible != tt.wantVisible {
+					t.Fatalf("%s visible = %v, want %v", operation.name, visible, tt.wantVisible)
+				}
+			})
+		}
+	}
+}
+
+// exportVisible reports whether the export of the tenant of ctx holds the record of the owner
+func exportVisible(service *Service, format string) func(ctx context.Context) (bool, error) {
+	return func(ctx context.Context) (bool, error) {
+		var out bytes.Buffer
+		rows, err := service.ExportOcr(ctx, primitive.ParameterFindOcr{}, format, &out)
+		if err != nil {
+			return false, err
+		}
+		if rows == 0 && bytes.Contains(out.Bytes(), []byte("globex")) {
+			return true, errors.New("the export holds data of globex without any row")
+		}
+		return rows > 0, nil
+	}
+}
+
+// ignoreNotFound treats the not found errors as the expected result of a foreign id
+func ignoreNotFound(err error) error {
+	if errors.Is(err, primitive.ErrorArticleNotFound) || errors.Is(err, primitive.ErrorExportNotFound) || errors.Is(err, primitive.ErrorUploadNotFound) {
+		return nil
+	}
+	return err
+}
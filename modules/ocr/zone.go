@@ -0,0 +1,128 @@
+package ocr
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"regexp"
+	"strconv"
+
+	"go-ocr/infrastructure/config"
+	"go-ocr/modules/primitive"
+)
+
+const (
+	maxZones          = 20
+	maxWhitelistBytes = 256
+)
+
+var validZoneName = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// ParseZones reads the json array of zones of a request, empty when there is none
+func ParseZones(value string) ([]primitive.OcrZone, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var zones []primitive.OcrZone
+	if err := json.Unmarshal([]byte(value), &zones); err != nil {
+		return nil, fmt.Errorf("%w: zones must be a json array of {name, x, y, width, height}", primitive.ErrorZoneNotValid)
+	}
+	if err := validateZones(zones); err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// ParseFullText reads the full text flag of a request, nil keeps the one of the profile
+func ParseFullText(value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	fullText, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("fullText %q must be true or false", value)
+	}
+	return &fullText, nil
+}
+
+func validateZones(zones []primitive.OcrZone) error {
+	if len(zones) > maxZones {
+		return fmt.Errorf("%w: at most %d zones are allowed", primitive.ErrorZoneNotValid, maxZones)
+	}
+	names := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		if !validZoneName.MatchString(zone.Name) {
+			return fmt.Errorf("%w: name %q must be lower case letters, digits, _ and -", primitive.ErrorZoneNotValid, zone.Name)
+		}
+		if names[zone.Name] {
+			return fmt.Errorf("%w: %q is given more than once", primitive.ErrorZoneNotValid, zone.Name)
+		}
+		names[zone.Name] = true
+
+		if zone.Unit != "" && zone.Unit != primitive.ZoneUnitPixel && zone.Unit != primitive.ZoneUnitNormalized {
+			return fmt.Errorf("%w: %q unit must be %s or %s", primitive.ErrorZoneNotValid, zone.Name,
+				primitive.ZoneUnitPixel, primitive.ZoneUnitNormalized)
+		}
+		if zone.X < 0 || zone.Y < 0 || zone.Width <= 0 || zone.Height <= 0 {
+			return fmt.Errorf("%w: %q x and y must not be negative, width and height must be above 0", primitive.ErrorZoneNotValid, zone.Name)
+		}
+		if zone.Unit != primitive.ZoneUnitPixel && (zone.X+zone.Width > 1 || zone.Y+zone.Height > 1) {
+			return fmt.Errorf("%w: %q must be inside the page, its coordinates are fractions of it", primitive.ErrorZoneNotValid, zone.Name)
+		}
+		if zone.Psm != nil && (*zone.Psm < 0 || *zone.Psm > 13) {
+			return fmt.Errorf("%w: %q psm must be between 0 and 13", primitive.ErrorZoneNotValid, zone.Name)
+		}
+		if len(zone.Whitelist) > maxWhitelistBytes {
+			return fmt.Errorf("%w: %q whitelist must have at most %d characters", primitive.ErrorZoneNotValid, zone.Name, maxWhitelistBytes)
+		}
+	}
+	return nil
+}
+
+// profileZones returns the zones of the profile config
+func profileZones(zones []config.ZoneConfig) []primitive.OcrZone {
+	result := make([]primitive.OcrZone, 0, len(zones))
+	for _, zone := range zones {
+		result = append(result, primitive.OcrZone{
+			Name:      zone.Name,
+			X:         zone.X,
+			Y:         zone.Y,
+			Width:     zone.Width,
+			Height:    zone.Height,
+			Unit:      zone.Unit,
+			Psm:       zone.Psm,
+			Whitelist: zone.Whitelist,
+		})
+	}
+	return result
+}
+
+// zoneRect returns the zone in pixels of the image, clipped to it
+func zoneRect(zone primitive.OcrZone, width, height int) image.Rectangle {
+	rect := image.Rect(int(zone.X), int(zone.Y), int(zone.X+zone.Width), int(zone.Y+zone.Height))
+	if zone.Unit != primitive.ZoneUnitPixel {
+		rect = image.Rect(
+			int(math.Round(zone.X*float64(width))), int(math.Round(zone.Y*float64(height))),
+			int(math.Round((zone.X+zone.Width)*float64(width))), int(math.Round((zone.Y+zone.Height)*float64(height))))
+	}
+	return rect.Intersect(image.Rect(0, 0, width, height))
+}
+
+// encodeZones returns the zone results as they are stored
+func encodeZones(zones primitive.OcrZones) string {
+	if len(zones) == 0 {
+		return ""
+	}
+	raw, _ := json.Marshal(zones)
+	return string(raw)
+}
+
+// decodeZones returns the stored zone results, never nil
+func decodeZones(raw string) primitive.OcrZones {
+	zones := make(primitive.OcrZones)
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &zones)
+	}
+	return zones
+}
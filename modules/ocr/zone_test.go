@@ -0,0 +1,90 @@
+package ocr
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"go-ocr/modules/primitive"
+)
+
+func TestZoneRect(t *testing.T) {
+	tests := []struct {
+		name string
+		zone primitive.OcrZone
+		want image.Rectangle
+	}{
+		{
+			name: "normalized is the default unit",
+			zone: primitive.OcrZone{X: 0.5, Y: 0.25, Width: 0.5, Height: 0.5},
+			want: image.Rect(400, 150, 800, 450),
+		},
+		{
+			name: "normalized is rounded to the nearest pixel",
+			zone: primitive.OcrZone{X: 0.1234, Y: 0.1, Width: 0.3333, Height: 0.25, Unit: primitive.ZoneUnitNormalized},
+			want: image.Rect(99, 60, 365, 210),
+		},
+		{
+			name: "normalized whole page",
+			zone: primitive.OcrZone{X: 0, Y: 0, Width: 1, Height: 1, Unit: primitive.ZoneUnitNormalized},
+			want: image.Rect(0, 0, 800, 600),
+		},
+		{
+			name: "pixel",
+			zone: primitive.OcrZone{X: 10, Y: 20, Width: 100, Height: 50, Unit: primitive.ZoneUnitPixel},
+			want: image.Rect(10, 20, 110, 70),
+		},
+		{
+			name: "pixel is clipped to the image",
+			zone: primitive.OcrZone{X: 700, Y: 500, Width: 300, Height: 300, Unit: primitive.ZoneUnitPixel},
+			want: image.Rect(700, 500, 800, 600),
+		},
+		{
+			name: "pixel outside of the image is empty",
+			zone: primitive.OcrZone{X: 900, Y: 10, Width: 50, Height: 50, Unit: primitive.ZoneUnitPixel},
+			want: image.Rectangle{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := zoneRect(tt.zone, 800, 600); got != tt.want {
+				t.Fatalf("zoneRect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseZones(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty", value: ""},
+		{name: "normalized", value: `[{"name":"total","x":0.5,"y":0.8,"width":0.5,"height":0.2}]`, want: 1},
+		{name: "pixel outside of the fractions", value: `[{"name":"total","x":10,"y":10,"width":200,"height":40,"unit":"pixel"}]`, want: 1},
+		{name: "not an array", value: `{"name":"total"}`, wantErr: true},
+		{name: "normalized outside of the page", value: `[{"name":"total","x":0.8,"y":0,"width":0.5,"height":0.2}]`, wantErr: true},
+		{name: "negative", value: `[{"name":"total","x":-0.1,"y":0,"width":0.5,"height":0.2}]`, wantErr: true},
+		{name: "no width", value: `[{"name":"total","x":0,"y":0,"width":0,"height":0.2}]`, wantErr: true},
+		{name: "unknown unit", value: `[{"name":"total","x":0,"y":0,"width":1,"height":1,"unit":"inch"}]`, wantErr: true},
+		{name: "name of a path", value: `[{"name":"../total","x":0,"y":0,"width":1,"height":1}]`, wantErr: true},
+		{name: "name given twice", value: `[{"name":"a","x":0,"y":0,"width":1,"height":1},{"name":"a","x":0,"y":0,"width":1,"height":1}]`, wantErr: true},
+		{name: "psm out of range", value: `[{"name":"a","x":0,"y":0,"width":1,"height":1,"psm":14}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseZones(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseZones() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, primitive.ErrorZoneNotValid) {
+				t.Fatalf("ParseZones() error = %v, want %v", err, primitive.ErrorZoneNotValid)
+			}
+			if len(got) != tt.want {
+				t.Fatalf("ParseZones() got %d zones, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
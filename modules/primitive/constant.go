@@ -12,6 +12,96 @@ const (
 	SomethingWrongWithTheBodyRequest = "oops, something wrong with body request, please recheck!"
 	SomethingWentWrong               = "oops, something went wrong!"
 	ErrOcrNotFound                   = "ocr not found"
+	CreateApiKeySuccess              = "api key created, store the key safely because it can't be shown again"
+	RotateApiKeySuccess              = "api key rotated, store the key safely because it can't be shown again"
+	RevokeApiKeySuccess              = "api key revoked"
+	ErrApiKeyNotFound                = "api key not found"
+	ErrApiKeyAlreadyRevoked          = "api key already revoked"
+	TenantIdIsNotValid               = "tenant id given value is not valid"
+	FileIsTooLarge                   = "the uploaded file is larger than allowed"
+	NoMoreEvents                     = "the record is final and every event was sent"
+	ExportFormatIsNotValid           = "export format must be one of csv, ndjson or zip"
+	ErrExportNotFound                = "export not found"
+	ExportIsNotReady                 = "the export is not finished"
+	CreateExportSuccess              = "export started"
+	CursorIsNotValid                 = "cursor is not valid"
+	DocumentTypeIsNotValid           = "document type is not valid"
+	ImageCantBePreprocessed          = "the image can't be preprocessed, use a png, jpeg or gif image"
+	ErrTemplateNotFound              = "template not found"
+	TemplateIsNotValid               = "template is not valid"
+	TemplateNameAlreadyExists        = "a template with this name already exists"
+	CreateTemplateSuccess            = "template created"
+	UpdateTemplateSuccess            = "template updated"
+	DeleteTemplateSuccess            = "template deleted"
+	ZoneIsNotValid                   = "zone is not valid"
+	ErrUploadNotFound                = "file not found"
+	JournalIsCorrupted               = "the ocr journal has a corrupted entry"
+	ErrBatchNotFound                 = "batch not found"
+	BatchHasNoFile                   = "the batch needs at least one file in files"
+	BatchHasTooManyFiles             = "the batch has more files than allowed"
+	CreateBatchSuccess               = "batch started"
+	ServerIsShuttingDown             = "the server is shutting down, try again later"
 )
 
-var ErrorArticleNotFound = errors.New(ErrOcrNotFound)
+// output formats of a recognition, json is the text wrapped with the file name
+const (
+	FormatText = "text"
+	FormatHocr = "hocr"
+	FormatTsv  = "tsv"
+	FormatJson = "json"
+
+	// PsmDefault keeps the page segmentation mode of the engine
+	PsmDefault = -1
+)
+
+// units of the coordinates of a zone, normalized is the default
+const (
+	ZoneUnitPixel      = "pixel"
+	ZoneUnitNormalized = "normalized"
+)
+
+// formats of an export of the records
+const (
+	ExportFormatCsv    = "csv"
+	ExportFormatNdjson = "ndjson"
+	ExportFormatZip    = "zip"
+)
+
+// status of an export running in the background
+const (
+	ExportStatusPending    = "PENDING"
+	ExportStatusRunning    = "RUNNING"
+	ExportStatusSuccessful = "SUCCESSFUL"
+	ExportStatusFailed     = "FAILED"
+)
+
+// status of a batch, partial is a finished batch with some files failed
+const (
+	BatchStatusPending    = "PENDING"
+	BatchStatusRunning    = "RUNNING"
+	BatchStatusSuccessful = "SUCCESSFUL"
+	BatchStatusPartial    = "PARTIAL"
+	BatchStatusFailed     = "FAILED"
+)
+
+var (
+	ErrorArticleNotFound      = errors.New(ErrOcrNotFound)
+	ErrorApiKeyNotFound       = errors.New(ErrApiKeyNotFound)
+	ErrorApiKeyAlreadyRevoked = errors.New(ErrApiKeyAlreadyRevoked)
+	ErrorTenantIdIsNotValid   = errors.New(TenantIdIsNotValid)
+	ErrorNoMoreEvents         = errors.New(NoMoreEvents)
+	ErrorExportFormatNotValid = errors.New(ExportFormatIsNotValid)
+	ErrorExportNotFound       = errors.New(ErrExportNotFound)
+	ErrorExportNotReady       = errors.New(ExportIsNotReady)
+	ErrorCursorNotValid       = errors.New(CursorIsNotValid)
+	ErrorDocumentTypeNotValid = errors.New(DocumentTypeIsNotValid)
+	ErrorImageNotPreprocessed = errors.New(ImageCantBePreprocessed)
+	ErrorTemplateNotFound     = errors.New(ErrTemplateNotFound)
+	ErrorTemplateNotValid     = errors.New(TemplateIsNotValid)
+	ErrorTemplateNameExists   = errors.New(TemplateNameAlreadyExists)
+	ErrorZoneNotValid         = errors.New(ZoneIsNotValid)
+	ErrorUploadNotFound       = errors.New(ErrUploadNotFound)
+	ErrorJournalCorrupted     = errors.New(JournalIsCorrupted)
+	ErrorBatchNotFound        = errors.New(ErrBatchNotFound)
+	ErrorShuttingDown         = errors.New(ServerIsShuttingDown)
+)
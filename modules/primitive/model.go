@@ -3,25 +3,141 @@ package primitive
 import "time"
 
 type Ocr struct {
-	ID        int64     `gorm:"column:id"`
-	ImageUrl  string    `gorm:"column:image_url"`
-	Text      string    `gorm:"column:text"`
-	Status    string    `gorm:"column:status"`
-	CreatedAt time.Time `gorm:"column:created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at"`
-	DeletedAt time.Time `gorm:"column:deleted_at"`
+	ID           int64     `gorm:"column:id"`
+	TenantID     string    `gorm:"column:tenant_id"`
+	ImageUrl     string    `gorm:"column:image_url"`
+	Text         string    `gorm:"column:text"`
+	Status       string    `gorm:"column:status"`
+	DocumentType string    `gorm:"column:document_type"`
+	Language     string    `gorm:"column:language"`
+	Confidence   float64   `gorm:"column:confidence"`
+	Tags         string    `gorm:"column:tags"`
+	ContentHash  string    `gorm:"column:content_hash"`
+	Fields       string    `gorm:"column:fields"`
+	Words        string    `gorm:"column:words"`
+	ImageWidth   int       `gorm:"column:image_width"`
+	ImageHeight  int       `gorm:"column:image_height"`
+	Zones        string    `gorm:"column:zones"`
+	CreatedBy    string    `gorm:"column:created_by"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+	DeletedAt    time.Time `gorm:"column:deleted_at"`
 }
 
 type ParameterFindOcr struct {
-	Text      string
-	Status    string
-	PageSize  int
-	Offset    int
-	SortBy    string
-	SortOrder string
+	TenantID string
+	Text     string
+	// Statuses matches any of the statuses
+	Statuses     []string
+	DocumentType string
+	// Language matches the records recognized with this language among others
+	Language string
+	// Tags matches the records having every tag
+	Tags        []string
+	ContentHash string
+	// the ranges include the from time and exclude the to time, a zero time is open
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	// the confidence bounds are inclusive, nil is open
+	MinConfidence *float64
+	MaxConfidence *float64
+	PageSize      int
+	Offset        int
+	SortBy        string
+	SortOrder     string
+	// Cursor continues a keyset page after its row, the offset is ignored
+	Cursor *OcrCursor
+}
+
+// OcrCursor is the row a keyset page starts after, its sort value is read from the row
+type OcrCursor struct {
+	SortBy    string `json:"s"`
+	SortOrder string `json:"o"`
+	ID        int64  `json:"i"`
+	// Backward reads the page before the row instead of after it
+	Backward bool `json:"b,omitempty"`
+}
+
+// OcrExport is an export running in the background, its artifact is written under the export dir
+type OcrExport struct {
+	ID         string
+	TenantID   string
+	Format     string
+	Status     string
+	Rows       int64
+	Size       int64
+	Error      string
+	Path       string
+	CreatedBy  string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// OcrBatch is a batch of uploads recognized one after the other in the background
+type OcrBatch struct {
+	ID         string
+	TenantID   string
+	Status     string
+	Files      []OcrBatchFile
+	CreatedBy  string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// OcrBatchFile is an upload of a batch, RecordID is set once it is recognized
+type OcrBatchFile struct {
+	Name     string
+	Status   string
+	RecordID int64
+	Error    string
 }
 
 type ParameterOcrHandler struct {
 	Text   string
 	Status string
 }
+
+// Template extracts the fields of the records of its document type, Rules is the json of the rules
+type Template struct {
+	ID           int64     `gorm:"column:id"`
+	TenantID     string    `gorm:"column:tenant_id"`
+	Name         string    `gorm:"column:name"`
+	DocumentType string    `gorm:"column:document_type"`
+	Rules        string    `gorm:"column:rules"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+}
+
+// OcrWord is a word recognized by the engine, its box is in pixels of the original image
+type OcrWord struct {
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+	// Line counts the lines of the page from 1, the words of a line share it
+	Line int    `json:"line"`
+	BBox OcrBox `json:"bbox"`
+}
+
+// OcrDocument is what a template is run on, the page size is 0 when it is unknown
+type OcrDocument struct {
+	TenantID     string
+	DocumentType string
+	Text         string
+	Words        []OcrWord
+	Width        int
+	Height       int
+}
+
+type ApiKey struct {
+	ID         int64      `gorm:"column:id"`
+	Name       string     `gorm:"column:name"`
+	TenantID   string     `gorm:"column:tenant_id"`
+	Prefix     string     `gorm:"column:prefix"`
+	SecretHash string     `gorm:"column:secret_hash"`
+	Roles      string     `gorm:"column:roles"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at"`
+	RotatedAt  *time.Time `gorm:"column:rotated_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+}
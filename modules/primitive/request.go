@@ -4,4 +4,88 @@ type OcrRequest struct {
 	Image       string `form:"-"`
 	Type        string `form:"type" validate:"required"`
 	HOCREnabled string `form:"hocrEnabled"`
+	// Tags are comma separated labels kept with the record to filter the list
+	Tags string `form:"tags"`
+	// Zones is a json array of OcrZone, they replace the zones of the profile
+	Zones string `form:"zones"`
+	// FullText reads the whole page besides the zones, empty keeps the one of the profile
+	FullText string `form:"fullText"`
+}
+
+// OcrZone is a named rectangle recognized on its own, in pixels or in fractions of the page from
+// the top left. Psm and Whitelist only apply to the zone, a nil psm is the one of the recognition.
+type OcrZone struct {
+	Name      string  `json:"name"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Width     float64 `json:"width"`
+	Height    float64 `json:"height"`
+	Unit      string  `json:"unit,omitempty"`
+	Psm       *int    `json:"psm,omitempty"`
+	Whitelist string  `json:"whitelist,omitempty"`
+}
+
+// RecognizeOptions are the engine settings of one recognition, shared by the http and the command line
+type RecognizeOptions struct {
+	Languages []string
+	Psm       int
+	Format    string
+	// DocumentType is the profile the steps below come from, empty without profile
+	DocumentType string
+	Preprocess   []string
+	Postprocess  []string
+	Extractors   []string
+	// Zones are read instead of the whole page, unless FullText is set
+	Zones    []OcrZone
+	FullText bool
+}
+
+type TemplateRequest struct {
+	Name         string         `json:"name" validate:"required"`
+	DocumentType string         `json:"documentType" validate:"required"`
+	Rules        []TemplateRule `json:"rules" validate:"required"`
+}
+
+// TemplateRule extracts one field, it is found by exactly one of the regex over the
+// whole text, the region next to an anchor keyword or a fixed zone of the page
+type TemplateRule struct {
+	Field  string          `json:"field"`
+	Regex  string          `json:"regex,omitempty"`
+	Anchor *TemplateAnchor `json:"anchor,omitempty"`
+	Zone   *TemplateZone   `json:"zone,omitempty"`
+}
+
+// TemplateAnchor is the region moved from the top left of the keyword by the offsets, the
+// offsets and the size are fractions of the page. The value is the first match of the
+// pattern in the region, or its whole text without pattern.
+type TemplateAnchor struct {
+	Keyword string  `json:"keyword"`
+	OffsetX float64 `json:"offsetX"`
+	OffsetY float64 `json:"offsetY"`
+	Width   float64 `json:"width"`
+	Height  float64 `json:"height"`
+	Pattern string  `json:"pattern,omitempty"`
+}
+
+// TemplateZone is a fixed region of the page, its coordinates are fractions of the page
+// from the top left
+type TemplateZone struct {
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Width   float64 `json:"width"`
+	Height  float64 `json:"height"`
+	Pattern string  `json:"pattern,omitempty"`
+}
+
+// TemplateDryRunRequest runs a stored template, or the given one, on a record
+type TemplateDryRunRequest struct {
+	RecordID   int64            `json:"recordId" validate:"required"`
+	TemplateID int64            `json:"templateId"`
+	Template   *TemplateRequest `json:"template"`
+}
+
+type ApiKeyRequest struct {
+	Name     string   `json:"name" validate:"required"`
+	TenantID string   `json:"tenantId"`
+	Roles    []string `json:"roles"`
 }
@@ -3,15 +3,165 @@ package primitive
 import "time"
 
 type OCrResponse struct {
-	ID        int64     `json:"id"`
-	ImageUrl  string    `json:"image_url"`
-	Text      string    `json:"text"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int64      `json:"id"`
+	TenantID     string     `json:"tenant_id"`
+	ImageUrl     string     `json:"image_url"`
+	Text         string     `json:"text"`
+	Status       string     `json:"status"`
+	DocumentType string     `json:"document_type"`
+	Language     string     `json:"language"`
+	Confidence   float64    `json:"confidence"`
+	Tags         []string   `json:"tags"`
+	ContentHash  string     `json:"content_hash"`
+	Fields       []OcrField `json:"fields"`
+	Zones        OcrZones   `json:"zones"`
+	CreatedBy    string     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RecognizeResponse is the result of a recognition of a local file, the record is set when it is stored
+type RecognizeResponse struct {
+	File         string       `json:"file"`
+	Format       string       `json:"format"`
+	DocumentType string       `json:"document_type,omitempty"`
+	Text         string       `json:"text"`
+	Fields       []OcrField   `json:"fields,omitempty"`
+	Zones        OcrZones     `json:"zones,omitempty"`
+	Record       *OCrResponse `json:"record,omitempty"`
+}
+
+// OcrZones are the results of the zones keyed by their name
+type OcrZones map[string]OcrZoneResult
+
+// OcrZoneResult is the text read in a zone, the box is the zone in pixels clipped to the image
+type OcrZoneResult struct {
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+	BBox       OcrBox  `json:"bbox"`
+}
+
+// OcrField is a value extracted from the recognized text, the confidence and the box
+// are the ones of its words and are left empty when the words are unknown
+type OcrField struct {
+	Name       string  `json:"name"`
+	Value      string  `json:"value"`
+	Confidence float64 `json:"confidence"`
+	BBox       *OcrBox `json:"bbox"`
+}
+
+// OcrBox is a rectangle in pixels from the top left of the image
+type OcrBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type TemplateResponse struct {
+	ID           int64          `json:"id"`
+	TenantID     string         `json:"tenant_id"`
+	Name         string         `json:"name"`
+	DocumentType string         `json:"document_type"`
+	Rules        []TemplateRule `json:"rules"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// TemplateDryRunResponse holds the fields the template finds in the record, nothing is stored
+type TemplateDryRunResponse struct {
+	RecordID   int64      `json:"record_id"`
+	TemplateID int64      `json:"template_id,omitempty"`
+	Fields     []OcrField `json:"fields"`
+}
+
+// OcrCursorPage is a keyset page of records, the cursors are empty without next or previous page
+type OcrCursorPage struct {
+	Data       []OCrResponse
+	Total      int64
+	NextCursor string
+	PrevCursor string
+}
+
+// OcrExportResponse is the state of an export, the download url is set once it succeeded
+type OcrExportResponse struct {
+	ID          string     `json:"id"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	Rows        int64      `json:"rows"`
+	Size        int64      `json:"size"`
+	Error       string     `json:"error,omitempty"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	DownloadUrl string     `json:"download_url,omitempty"`
+}
+
+// OcrBatchResponse is the state of a batch, the files are in the order of the upload
+type OcrBatchResponse struct {
+	ID         string                 `json:"id"`
+	Status     string                 `json:"status"`
+	Total      int                    `json:"total"`
+	Completed  int                    `json:"completed"`
+	Failed     int                    `json:"failed"`
+	Percent    int                    `json:"percent"`
+	Files      []OcrBatchFileResponse `json:"files"`
+	CreatedBy  string                 `json:"created_by"`
+	CreatedAt  time.Time              `json:"created_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+}
+
+// OcrBatchFileResponse is the state of an upload of a batch, completed and failed files are done
+type OcrBatchFileResponse struct {
+	Index    int    `json:"index"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	RecordID int64  `json:"record_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// OcrBatchProgressEvent is the data of the progress events of a batch
+type OcrBatchProgressEvent struct {
+	ID        string `json:"id"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+	Total     int    `json:"total"`
+	Percent   int    `json:"percent"`
+}
+
+// OcrBatchResultEvent is the data of the result events of a batch, one per file with its record once it succeeded
+type OcrBatchResultEvent struct {
+	ID     string               `json:"id"`
+	File   OcrBatchFileResponse `json:"file"`
+	Record *OCrResponse         `json:"record,omitempty"`
+}
+
+// OcrStatusEvent is the data of the status events of a record
+type OcrStatusEvent struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
 }
 
 type HealthResponse struct {
 	Db    string `json:"db"`
 	Redis string `json:"redis"`
 }
+
+type ApiKeyResponse struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	TenantID  string     `json:"tenant_id"`
+	Prefix    string     `json:"prefix"`
+	Roles     []string   `json:"roles"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	RotatedAt *time.Time `json:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// ApiKeyCreatedResponse is the only response that contains the plain key,
+// it can't be retrieved again after that.
+type ApiKeyCreatedResponse struct {
+	ApiKeyResponse
+	Key string `json:"key"`
+}
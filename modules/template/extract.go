@@ -0,0 +1,249 @@
+package template
+
+import (
+	"encoding/json"
+	"image"
+	"math"
+	"regexp"
+	"strings"
+
+	"go-ocr/modules/primitive"
+)
+
+// layout is the text of the words, a word keeps the span of its characters in it
+// so a match in the text can be mapped back to the words and their boxes
+type layout struct {
+	text  string
+	words []primitive.OcrWord
+	spans [][2]int
+}
+
+func newLayout(words []primitive.OcrWord) layout {
+	var builder strings.Builder
+	spans := make([][2]int, len(words))
+	for idx, word := range words {
+		if idx > 0 {
+			if word.Line != words[idx-1].Line {
+				builder.WriteByte('\n')
+			} else {
+				builder.WriteByte(' ')
+			}
+		}
+		start := builder.Len()
+		builder.WriteString(word.Text)
+		spans[idx] = [2]int{start, builder.Len()}
+	}
+	return layout{text: builder.String(), words: words, spans: spans}
+}
+
+// wordsIn returns the indexes of the words overlapping the characters from start to end
+func (l layout) wordsIn(start, end int) []int {
+	var indexes []int
+	for idx, span := range l.spans {
+		if span[0] < end && span[1] > start {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+// field returns the field of the characters from start to end, its confidence and box are the ones of their words
+func (l layout) field(name string, start, end int) primitive.OcrField {
+	field := primitive.OcrField{Name: name, Value: strings.TrimSpace(l.text[start:end])}
+	indexes := l.wordsIn(start, end)
+	if len(indexes) == 0 {
+		return field
+	}
+
+	var total float64
+	box := l.words[indexes[0]].BBox
+	for _, idx := range indexes {
+		total += l.words[idx].Confidence
+		box = union(box, l.words[idx].BBox)
+	}
+	field.Confidence = math.Round(total/float64(len(indexes))*100) / 100
+	field.BBox = &box
+	return field
+}
+
+// find returns the field of the first match of the pattern, the value is its first group when it has one
+func (l layout) find(name string, pattern *regexp.Regexp) (primitive.OcrField, bool) {
+	match := pattern.FindStringSubmatchIndex(l.text)
+	if match == nil {
+		return primitive.OcrField{}, false
+	}
+	start, end := match[0], match[1]
+	if len(match) >= 4 && match[2] >= 0 {
+		start, end = match[2], match[3]
+	}
+	if strings.TrimSpace(l.text[start:end]) == "" {
+		return primitive.OcrField{}, false
+	}
+	return l.field(name, start, end), true
+}
+
+// region returns the field of the words centered in the rectangle, skipping the excluded ones.
+// The value is the first match of the pattern or the whole text of the words without pattern.
+func (l layout) region(name string, rect image.Rectangle, pattern *regexp.Regexp, excluded map[int]bool) (primitive.OcrField, bool) {
+	var words []primitive.OcrWord
+	for idx, word := range l.words {
+		center := image.Pt(word.BBox.X+word.BBox.Width/2, word.BBox.Y+word.BBox.Height/2)
+		if !excluded[idx] && center.In(rect) {
+			words = append(words, word)
+		}
+	}
+	inside := newLayout(words)
+	if pattern != nil {
+		return inside.find(name, pattern)
+	}
+	if strings.TrimSpace(inside.text) == "" {
+		return primitive.OcrField{}, false
+	}
+	return inside.field(name, 0, len(inside.text)), true
+}
+
+func union(a, b primitive.OcrBox) primitive.OcrBox {
+	rect := image.Rect(a.X, a.Y, a.X+a.Width, a.Y+a.Height).Union(image.Rect(b.X, b.Y, b.X+b.Width, b.Y+b.Height))
+	return primitive.OcrBox{X: rect.Min.X, Y: rect.Min.Y, Width: rect.Dx(), Height: rect.Dy()}
+}
+
+// pageSize returns the size of the document, the extent of its words when it is unknown
+func pageSize(document primitive.OcrDocument) (width, height int) {
+	width, height = document.Width, document.Height
+	if width > 0 && height > 0 {
+		return width, height
+	}
+	for _, word := range document.Words {
+		width = max(width, word.BBox.X+word.BBox.Width)
+		height = max(height, word.BBox.Y+word.BBox.Height)
+	}
+	return width, height
+}
+
+// scaled returns the rectangle of the fractions of the page from the given origin
+func scaled(originX, originY int, x, y, width, height float64, pageWidth, pageHeight int) image.Rectangle {
+	minX := originX + int(math.Round(x*float64(pageWidth)))
+	minY := originY + int(math.Round(y*float64(pageHeight)))
+	return image.Rect(minX, minY,
+		minX+int(math.Round(width*float64(pageWidth))), minY+int(math.Round(height*float64(pageHeight))))
+}
+
+// keywordPattern matches the keyword without case, its words may be separated by any space
+func keywordPattern(keyword string) (*regexp.Regexp, error) {
+	parts := strings.Fields(keyword)
+	for idx, part := range parts {
+		parts[idx] = regexp.QuoteMeta(part)
+	}
+	return regexp.Compile(`(?i)` + strings.Join(parts, `\s+`))
+}
+
+// optionalPattern compiles the pattern of an anchor or a zone, nil when there is none
+func optionalPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// Extract runs the rules on the document in order, the fields not found are left out. Without
+// the words of the document only the regex rules run, on its text.
+func Extract(document primitive.OcrDocument, rules []primitive.TemplateRule) []primitive.OcrField {
+	page := newLayout(document.Words)
+	if len(document.Words) == 0 {
+		page = layout{text: document.Text}
+	}
+	width, height := pageSize(document)
+
+	fields := make([]primitive.OcrField, 0, len(rules))
+	for _, rule := range rules {
+		field, found := extractRule(page, rule, width, height)
+		if found {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func extractRule(page layout, rule primitive.TemplateRule, width, height int) (primitive.OcrField, bool) {
+	switch {
+	case rule.Regex != "":
+		pattern, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return primitive.OcrField{}, false
+		}
+		return page.find(rule.Field, pattern)
+
+	case rule.Zone != nil:
+		pattern, err := optionalPattern(rule.Zone.Pattern)
+		if err != nil || len(page.words) == 0 {
+			return primitive.OcrField{}, false
+		}
+		zone := rule.Zone
+		rect := scaled(0, 0, zone.X, zone.Y, zone.Width, zone.Height, width, height)
+		return page.region(rule.Field, rect, pattern, nil)
+
+	case rule.Anchor != nil:
+		anchor := rule.Anchor
+		pattern, err := optionalPattern(anchor.Pattern)
+		if err != nil || len(page.words) == 0 {
+			return primitive.OcrField{}, false
+		}
+		keyword, err := keywordPattern(anchor.Keyword)
+		if err != nil {
+			return primitive.OcrField{}, false
+		}
+		// the keyword can be printed more than once, the first one with a value wins
+		for _, match := range keyword.FindAllStringIndex(page.text, -1) {
+			indexes := page.wordsIn(match[0], match[1])
+			if len(indexes) == 0 {
+				continue
+			}
+			box := page.words[indexes[0]].BBox
+			excluded := make(map[int]bool, len(indexes))
+			for _, idx := range indexes {
+				box = union(box, page.words[idx].BBox)
+				excluded[idx] = true
+			}
+			rect := scaled(box.X, box.Y, anchor.OffsetX, anchor.OffsetY, anchor.Width, anchor.Height, width, height)
+			if field, found := page.region(rule.Field, rect, pattern, excluded); found {
+				return field, true
+			}
+		}
+	}
+	return primitive.OcrField{}, false
+}
+
+// Locate gives the field the confidence and the box of the words holding its value, the field is
+// returned as is when the value is not among the words
+func Locate(words []primitive.OcrWord, field primitive.OcrField) primitive.OcrField {
+	if len(words) == 0 || strings.TrimSpace(field.Value) == "" {
+		return field
+	}
+	pattern, err := keywordPattern(field.Value)
+	if err != nil {
+		return field
+	}
+	page := newLayout(words)
+	match := pattern.FindStringIndex(page.text)
+	if match == nil {
+		return field
+	}
+	located := page.field(field.Name, match[0], match[1])
+	located.Value = field.Value
+	return located
+}
+
+// EncodeRules returns the rules as they are stored
+func EncodeRules(rules []primitive.TemplateRule) string {
+	raw, _ := json.Marshal(rules)
+	return string(raw)
+}
+
+// DecodeRules returns the stored rules, never nil
+func DecodeRules(raw string) []primitive.TemplateRule {
+	rules := make([]primitive.TemplateRule, 0)
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &rules)
+	}
+	return rules
+}
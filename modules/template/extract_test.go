@@ -0,0 +1,151 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+
+	"go-ocr/modules/primitive"
+)
+
+// invoicePage is a page of 1000 by 1000 pixels with a header, an invoice number and a total at the bottom right
+func invoicePage() primitive.OcrDocument {
+	words := []primitive.OcrWord{
+		{Text: "ACME", Confidence: 95, Line: 1, BBox: primitive.OcrBox{X: 50, Y: 40, Width: 100, Height: 20}},
+		{Text: "Corp", Confidence: 93, Line: 1, BBox: primitive.OcrBox{X: 160, Y: 40, Width: 80, Height: 20}},
+		{Text: "Invoice", Confidence: 90, Line: 2, BBox: primitive.OcrBox{X: 50, Y: 100, Width: 120, Height: 20}},
+		{Text: "No:", Confidence: 90, Line: 2, BBox: primitive.OcrBox{X: 180, Y: 100, Width: 50, Height: 20}},
+		{Text: "INV-2024-001", Confidence: 80, Line: 2, BBox: primitive.OcrBox{X: 240, Y: 100, Width: 200, Height: 20}},
+		{Text: "Total", Confidence: 96, Line: 3, BBox: primitive.OcrBox{X: 600, Y: 900, Width: 80, Height: 20}},
+		{Text: "Amount", Confidence: 94, Line: 3, BBox: primitive.OcrBox{X: 690, Y: 900, Width: 100, Height: 20}},
+		{Text: "$1,234.50", Confidence: 88, Line: 3, BBox: primitive.OcrBox{X: 800, Y: 900, Width: 150, Height: 20}},
+	}
+	return primitive.OcrDocument{
+		Text:   "ACME Corp\nInvoice No: INV-2024-001\nTotal Amount $1,234.50",
+		Words:  words,
+		Width:  1000,
+		Height: 1000,
+	}
+}
+
+func TestExtract(t *testing.T) {
+	invoiceNumber := primitive.OcrField{Name: "number", Value: "INV-2024-001", Confidence: 80, BBox: &primitive.OcrBox{X: 240, Y: 100, Width: 200, Height: 20}}
+	total := primitive.OcrField{Name: "total", Value: "$1,234.50", Confidence: 88, BBox: &primitive.OcrBox{X: 800, Y: 900, Width: 150, Height: 20}}
+	totalRegion := &primitive.TemplateAnchor{Keyword: "total   AMOUNT", Width: 0.4, Height: 0.03}
+
+	tests := []struct {
+		name     string
+		document primitive.OcrDocument
+		rules    []primitive.TemplateRule
+		want     []primitive.OcrField
+	}{
+		{
+			name:     "regex",
+			document: invoicePage(),
+			rules:    []primitive.TemplateRule{{Field: "number", Regex: `INV-\d{4}-\d+`}},
+			want:     []primitive.OcrField{invoiceNumber},
+		},
+		{
+			name:     "regex value is its first group",
+			document: invoicePage(),
+			rules:    []primitive.TemplateRule{{Field: "number", Regex: `No:\s*(\S+)`}},
+			want:     []primitive.OcrField{invoiceNumber},
+		},
+		{
+			name:     "anchor keyword without case with any space",
+			document: invoicePage(),
+			rules:    []primitive.TemplateRule{{Field: "total", Anchor: totalRegion}},
+			want:     []primitive.OcrField{total},
+		},
+		{
+			name:     "anchor with a pattern",
+			document: invoicePage(),
+			rules: []primitive.TemplateRule{{Field: "total", Anchor: &primitive.TemplateAnchor{
+				Keyword: "total", Width: 0.4, Height: 0.03, Pattern: `\$[\d,.]+`,
+			}}},
+			want: []primitive.OcrField{total},
+		},
+		{
+			name:     "zone",
+			document: invoicePage(),
+			rules:    []primitive.TemplateRule{{Field: "vendor", Zone: &primitive.TemplateZone{Width: 0.3, Height: 0.06}}},
+			want: []primitive.OcrField{
+				{Name: "vendor", Value: "ACME Corp", Confidence: 94, BBox: &primitive.OcrBox{X: 50, Y: 40, Width: 190, Height: 20}},
+			},
+		},
+		{
+			name:     "fields not found are left out and the order is kept",
+			document: invoicePage(),
+			rules: []primitive.TemplateRule{
+				{Field: "total", Anchor: totalRegion},
+				{Field: "invalid", Regex: `(`},
+				{Field: "missing", Regex: `IBAN`},
+				{Field: "empty", Zone: &primitive.TemplateZone{X: 0.5, Y: 0.3, Width: 0.1, Height: 0.1}},
+				{Field: "unknown keyword", Anchor: &primitive.TemplateAnchor{Keyword: "due date", Width: 0.4, Height: 0.03}},
+				{Field: "number", Regex: `INV-\d{4}-\d+`},
+			},
+			want: []primitive.OcrField{total, invoiceNumber},
+		},
+		{
+			name:     "without words only the regex rules run on the text",
+			document: primitive.OcrDocument{Text: invoicePage().Text},
+			rules: []primitive.TemplateRule{
+				{Field: "number", Regex: `INV-\d{4}-\d+`},
+				{Field: "total", Anchor: totalRegion},
+				{Field: "vendor", Zone: &primitive.TemplateZone{Width: 0.3, Height: 0.06}},
+			},
+			want: []primitive.OcrField{{Name: "number", Value: "INV-2024-001"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.document, tt.rules); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Extract() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	document := invoicePage()
+	if width, height := pageSize(document); width != 1000 || height != 1000 {
+		t.Fatalf("pageSize() = %d, %d, want the size of the document", width, height)
+	}
+
+	// the extent of the words stands for an unknown size
+	document.Width, document.Height = 0, 0
+	if width, height := pageSize(document); width != 950 || height != 920 {
+		t.Fatalf("pageSize() = %d, %d, want 950, 920", width, height)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	words := invoicePage().Words
+	tests := []struct {
+		name  string
+		field primitive.OcrField
+		want  primitive.OcrField
+	}{
+		{
+			name:  "the value is among the words",
+			field: primitive.OcrField{Name: "vendor", Value: "acme corp"},
+			want:  primitive.OcrField{Name: "vendor", Value: "acme corp", Confidence: 94, BBox: &primitive.OcrBox{X: 50, Y: 40, Width: 190, Height: 20}},
+		},
+		{
+			name:  "the value is not among the words",
+			field: primitive.OcrField{Name: "vendor", Value: "Globex"},
+			want:  primitive.OcrField{Name: "vendor", Value: "Globex"},
+		},
+		{
+			name:  "empty value",
+			field: primitive.OcrField{Name: "vendor", Value: " "},
+			want:  primitive.OcrField{Name: "vendor", Value: " "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Locate(words, tt.field); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Locate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go-ocr/infrastructure/httplib"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/validator"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Http struct {
+	serviceTemplate ServiceInterface
+}
+
+func NewHttp(serviceTemplate ServiceInterface) InterfaceHttp {
+	return &Http{
+		serviceTemplate: serviceTemplate,
+	}
+}
+
+type InterfaceHttp interface {
+	GroupTemplate(group *gin.RouterGroup)
+}
+
+func (h *Http) GroupTemplate(g *gin.RouterGroup) {
+	g.POST("", h.CreateTemplate)
+	g.GET("", h.ListTemplate)
+	g.POST("/dry-run", h.DryRunTemplate)
+	g.GET("/:id", h.DetailTemplate)
+	g.PUT("/:id", h.UpdateTemplate)
+	g.DELETE("/:id", h.DeleteTemplate)
+}
+
+func (h *Http) CreateTemplate(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.CreateTemplate")
+
+	requestBody, ok := bindTemplateRequest(ctx, logCtx)
+	if !ok {
+		return
+	}
+
+	response, err := h.serviceTemplate.CreateTemplate(ctx, requestBody)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceTemplate.CreateTemplate")
+		setTemplateErrorResponse(ctx, err)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusCreated, primitive.CreateTemplateSuccess, response)
+	return
+}
+
+func (h *Http) ListTemplate(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.ListTemplate")
+
+	data, err := h.serviceTemplate.ListTemplate(ctx, ctx.Query("documentType"))
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceTemplate.ListTemplate")
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, primitive.SomethingWentWrong)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), data)
+	return
+}
+
+func (h *Http) DetailTemplate(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.DetailTemplate")
+
+	idInt, err := getIdParam(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "getIdParam")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.ParamIdIsZeroOrNullString)
+		return
+	}
+
+	response, err := h.serviceTemplate.GetTemplate(ctx, idInt)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceTemplate.GetTemplate")
+		setTemplateErrorResponse(ctx, err)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), response)
+	return
+}
+
+func (h *Http) UpdateTemplate(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.UpdateTemplate")
+
+	idInt, err := getIdParam(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "getIdParam")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.ParamIdIsZeroOrNullString)
+		return
+	}
+
+	requestBody, ok := bindTemplateRequest(ctx, logCtx)
+	if !ok {
+		return
+	}
+
+	response, err := h.serviceTemplate.UpdateTemplate(ctx, idInt, requestBody)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceTemplate.UpdateTemplate")
+		setTemplateErrorResponse(ctx, err)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, primitive.UpdateTemplateSuccess, response)
+	return
+}
+
+func (h *Http) DeleteTemplate(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.DeleteTemplate")
+
+	idInt, err := getIdParam(ctx)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "getIdParam")
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.ParamIdIsZeroOrNullString)
+		return
+	}
+
+	if err = h.serviceTemplate.DeleteTemplate(ctx, idInt); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceTemplate.DeleteTemplate")
+		setTemplateErrorResponse(ctx, err)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, primitive.DeleteTemplateSuccess, nil)
+	return
+}
+
+func (h *Http) DryRunTemplate(ctx *gin.Context) {
+	logCtx := fmt.Sprintf("handler.DryRunTemplate")
+
+	var requestBody primitive.TemplateDryRunRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Error(ctx, logCtx, "ctx.ShouldBindJSON got err : %v", err)
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.SomethingWrongWithTheBodyRequest)
+		return
+	}
+
+	errValidateStruct := validator.ValidateStructResponseSliceString(requestBody)
+	if errValidateStruct != nil {
+		logger.Error(ctx, logCtx, "validator.ValidateStructResponseSliceString got err : %v", errValidateStruct)
+		httplib.SetCustomResponse(ctx, http.StatusBadRequest, http.StatusText(http.StatusBadRequest), nil, errValidateStruct)
+		return
+	}
+
+	response, err := h.serviceTemplate.DryRunTemplate(ctx, requestBody)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "h.serviceTemplate.DryRunTemplate")
+		setTemplateErrorResponse(ctx, err)
+		return
+	}
+
+	httplib.SetSuccessResponse(ctx, http.StatusOK, http.StatusText(http.StatusOK), response)
+	return
+}
+
+// bindTemplateRequest reads the template of the body, the response is set when it is not valid
+func bindTemplateRequest(ctx *gin.Context, logCtx string) (primitive.TemplateRequest, bool) {
+	var requestBody primitive.TemplateRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Error(ctx, logCtx, "ctx.ShouldBindJSON got err : %v", err)
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, primitive.SomethingWrongWithTheBodyRequest)
+		return requestBody, false
+	}
+
+	errValidateStruct := validator.ValidateStructResponseSliceString(requestBody)
+	if errValidateStruct != nil {
+		logger.Error(ctx, logCtx, "validator.ValidateStructResponseSliceString got err : %v", errValidateStruct)
+		httplib.SetCustomResponse(ctx, http.StatusBadRequest, http.StatusText(http.StatusBadRequest), nil, errValidateStruct)
+		return requestBody, false
+	}
+	return requestBody, true
+}
+
+func getIdParam(ctx *gin.Context) (int64, error) {
+	idInt, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || idInt == 0 {
+		return 0, errors.New(primitive.ParamIdIsZeroOrNullString)
+	}
+	return idInt, nil
+}
+
+func setTemplateErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case utils.ContainsError(err, []error{primitive.ErrorTemplateNotValid, primitive.ErrorDocumentTypeNotValid}):
+		httplib.SetErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, primitive.ErrorArticleNotFound):
+		httplib.SetErrorResponse(ctx, http.StatusNotFound, primitive.RecordOCrNotFound)
+	case utils.ContainsError(err, []error{gorm.ErrRecordNotFound, primitive.ErrorTemplateNotFound}):
+		httplib.SetErrorResponse(ctx, http.StatusNotFound, primitive.ErrTemplateNotFound)
+	case errors.Is(err, primitive.ErrorTemplateNameExists):
+		httplib.SetErrorResponse(ctx, http.StatusConflict, primitive.TemplateNameAlreadyExists)
+	default:
+		httplib.SetErrorResponse(ctx, http.StatusInternalServerError, primitive.SomethingWentWrong)
+	}
+}
@@ -0,0 +1,102 @@
+package template
+
+import (
+	"context"
+
+	"go-ocr/modules/primitive"
+
+	"gorm.io/gorm"
+)
+
+type RepositoryInterface interface {
+	CreateTemplate(ctx context.Context, request primitive.Template) (result primitive.Template, err error)
+	UpdateTemplate(ctx context.Context, request primitive.Template) (result primitive.Template, err error)
+	DeleteTemplate(ctx context.Context, tenantID string, id int64) (err error)
+	FindTemplateByID(ctx context.Context, tenantID string, id int64) (result primitive.Template, err error)
+	FindTemplateByName(ctx context.Context, tenantID string, name string) (result primitive.Template, err error)
+	// FindAllTemplate returns the templates of the tenant, only the ones of the document type when it is set
+	FindAllTemplate(ctx context.Context, tenantID string, documentType string) (result []primitive.Template, err error)
+}
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (repo *Repository) CreateTemplate(ctx context.Context, request primitive.Template) (result primitive.Template, err error) {
+	err = repo.db.WithContext(ctx).Table("template").Create(&request).Error
+	if err != nil {
+		return result, err
+	}
+	return request, nil
+}
+
+func (repo *Repository) UpdateTemplate(ctx context.Context, request primitive.Template) (result primitive.Template, err error) {
+	query := repo.db.WithContext(ctx).Table("template").
+		Where("id = ? and tenant_id = ?", request.ID, request.TenantID).
+		Updates(map[string]interface{}{
+			"name":          request.Name,
+			"document_type": request.DocumentType,
+			"rules":         request.Rules,
+			"updated_at":    request.UpdatedAt,
+		})
+	if query.Error != nil {
+		return result, query.Error
+	}
+	if query.RowsAffected == 0 {
+		return result, primitive.ErrorTemplateNotFound
+	}
+	return repo.FindTemplateByID(ctx, request.TenantID, request.ID)
+}
+
+func (repo *Repository) DeleteTemplate(ctx context.Context, tenantID string, id int64) (err error) {
+	query := repo.db.WithContext(ctx).Table("template").
+		Where("id = ? and tenant_id = ?", id, tenantID).
+		Delete(&primitive.Template{})
+	if query.Error != nil {
+		return query.Error
+	}
+	if query.RowsAffected == 0 {
+		return primitive.ErrorTemplateNotFound
+	}
+	return nil
+}
+
+func (repo *Repository) FindTemplateByID(ctx context.Context, tenantID string, id int64) (result primitive.Template, err error) {
+	err = repo.db.WithContext(ctx).Table("template").
+		Where("id = ? and tenant_id = ?", id, tenantID).
+		First(&result).
+		Error
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (repo *Repository) FindTemplateByName(ctx context.Context, tenantID string, name string) (result primitive.Template, err error) {
+	err = repo.db.WithContext(ctx).Table("template").
+		Where("name = ? and tenant_id = ?", name, tenantID).
+		First(&result).
+		Error
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (repo *Repository) FindAllTemplate(ctx context.Context, tenantID string, documentType string) (result []primitive.Template, err error) {
+	query := repo.db.WithContext(ctx).Table("template").Where("tenant_id = ?", tenantID)
+	if documentType != "" {
+		query = query.Where("document_type = ?", documentType)
+	}
+	err = query.Order("id asc").Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package template
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go-ocr/modules/primitive"
+)
+
+// InMemoryRepository stores templates in memory.
+type InMemoryRepository struct {
+	templates  map[int64]primitive.Template
+	idSequence int64
+	mu         sync.RWMutex
+}
+
+// CreateTemplate adds a new template to the in-memory repository.
+func (i *InMemoryRepository) CreateTemplate(ctx context.Context, request primitive.Template) (result primitive.Template, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	request.ID = i.idSequence
+	i.idSequence++
+	i.templates[request.ID] = request
+
+	return request, nil
+}
+
+// UpdateTemplate replaces the stored template with the same ID and tenant.
+func (i *InMemoryRepository) UpdateTemplate(ctx context.Context, request primitive.Template) (result primitive.Template, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	stored, ok := i.templates[request.ID]
+	if !ok || stored.TenantID != request.TenantID {
+		return primitive.Template{}, primitive.ErrorTemplateNotFound
+	}
+	request.CreatedAt = stored.CreatedAt
+	i.templates[request.ID] = request
+
+	return request, nil
+}
+
+// DeleteTemplate removes the template of the tenant.
+func (i *InMemoryRepository) DeleteTemplate(ctx context.Context, tenantID string, id int64) (err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	stored, ok := i.templates[id]
+	if !ok || stored.TenantID != tenantID {
+		return primitive.ErrorTemplateNotFound
+	}
+	delete(i.templates, id)
+
+	return nil
+}
+
+// FindTemplateByID retrieves a template of the tenant by its ID.
+func (i *InMemoryRepository) FindTemplateByID(ctx context.Context, tenantID string, id int64) (result primitive.Template, err error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	stored, ok := i.templates[id]
+	if !ok || stored.TenantID != tenantID {
+		return primitive.Template{}, primitive.ErrorTemplateNotFound
+	}
+
+	return stored, nil
+}
+
+// FindTemplateByName retrieves a template of the tenant by its name.
+func (i *InMemoryRepository) FindTemplateByName(ctx context.Context, tenantID string, name string) (result primitive.Template, err error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, stored := range i.templates {
+		if stored.TenantID == tenantID && stored.Name == name {
+			return stored, nil
+		}
+	}
+
+	return primitive.Template{}, primitive.ErrorTemplateNotFound
+}
+
+// FindAllTemplate returns the templates of the tenant ordered by the oldest first.
+func (i *InMemoryRepository) FindAllTemplate(ctx context.Context, tenantID string, documentType string) (result []primitive.Template, err error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	result = make([]primitive.Template, 0)
+	for _, stored := range i.templates {
+		if stored.TenantID != tenantID || (documentType != "" && stored.DocumentType != documentType) {
+			continue
+		}
+		result = append(result, stored)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].ID < result[b].ID
+	})
+
+	return result, nil
+}
+
+// NewInMemoryRepository creates a new instance of InMemoryRepository.
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		templates:  make(map[int64]primitive.Template),
+		idSequence: 1,
+	}
+}
@@ -0,0 +1,304 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go-ocr/infrastructure/config"
+	logger "go-ocr/infrastructure/log"
+	"go-ocr/infrastructure/tenant"
+	"go-ocr/modules/primitive"
+	"go-ocr/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxNameLength = 64
+	maxRules      = 50
+)
+
+var validField = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// RecordReader gives the recognized words of a record of the tenant in the context
+type RecordReader interface {
+	GetOcrDocument(ctx context.Context, id int64) (primitive.OcrDocument, error)
+}
+
+type ServiceInterface interface {
+	CreateTemplate(ctx context.Context, payload primitive.TemplateRequest) (primitive.TemplateResponse, error)
+	ListTemplate(ctx context.Context, documentType string) ([]primitive.TemplateResponse, error)
+	GetTemplate(ctx context.Context, id int64) (primitive.TemplateResponse, error)
+	UpdateTemplate(ctx context.Context, id int64, payload primitive.TemplateRequest) (primitive.TemplateResponse, error)
+	DeleteTemplate(ctx context.Context, id int64) error
+	DryRunTemplate(ctx context.Context, payload primitive.TemplateDryRunRequest) (primitive.TemplateDryRunResponse, error)
+}
+
+type Service struct {
+	repository RepositoryInterface
+	records    RecordReader
+}
+
+func NewService(repository RepositoryInterface, records RecordReader) ServiceInterface {
+	return &Service{
+		repository: repository,
+		records:    records,
+	}
+}
+
+func toTemplateResponse(data primitive.Template) primitive.TemplateResponse {
+	return primitive.TemplateResponse{
+		ID:           data.ID,
+		TenantID:     data.TenantID,
+		Name:         data.Name,
+		DocumentType: data.DocumentType,
+		Rules:        DecodeRules(data.Rules),
+		CreatedAt:    data.CreatedAt,
+		UpdatedAt:    data.UpdatedAt,
+	}
+}
+
+// Validate checks the template before it is stored or run, the document type must be one of the profiles
+func Validate(payload primitive.TemplateRequest) error {
+	if strings.TrimSpace(payload.Name) == "" || len(payload.Name) > maxNameLength {
+		return fmt.Errorf("%w: name must have 1 to %d characters", primitive.ErrorTemplateNotValid, maxNameLength)
+	}
+
+	documentTypes := make([]string, 0)
+	for _, profile := range config.Current().Profiles {
+		documentTypes = append(documentTypes, profile.Name)
+	}
+	if !utils.Contains(documentTypes, payload.DocumentType) {
+		return fmt.Errorf("%w: documentType %q, use one of %s",
+			primitive.ErrorDocumentTypeNotValid, payload.DocumentType, strings.Join(documentTypes, ", "))
+	}
+
+	if len(payload.Rules) == 0 || len(payload.Rules) > maxRules {
+		return fmt.Errorf("%w: give 1 to %d rules", primitive.ErrorTemplateNotValid, maxRules)
+	}
+	for idx, rule := range payload.Rules {
+		if err := validateRule(rule); err != nil {
+			return fmt.Errorf("%w: rule %d: %v", primitive.ErrorTemplateNotValid, idx+1, err)
+		}
+	}
+	return nil
+}
+
+func validateRule(rule primitive.TemplateRule) error {
+	if !validField.MatchString(rule.Field) {
+		return fmt.Errorf("field %q must have up to 64 lower case letters, digits and _", rule.Field)
+	}
+
+	kinds := 0
+	if rule.Regex != "" {
+		kinds++
+		if _, err := regexp.Compile(rule.Regex); err != nil {
+			return fmt.Errorf("regex is not valid: %v", err)
+		}
+	}
+	if rule.Anchor != nil {
+		kinds++
+		anchor := rule.Anchor
+		if strings.TrimSpace(anchor.Keyword) == "" {
+			return fmt.Errorf("anchor keyword is required")
+		}
+		if anchor.OffsetX < -1 || anchor.OffsetX > 1 || anchor.OffsetY < -1 || anchor.OffsetY > 1 {
+			return fmt.Errorf("anchor offsets must be between -1 and 1")
+		}
+		if err := validateSize(anchor.Width, anchor.Height); err != nil {
+			return fmt.Errorf("anchor %v", err)
+		}
+		if _, err := optionalPattern(anchor.Pattern); err != nil {
+			return fmt.Errorf("anchor pattern is not valid: %v", err)
+		}
+	}
+	if rule.Zone != nil {
+		kinds++
+		zone := rule.Zone
+		if zone.X < 0 || zone.X >= 1 || zone.Y < 0 || zone.Y >= 1 {
+			return fmt.Errorf("zone x and y must be from 0 to below 1")
+		}
+		if err := validateSize(zone.Width, zone.Height); err != nil {
+			return fmt.Errorf("zone %v", err)
+		}
+		if zone.X+zone.Width > 1 || zone.Y+zone.Height > 1 {
+			return fmt.Errorf("zone must be inside the page")
+		}
+		if _, err := optionalPattern(zone.Pattern); err != nil {
+			return fmt.Errorf("zone pattern is not valid: %v", err)
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("give exactly one of regex, anchor or zone")
+	}
+	return nil
+}
+
+func validateSize(width, height float64) error {
+	if width <= 0 || width > 1 || height <= 0 || height > 1 {
+		return fmt.Errorf("width and height must be above 0 and at most 1")
+	}
+	return nil
+}
+
+// checkNameAvailable rejects a name taken by another template of the tenant
+func (s *Service) checkNameAvailable(ctx context.Context, tenantID, name string, id int64) error {
+	found, err := s.repository.FindTemplateByName(ctx, tenantID, name)
+	if err != nil {
+		if utils.ContainsError(err, []error{gorm.ErrRecordNotFound, primitive.ErrorTemplateNotFound}) {
+			return nil
+		}
+		return err
+	}
+	if found.ID != id {
+		return primitive.ErrorTemplateNameExists
+	}
+	return nil
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, payload primitive.TemplateRequest) (primitive.TemplateResponse, error) {
+	logCtx := "service.CreateTemplate"
+
+	if err := Validate(payload); err != nil {
+		return primitive.TemplateResponse{}, err
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	if err := s.checkNameAvailable(ctx, tenantID, payload.Name, 0); err != nil {
+		return primitive.TemplateResponse{}, err
+	}
+
+	now := time.Now()
+	data, err := s.repository.CreateTemplate(ctx, primitive.Template{
+		TenantID:     tenantID,
+		Name:         payload.Name,
+		DocumentType: payload.DocumentType,
+		Rules:        EncodeRules(payload.Rules),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.CreateTemplate")
+		return primitive.TemplateResponse{}, err
+	}
+
+	return toTemplateResponse(data), nil
+}
+
+func (s *Service) ListTemplate(ctx context.Context, documentType string) ([]primitive.TemplateResponse, error) {
+	logCtx := "service.ListTemplate"
+
+	listData, err := s.repository.FindAllTemplate(ctx, tenant.FromContext(ctx), documentType)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.FindAllTemplate")
+		return nil, err
+	}
+
+	list := make([]primitive.TemplateResponse, 0, len(listData))
+	for _, val := range listData {
+		list = append(list, toTemplateResponse(val))
+	}
+
+	return list, nil
+}
+
+func (s *Service) GetTemplate(ctx context.Context, id int64) (primitive.TemplateResponse, error) {
+	logCtx := "service.GetTemplate"
+
+	data, err := s.repository.FindTemplateByID(ctx, tenant.FromContext(ctx), id)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.FindTemplateByID")
+		return primitive.TemplateResponse{}, err
+	}
+
+	return toTemplateResponse(data), nil
+}
+
+func (s *Service) UpdateTemplate(ctx context.Context, id int64, payload primitive.TemplateRequest) (primitive.TemplateResponse, error) {
+	logCtx := "service.UpdateTemplate"
+
+	if err := Validate(payload); err != nil {
+		return primitive.TemplateResponse{}, err
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	data, err := s.repository.FindTemplateByID(ctx, tenantID, id)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.FindTemplateByID")
+		return primitive.TemplateResponse{}, err
+	}
+	if err = s.checkNameAvailable(ctx, tenantID, payload.Name, id); err != nil {
+		return primitive.TemplateResponse{}, err
+	}
+
+	data.Name = payload.Name
+	data.DocumentType = payload.DocumentType
+	data.Rules = EncodeRules(payload.Rules)
+	data.UpdatedAt = time.Now()
+	data, err = s.repository.UpdateTemplate(ctx, data)
+	if err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.UpdateTemplate")
+		return primitive.TemplateResponse{}, err
+	}
+
+	return toTemplateResponse(data), nil
+}
+
+func (s *Service) DeleteTemplate(ctx context.Context, id int64) error {
+	logCtx := "service.DeleteTemplate"
+
+	if err := s.repository.DeleteTemplate(ctx, tenant.FromContext(ctx), id); err != nil {
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.repository.DeleteTemplate")
+		return err
+	}
+	return nil
+}
+
+// DryRunTemplate runs a stored template, or the one of the request, on the words of a record
+// without storing anything, to try the rules before saving them
+func (s *Service) DryRunTemplate(ctx context.Context, payload primitive.TemplateDryRunRequest) (primitive.TemplateDryRunResponse, error) {
+	logCtx := "service.DryRunTemplate"
+
+	if (payload.TemplateID == 0) == (payload.Template == nil) {
+		return primitive.TemplateDryRunResponse{}, fmt.Errorf("%w: give either templateId or template", primitive.ErrorTemplateNotValid)
+	}
+
+	var rules []primitive.TemplateRule
+	if payload.Template != nil {
+		if err := Validate(*payload.Template); err != nil {
+			return primitive.TemplateDryRunResponse{}, err
+		}
+		rules = payload.Template.Rules
+	}
+
+	document, err := s.records.GetOcrDocument(ctx, payload.RecordID)
+	if err != nil {
+		if utils.ContainsError(err, []error{gorm.ErrRecordNotFound, primitive.ErrorArticleNotFound}) {
+			return primitive.TemplateDryRunResponse{}, primitive.ErrorArticleNotFound
+		}
+		logger.Error(ctx, utils.ErrorLogFormat, err.Error(), logCtx, "s.records.GetOcrDocument")
+		return primitive.TemplateDryRunResponse{}, err
+	}
+
+	if payload.TemplateID != 0 {
+		data, errFind := s.repository.FindTemplateByID(ctx, tenant.FromContext(ctx), payload.TemplateID)
+		if errFind != nil {
+			if errors.Is(errFind, gorm.ErrRecordNotFound) {
+				return primitive.TemplateDryRunResponse{}, primitive.ErrorTemplateNotFound
+			}
+			logger.Error(ctx, utils.ErrorLogFormat, errFind.Error(), logCtx, "s.repository.FindTemplateByID")
+			return primitive.TemplateDryRunResponse{}, errFind
+		}
+		rules = DecodeRules(data.Rules)
+	}
+
+	return primitive.TemplateDryRunResponse{
+		RecordID:   payload.RecordID,
+		TemplateID: payload.TemplateID,
+		Fields:     Extract(document, rules),
+	}, nil
+}
@@ -0,0 +1,50 @@
+package router
+
+import (
+	ocrv1 "go-ocr/api/ocr/v1"
+	"go-ocr/infrastructure/auth"
+	"go-ocr/infrastructure/config"
+	"go-ocr/infrastructure/middleware"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+func (hr *HandlerRouter) GrpcServer() *grpc.Server {
+	//the permission of each method, like the read and write permission of the http methods
+	permissions := map[string]string{}
+	if config.Conf.Auth.EnableApiKey || config.Conf.Auth.EnableJwt {
+		permissions = map[string]string{
+			ocrv1.OcrService_Recognize_FullMethodName:       auth.PermissionOcrWrite,
+			ocrv1.OcrService_RecognizeStream_FullMethodName: auth.PermissionOcrWrite,
+			ocrv1.OcrService_GetResult_FullMethodName:       auth.PermissionOcrRead,
+			ocrv1.OcrService_ListResults_FullMethodName:     auth.PermissionOcrRead,
+		}
+	}
+	var authenticator auth.Authenticator
+	if config.Conf.Auth.EnableApiKey {
+		authenticator = hr.Setup.Authenticator
+	}
+
+	//same order as the http middlewares of the ocr group
+	steps := []middleware.GrpcStep{
+		middleware.GrpcCorrelationID(),
+		middleware.GrpcRateLimiter(hr.Setup.IpLimiter),
+		middleware.GrpcAuth(authenticator, hr.Setup.JwtVerifier, permissions),
+		middleware.GrpcIdentityRateLimiter(hr.Setup.Limiter),
+		middleware.GrpcTenant(),
+		middleware.GrpcTenantRateLimiter(hr.Setup.TenantLimiters),
+	}
+
+	//start the server span, continue the trace from the W3C trace context metadata
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(middleware.UnaryInterceptor(steps...)),
+		grpc.ChainStreamInterceptor(middleware.StreamInterceptor(steps...)),
+	)
+
+	//module ocr
+	hr.Setup.OcrGrpc.RegisterOcr(server)
+
+	return server
+}
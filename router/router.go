@@ -3,14 +3,22 @@ package router
 import (
 	"net/http"
 	"os"
+	"time"
 
+	"go-ocr/api/openapi"
 	"go-ocr/boot"
+	"go-ocr/infrastructure/auth"
 	"go-ocr/infrastructure/config"
 	"go-ocr/infrastructure/httplib"
+	"go-ocr/infrastructure/idempotency"
+	"go-ocr/infrastructure/metrics"
 	"go-ocr/infrastructure/middleware"
+	"go-ocr/infrastructure/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/grpc"
 )
 
 type HandlerRouter struct {
@@ -25,6 +33,7 @@ func NewHandlerRouter(setup boot.HandlerSetup) InterfaceRouter {
 
 type InterfaceRouter interface {
 	RouterWithMiddleware() *gin.Engine
+	GrpcServer() *grpc.Server
 }
 
 func notFoundHandler(c *gin.Context) {
@@ -44,6 +53,16 @@ func (hr *HandlerRouter) RouterWithMiddleware() *gin.Engine {
 	//and method with not allowed handler
 	c := gin.New()
 
+	//let the gin context fallback to the request context, so the span and the
+	//values set on the request context are visible from the handlers context
+	c.ContextWithFallback = true
+
+	//only take the client ip of the X-Forwarded-For header from the configured proxies,
+	//anyone else could set it to get a new rate limit bucket on each request
+	if err := c.SetTrustedProxies(config.Conf.TrustedProxies); err != nil {
+		panic(err)
+	}
+
 	//use recovery
 	c.Use(gin.Recovery())
 
@@ -52,6 +71,15 @@ func (hr *HandlerRouter) RouterWithMiddleware() *gin.Engine {
 		c.Status(http.StatusOK)
 	})
 
+	//record the http metrics of every request
+	c.Use(middleware.MetricsMiddleware())
+
+	//start the server span, continue the trace from the W3C trace context headers
+	c.Use(otelgin.Middleware(tracing.TracerName))
+
+	//accept or generate the correlation id, used by the logs and the error bodies
+	c.Use(middleware.CorrelationIDMiddleware())
+
 	//use cors need to updated if the requested need specific allow origin
 	c.Use(cors.Default())
 
@@ -80,13 +108,30 @@ func (hr *HandlerRouter) RouterWithMiddleware() *gin.Engine {
 	//set middleware to use method not allowed
 	c.NoMethod(methodNotAllowedHandler)
 
-	//serve static files
-	c.Static("/uploads", "./uploads")
+	//expose the metrics in prometheus text format
+	c.GET("/metrics", metrics.Handler())
+
+	//serve the uploaded images of the tenant of the caller, protected like the ocr records
+	prefixUpload := c.Group("/uploads")
+	prefixUpload.Use(middleware.RateLimiterMiddleware(hr.Setup.IpLimiter))
+	prefixUpload.Use(hr.recordAuthMiddlewares()...)
+	prefixUpload.Use(middleware.TenantMiddleware(), middleware.TenantRateLimiterMiddleware(hr.Setup.TenantLimiters))
+	hr.Setup.OcrHttp.GroupUpload(prefixUpload)
+
+	//serve the openapi document and the swagger ui, outside of the rate limit
+	c.GET("/api/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", openapi.Spec)
+	})
+	c.GET("/api/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.SwaggerUI)
+	})
+	c.StaticFS("/api/docs/assets", openapi.SwaggerAssets)
 
 	//grouping on root endpoint
 	api := c.Group("/api")
 
-	api.Use(middleware.RateLimiterMiddleware(hr.Setup.Limiter))
+	//limit by client ip before the credential is verified with its own more generous limit, the identity is limited after the auth
+	api.Use(middleware.RateLimiterMiddleware(hr.Setup.IpLimiter))
 
 	//grouping on "api/v1"
 	v1 := api.Group("/v1")
@@ -95,10 +140,45 @@ func (hr *HandlerRouter) RouterWithMiddleware() *gin.Engine {
 	prefixHealth := v1.Group("/health")
 	hr.Setup.HealthHttp.GroupHealth(prefixHealth)
 
-	//module ocr
+	//module ocr, protected by api key or jwt when enabled
 	prefixOcr := v1.Group("/ocr")
+	prefixOcr.Use(hr.recordAuthMiddlewares()...)
+	prefixOcr.Use(middleware.TenantMiddleware(), middleware.TenantRateLimiterMiddleware(hr.Setup.TenantLimiters))
+	prefixOcr.Use(middleware.IdempotencyMiddleware(hr.Setup.IdempotencyStore, func() time.Duration {
+		return idempotency.ParseTTL(config.Current().Idempotency.TTL)
+	}))
 	hr.Setup.OcrHttp.GroupOcr(prefixOcr)
 
+	//module template, its templates belong to the tenant like the ocr records
+	prefixTemplate := v1.Group("/templates")
+	prefixTemplate.Use(hr.recordAuthMiddlewares()...)
+	prefixTemplate.Use(middleware.TenantMiddleware(), middleware.TenantRateLimiterMiddleware(hr.Setup.TenantLimiters))
+	hr.Setup.TemplateHttp.GroupTemplate(prefixTemplate)
+
+	//module api key, always need the admin permission
+	prefixApiKey := v1.Group("/admin/api-keys")
+	prefixApiKey.Use(middleware.AuthMiddleware(hr.Setup.Authenticator, hr.Setup.JwtVerifier),
+		middleware.IdentityRateLimiterMiddleware(hr.Setup.Limiter),
+		middleware.RequirePermission(auth.PermissionOcrAdmin))
+	hr.Setup.ApiKeyHttp.GroupApiKey(prefixApiKey)
+
 	return c
 
 }
+
+// recordAuthMiddlewares returns the auth of the routes of the tenant records, the api key or the
+// jwt when enabled with the read permission for the safe methods and the write one otherwise
+func (hr *HandlerRouter) recordAuthMiddlewares() []gin.HandlerFunc {
+	if !config.Conf.Auth.EnableApiKey && !config.Conf.Auth.EnableJwt {
+		return nil
+	}
+	var authenticator auth.Authenticator
+	if config.Conf.Auth.EnableApiKey {
+		authenticator = hr.Setup.Authenticator
+	}
+	return []gin.HandlerFunc{
+		middleware.AuthMiddleware(authenticator, hr.Setup.JwtVerifier),
+		middleware.IdentityRateLimiterMiddleware(hr.Setup.Limiter),
+		middleware.RequireReadWritePermission(auth.PermissionOcrRead, auth.PermissionOcrWrite),
+	}
+}
@@ -0,0 +1,101 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go-ocr/api/openapi"
+	"go-ocr/boot"
+	"go-ocr/infrastructure/events"
+	"go-ocr/infrastructure/idempotency"
+	"go-ocr/infrastructure/limiter"
+	"go-ocr/modules/apikey"
+	"go-ocr/modules/health"
+	"go-ocr/modules/ocr"
+	"go-ocr/modules/template"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ginPathParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+func newTestEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	templateRepository := template.NewInMemoryRepository()
+	ocrService := ocr.NewService(ocr.NewInMemoryRepositoryRepositoryAdapter(), nil, events.NewInMemoryBroker(0, 0), templateRepository, nil)
+	apiKeyService := apikey.NewService(apikey.NewInMemoryRepository())
+	setup := boot.HandlerSetup{
+		Limiter:          limiter.NewRateLimiter(1, 1, 1),
+		IpLimiter:        limiter.NewRateLimiter(1, 1, 1),
+		TenantLimiters:   map[string]limiter.LimiterInterface{},
+		IdempotencyStore: idempotency.NewInMemoryStore(),
+		Authenticator:    apiKeyService,
+		HealthHttp:       health.NewHttp(health.NewService(nil, nil)),
+		OcrService:       ocrService,
+		OcrHttp:          ocr.NewHttp(ocrService),
+		ApiKeyHttp:       apikey.NewHttp(apiKeyService),
+		TemplateHttp:     template.NewHttp(template.NewService(templateRepository, ocrService)),
+	}
+	return NewHandlerRouter(setup).RouterWithMiddleware()
+}
+
+func loadSpecPaths(t *testing.T) map[string]map[string]json.RawMessage {
+	t.Helper()
+	var spec struct {
+		OpenApi string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid json: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenApi, "3.") {
+		t.Fatalf("openapi version = %q, want 3.x", spec.OpenApi)
+	}
+	return spec.Paths
+}
+
+// TestEveryRouteIsInTheSpec fails when a route is registered without being documented
+func TestEveryRouteIsInTheSpec(t *testing.T) {
+	paths := loadSpecPaths(t)
+
+	for _, route := range newTestEngine(t).Routes() {
+		// the preflight handler answers any path
+		if route.Method == http.MethodOptions {
+			continue
+		}
+		path := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+		operations, ok := paths[path]
+		if !ok {
+			t.Errorf("route %s %s is missing from openapi.json, expected path %s", route.Method, route.Path, path)
+			continue
+		}
+		if _, ok := operations[strings.ToLower(route.Method)]; !ok {
+			t.Errorf("route %s %s is missing from openapi.json, expected operation %s of %s", route.Method, route.Path, strings.ToLower(route.Method), path)
+		}
+	}
+}
+
+// TestEverySpecOperationIsRouted fails when the spec documents a route that doesn't exist
+func TestEverySpecOperationIsRouted(t *testing.T) {
+	routed := map[string]bool{}
+	for _, route := range newTestEngine(t).Routes() {
+		routed[route.Method+" "+ginPathParam.ReplaceAllString(route.Path, "{$1}")] = true
+	}
+
+	methods := map[string]bool{"get": true, "post": true, "put": true, "patch": true, "delete": true, "head": true}
+	for path, operations := range loadSpecPaths(t) {
+		for method := range operations {
+			if !methods[method] {
+				continue
+			}
+			if !routed[strings.ToUpper(method)+" "+path] {
+				t.Errorf("openapi.json documents %s %s but the router doesn't register it", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
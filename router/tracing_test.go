@@ -0,0 +1,103 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans sets a tracer provider keeping the ended spans in memory, before the engine is built
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestSpanTree(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	remoteTraceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	remoteSpanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	// every span and the name of its parent, empty for the server span
+	wantParents := map[string]string{
+		"/api/v1/ocr/export": "",
+		"Http.ExportOcr":     "/api/v1/ocr/export",
+		"Service.ExportOcr":  "Http.ExportOcr",
+	}
+
+	tests := []struct {
+		name        string
+		traceParent string
+	}{
+		{name: "new trace"},
+		{name: "trace continued from the caller", traceParent: traceParent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			engine := newTestEngine(t)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/ocr/export?format=csv", nil)
+			if tt.traceParent != "" {
+				req.Header.Set("traceparent", tt.traceParent)
+			}
+			response := httptest.NewRecorder()
+			engine.ServeHTTP(response, req)
+			if response.Code != http.StatusOK {
+				t.Fatalf("GET /api/v1/ocr/export status = %d, want %d", response.Code, http.StatusOK)
+			}
+
+			spans := recorder.Ended()
+			byID := make(map[trace.SpanID]sdktrace.ReadOnlySpan, len(spans))
+			for _, span := range spans {
+				byID[span.SpanContext().SpanID()] = span
+			}
+			if len(spans) != len(wantParents) {
+				t.Fatalf("got %d spans, want %d", len(spans), len(wantParents))
+			}
+
+			traceID := spans[0].SpanContext().TraceID()
+			for _, span := range spans {
+				wantParent, ok := wantParents[span.Name()]
+				if !ok {
+					t.Fatalf("got unexpected span %s", span.Name())
+				}
+				if span.SpanContext().TraceID() != traceID {
+					t.Fatalf("span %s is on trace %s, want %s", span.Name(), span.SpanContext().TraceID(), traceID)
+				}
+
+				if wantParent == "" {
+					if span.SpanKind() != trace.SpanKindServer {
+						t.Fatalf("span %s kind = %s, want server", span.Name(), span.SpanKind())
+					}
+					// the server span is the root, or the child of the span of the caller
+					if tt.traceParent == "" && span.Parent().IsValid() {
+						t.Fatalf("span %s has parent %s, want none", span.Name(), span.Parent().SpanID())
+					}
+					if tt.traceParent != "" && (span.Parent().TraceID() != remoteTraceID || span.Parent().SpanID() != remoteSpanID || !span.Parent().IsRemote()) {
+						t.Fatalf("span %s has parent %+v, want the span of the traceparent header", span.Name(), span.Parent())
+					}
+					continue
+				}
+				parent, ok := byID[span.Parent().SpanID()]
+				if !ok || parent.Name() != wantParent {
+					t.Fatalf("span %s has parent %v, want %s", span.Name(), span.Parent().SpanID(), wantParent)
+				}
+			}
+		})
+	}
+}
@@ -40,33 +40,28 @@ func ContainsError(err error, errorTargetSlice []error) bool {
 	return false
 }
 
+// durationUnits are the units accepted by StringUnitToDuration
+var durationUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
 func StringUnitToDuration(input string) time.Duration {
-	durationMapping := map[string]time.Duration{
-		"second": time.Second,
-		"minute": time.Minute,
-		"hour":   time.Hour,
-		"day":    24 * time.Hour,
-		"week":   7 * 24 * time.Hour,
-		"month":  30 * 24 * time.Hour,
-		"year":   365 * 24 * time.Hour,
+	if duration, ok := durationUnits[input]; ok {
+		return duration
 	}
+	return time.Second
+}
 
-	switch input {
-	case "second":
-		return durationMapping["second"]
-	case "minute":
-		return durationMapping["minute"]
-	case "hour":
-		return durationMapping["hour"]
-	case "week":
-		return durationMapping["week"]
-	case "month":
-		return durationMapping["month"]
-	case "year":
-		return durationMapping["year"]
-	default:
-		return time.Second
-	}
+// IsDurationUnit tells whether StringUnitToDuration knows the unit instead of falling back to a second
+func IsDurationUnit(input string) bool {
+	_, ok := durationUnits[input]
+	return ok
 }
 
 func IsDisablePagination(ctx *gin.Context) bool {